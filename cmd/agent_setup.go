@@ -64,6 +64,15 @@ Chat (interactive):
 Chat (single-turn):
   openrouter chat -p "Explain Go concurrency"
   openrouter chat -m google/gemini-2.5-flash -p "Hello"
+  openrouter chat -p "Hello" -o json          # content, model, usage, finish_reason, id
+  git diff | openrouter chat -p "Review this diff"
+  openrouter chat -p "Describe this" -f photo.png
+  openrouter chat -p "Summarize" -f big.txt --dry-run  # Estimate tokens and cost, print the body, send nothing
+
+Piped output is raw text by default (no markdown rendering).
+
+Estimate tokens and cost:
+  openrouter tokens -f report.md -m anthropic/claude-sonnet-4
 
 List models:
   openrouter models
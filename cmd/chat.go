@@ -25,7 +25,7 @@ type modelsLoadErrorMsg struct {
 // loadModelsCmd fetches models asynchronously from the API
 func loadModelsCmd(apiKey string) tea.Cmd {
 	return func() tea.Msg {
-		client := api.DefaultClient(apiKey, timeout)
+		client := newClient(apiKey)
 		models, err := client.ListModels(context.Background(), nil)
 		if err != nil {
 			return modelsLoadErrorMsg{err: err}
@@ -39,18 +39,24 @@ type chatWrapper struct {
 	chat               chat.Model
 	apiKey             string
 	showingPicker      bool
-	pickerModel        picker.Model
+	pickerModel        picker.SessionPicker
 	showingModelPicker bool
 	modelPickerModel   picker.Model
 	width              int
 	height             int
 }
 
-func newChatWrapper(apiKey, modelName string, existingSession *config.Session) chatWrapper {
-	client := api.DefaultClient(apiKey, timeout)
+func newChatWrapper(apiKey string, models []string, settings chatSettings, existingSession *config.Session) chatWrapper {
+	client := newClient(apiKey)
 	chatModel := chat.New(chat.Config{
 		Client:          client,
-		ModelName:       modelName,
+		ModelName:       models[0],
+		Fallbacks:       models[1:],
+		Params:          settings.Params,
+		Reasoning:       settings.Reasoning,
+		Provider:        settings.Provider,
+		SystemPrompt:    settings.System,
+		CompactModel:    settings.CompactModel,
 		ExistingSession: existingSession,
 	})
 
@@ -106,7 +112,7 @@ func (m chatWrapper) View() string {
 
 	// Show session picker if active
 	if m.showingPicker {
-		return m.pickerModel.View() + "\n" + tui.HelpStyle.Render("Enter: select | Esc: cancel | /: filter")
+		return m.pickerModel.View() + "\n" + tui.HelpStyle.Render(m.pickerModel.Help("Enter: select | Esc: cancel | /: filter"))
 	}
 
 	return m.chat.View()
@@ -120,6 +126,7 @@ func (m chatWrapper) showSessionPicker() (tea.Model, tea.Cmd) {
 	}
 
 	m.pickerModel = picker.NewSessionPicker(summaries, m.width, m.height)
+	m.pickerModel.SetOpen(m.chat.Session().ID)
 	m.showingPicker = true
 	return m, nil
 }
@@ -134,6 +141,10 @@ func (m chatWrapper) updatePicker(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 
 	case tea.KeyMsg:
+		// Confirming a delete or editing a title takes every key
+		if m.pickerModel.Busy() {
+			break
+		}
 		switch msg.String() {
 		case "esc":
 			// If filtering is active, let the picker handle it
@@ -160,9 +171,9 @@ func (m chatWrapper) updatePicker(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 				// Update chat with loaded session
-				m.chat.SetSession(session)
+				cmd := m.chat.SetSession(session)
 				m.showingPicker = false
-				return m, nil
+				return m, cmd
 			}
 		}
 	}
@@ -226,6 +237,12 @@ func (m chatWrapper) updateModelPicker(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if model := picker.GetModel(m.modelPickerModel.SelectedItem()); model != nil {
 				// Update the model
 				m.chat.SetModelName(model.ID)
+				// Drop pending attachments the new model can't accept
+				m.chat.SetModelDetails(model)
+				// Warn about parameters the new model will not honor
+				if err := model.ValidateParameters(m.chat.ParameterNames()); err != nil {
+					m.chat.SetErr(err)
+				}
 				m.showingModelPicker = false
 				return m, nil
 			}
@@ -238,17 +255,21 @@ func (m chatWrapper) updateModelPicker(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-func runChat(apiKey, modelName string) error {
-	return runChatWithSession(apiKey, modelName, nil)
+// runChat starts an interactive chat with a model fallback chain, primary first.
+func runChat(apiKey string, models []string, settings chatSettings) error {
+	return runChatWithSession(apiKey, models, settings, nil)
 }
 
-func runChatWithSession(apiKey, modelName string, session *config.Session) error {
+func runChatWithSession(apiKey string, models []string, settings chatSettings, session *config.Session) error {
 	p := tea.NewProgram(
-		newChatWrapper(apiKey, modelName, session),
+		newChatWrapper(apiKey, models, settings, session),
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(), // Enable mouse to handle scroll wheel properly
 	)
 
-	_, err := p.Run()
+	final, err := p.Run()
+	if m, ok := final.(chatWrapper); ok {
+		m.chat.Close()
+	}
 	return err
 }
@@ -1,14 +1,34 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/spf13/cobra"
+	"github.com/vstratful/openrouter-cli/internal/api"
 	"github.com/vstratful/openrouter-cli/internal/config"
 )
 
 var (
-	chatModel  string
-	chatPrompt string
-	chatStream bool
+	chatModels    []string
+	chatPrompt    string
+	chatFiles     []string
+	chatSystem    string
+	chatSysFile   string
+	chatPreset    string
+	chatStream    bool
+	chatUsage     bool
+	chatOutput    string
+	chatJSON      bool
+	chatSchema    string
+	chatStrict    bool
+	chatDryRun    bool
+	chatSampling  samplingFlags
+	chatReasoning reasoningFlags
+	chatProvider  providerFlags
 )
 
 var chatCmd = &cobra.Command{
@@ -23,16 +43,64 @@ Modes:
 Examples:
   openrouter chat                                 # Interactive chat
   openrouter chat -m anthropic/claude-3.5-sonnet  # With specific model
+  openrouter chat -m openai/gpt-4o -m anthropic/claude-3.5-sonnet  # With a fallback
   openrouter chat -p "Explain Go concurrency"     # Single-turn mode
-  openrouter chat -p "Hello" --stream=false       # Without streaming`,
+  openrouter chat -p "Hello" --stream=false       # Without streaming
+  openrouter chat -p "Write a haiku" --temperature 1.2 --max-tokens 100
+  openrouter chat -p "Hello" --usage              # Print tokens and cost
+  openrouter chat -p "Hello" -o json              # Content, model, usage as JSON
+  openrouter chat --reasoning-effort high         # Request more thinking
+  openrouter chat --system "Answer like a pirate" # Set a system prompt
+  openrouter chat --preset reviewer               # Use a saved preset
+  openrouter chat --provider-order groq,together --allow-fallbacks=false
+  openrouter chat -p "List 3 colors" --json       # Any JSON object
+  openrouter chat -p "Extract the people" --schema people.json
+  git diff | openrouter chat -p "Review this diff"  # Prompt plus piped input
+  openrouter chat -p "Summarize" -f report.pdf -f chart.png
+  openrouter chat -p "Summarize" -f big.txt --dry-run  # Estimate without sending
+
+Structured output (--json or --schema) requires --prompt. With --schema the
+response is validated locally against the JSON Schema; if it does not match,
+the request is retried once with the validation error. The result is printed as
+compact JSON and the command exits non-zero if it still does not validate.
+
+--output selects how single-turn responses are printed: text (raw content),
+markdown (rendered), json (one object with the content, model, provider,
+usage, finish reason and generation id) or jsonl (one object per streamed
+chunk). When stdout is not a terminal the default is text.
+
+When input is piped in, it is appended to the --prompt text (or used as the
+prompt on its own) and the command runs in single-turn mode. --file attaches
+images, PDFs and text files to the prompt; each input is limited to 20 MiB.
+
+--dry-run sends nothing: it prints the estimated prompt tokens and cost for
+each model to stderr and the JSON request body to stdout.
+
+A preset is a JSON file in the presets directory of the config directory
+(presets/<name>.json) with any of "system_prompt", "model", "params" (the
+sampling parameters, e.g. {"temperature": 0.2}) and "reasoning". Flags given
+on the command line override the preset.`,
 	RunE: runChatCommand,
 }
 
 func init() {
 	rootCmd.AddCommand(chatCmd)
-	chatCmd.Flags().StringVarP(&chatModel, "model", "m", "", "Model to use (default: "+config.DefaultModel+")")
+	chatCmd.Flags().StringArrayVarP(&chatModels, "model", "m", nil, "Model to use; repeat or comma-separate for a fallback chain (default: "+config.DefaultModel+")")
 	chatCmd.Flags().StringVarP(&chatPrompt, "prompt", "p", "", "Prompt for single-turn mode (omit for interactive chat)")
+	chatCmd.Flags().StringArrayVarP(&chatFiles, "file", "f", nil, "Attach an image, PDF or text file to the prompt (repeatable, single-turn mode)")
+	chatCmd.Flags().StringVar(&chatSystem, "system", "", "System prompt sent before the conversation")
+	chatCmd.Flags().StringVar(&chatSysFile, "system-file", "", "Read the system prompt from a file")
+	chatCmd.Flags().StringVar(&chatPreset, "preset", "", "Use a named preset (system prompt, model and parameters)")
 	chatCmd.Flags().BoolVarP(&chatStream, "stream", "s", true, "Stream the response (default: true)")
+	chatCmd.Flags().StringVarP(&chatOutput, "output", "o", "", "Output format: text, markdown, json or jsonl (default: markdown on a terminal, text otherwise)")
+	chatCmd.Flags().BoolVar(&chatUsage, "usage", false, "Print token usage and cost to stderr (single-turn mode)")
+	chatCmd.Flags().BoolVar(&chatJSON, "json", false, "Require a JSON object response (single-turn mode)")
+	chatCmd.Flags().StringVar(&chatSchema, "schema", "", "JSON Schema file the response must match (single-turn mode)")
+	chatCmd.Flags().BoolVar(&chatStrict, "schema-strict", true, "Ask the provider to enforce the schema strictly")
+	chatCmd.Flags().BoolVar(&chatDryRun, "dry-run", false, "Print the estimated tokens and cost and the request body without sending (single-turn mode)")
+	chatSampling.register(chatCmd)
+	chatReasoning.register(chatCmd)
+	chatProvider.register(chatCmd)
 }
 
 func runChatCommand(cmd *cobra.Command, args []string) error {
@@ -48,17 +116,153 @@ func runChatCommand(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Use default model if not specified
-	modelName := chatModel
-	if modelName == "" {
-		modelName = cfg.DefaultModel
+	var preset *config.Preset
+	if chatPreset != "" {
+		if preset, err = loadPreset(chatPreset); err != nil {
+			return err
+		}
+	}
+
+	// Use the preset's or the default model if not specified
+	models := parseModelChain(chatModels)
+	if len(models) == 0 && preset != nil {
+		models = parseModelChain([]string{preset.Model})
+	}
+	if len(models) == 0 {
+		models = []string{cfg.DefaultModel}
+	}
+	system, err := systemPromptFromFlags(preset)
+	if err != nil {
+		return err
+	}
+
+	// Piped input joins the prompt and selects single-turn mode
+	prompt := chatPrompt
+	if !isTerminal(os.Stdin) {
+		input, err := readInput(os.Stdin)
+		if err != nil {
+			return err
+		}
+		prompt = combinePrompt(prompt, input)
+	}
+	files, err := loadFiles(chatFiles)
+	if err != nil {
+		return err
+	}
+	if len(files) > 0 && prompt == "" {
+		return fmt.Errorf("--file requires --prompt or piped input")
+	}
+	if chatDryRun && prompt == "" {
+		return fmt.Errorf("--dry-run requires --prompt or piped input")
+	}
+
+	params, err := chatSampling.params(cmd)
+	if err != nil {
+		return err
+	}
+	reasoning, err := chatReasoning.options(cmd)
+	if err != nil {
+		return err
+	}
+	provider, err := chatProvider.preferences(cmd, cfg.Provider)
+	if err != nil {
+		return err
+	}
+	output, err := structuredOutputFromFlags(prompt)
+	if err != nil {
+		return err
+	}
+	format, err := parseOutputFormat(chatOutput, isTerminal(os.Stdout))
+	if err != nil {
+		return err
+	}
+	if preset != nil {
+		params = preset.Params.Merge(params)
+		if reasoning == nil {
+			reasoning = preset.Reasoning
+		}
+	}
+	settings := chatSettings{
+		System:       system,
+		CompactModel: cfg.CompactModel,
+		Params:       params,
+		Reasoning:    reasoning,
+		Provider:     provider,
+	}
+	if output != nil {
+		settings.ResponseFormat = output.Format
+	}
+	if err := validateModels(newClient(apiKey), models, settings.parameterNames(), files, os.Stderr); err != nil {
+		return err
 	}
 
 	// Interactive chat mode when no prompt provided
-	if chatPrompt == "" {
-		return runChat(apiKey, modelName)
+	if prompt == "" {
+		return runChat(apiKey, models, settings)
 	}
 
 	// Single-turn mode
-	return runPrompt(apiKey, modelName, chatPrompt, chatStream)
+	return runPrompt(apiKey, promptOptions{
+		Models:      models,
+		Prompt:      prompt,
+		Attachments: files,
+		Stream:      chatStream,
+		Settings:    settings,
+		ShowUsage:   chatUsage,
+		Format:      format,
+		Output:      output,
+		DryRun:      chatDryRun,
+	})
+}
+
+// structuredOutputFromFlags builds the structured output settings from the
+// --json and --schema flags. Returns nil when neither is set.
+func structuredOutputFromFlags(prompt string) (*structuredOutput, error) {
+	if chatSchema == "" && !chatJSON {
+		return nil, nil
+	}
+	if prompt == "" {
+		return nil, fmt.Errorf("--json and --schema require --prompt")
+	}
+	if chatSchema != "" && chatJSON {
+		return nil, fmt.Errorf("--json and --schema are mutually exclusive")
+	}
+	if chatJSON {
+		return &structuredOutput{Format: api.NewJSONObjectFormat()}, nil
+	}
+	return loadSchemaFile(chatSchema, chatStrict)
+}
+
+// loadPreset loads a named preset, listing the available presets when it
+// does not exist.
+func loadPreset(name string) (*config.Preset, error) {
+	preset, err := config.LoadPreset(name)
+	if errors.Is(err, config.ErrPresetNotFound) {
+		names, _ := config.ListPresets()
+		dir, _ := config.GetPresetDir()
+		if len(names) == 0 {
+			return nil, fmt.Errorf("%w; create %s", err, filepath.Join(dir, name+".json"))
+		}
+		return nil, fmt.Errorf("%w; available presets: %s", err, strings.Join(names, ", "))
+	}
+	return preset, err
+}
+
+// systemPromptFromFlags returns the system prompt from --system or
+// --system-file, falling back to the preset's.
+func systemPromptFromFlags(preset *config.Preset) (string, error) {
+	if chatSystem != "" && chatSysFile != "" {
+		return "", fmt.Errorf("--system and --system-file are mutually exclusive")
+	}
+	if chatSysFile != "" {
+		data, err := os.ReadFile(chatSysFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read system prompt file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if chatSystem == "" && preset != nil {
+		return preset.SystemPrompt, nil
+	}
+	return chatSystem, nil
 }
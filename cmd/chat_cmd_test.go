@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vstratful/openrouter-cli/internal/config"
+)
+
+func TestSystemPromptFromFlags(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "system.txt")
+	if err := os.WriteFile(file, []byte("From file.\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	preset := &config.Preset{SystemPrompt: "From preset."}
+
+	tests := []struct {
+		name    string
+		system  string
+		file    string
+		preset  *config.Preset
+		want    string
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "flag", system: "From flag.", preset: preset, want: "From flag."},
+		{name: "file", file: file, preset: preset, want: "From file."},
+		{name: "preset", preset: preset, want: "From preset."},
+		{name: "both flags", system: "x", file: file, wantErr: true},
+		{name: "missing file", file: filepath.Join(t.TempDir(), "missing.txt"), wantErr: true},
+	}
+
+	defer func() { chatSystem, chatSysFile = "", "" }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chatSystem, chatSysFile = tt.system, tt.file
+			got, err := systemPromptFromFlags(tt.preset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("systemPromptFromFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("systemPromptFromFlags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,80 +3,239 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
 
 	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/attachment"
 	"github.com/vstratful/openrouter-cli/internal/tui"
 )
 
-// runPrompt sends a single prompt to the API and prints the response.
-func runPrompt(apiKey, model, prompt string, stream bool) error {
-	client := api.DefaultClient(apiKey, timeout)
+// chatSettings holds the request settings shared by single-turn and
+// interactive chat. System is the system prompt sent before the conversation.
+// CompactModel summarizes older messages for the chat's /compact.
+type chatSettings struct {
+	System         string
+	CompactModel   string
+	Params         api.SamplingParams
+	Reasoning      *api.ReasoningOptions
+	ResponseFormat *api.ResponseFormat
+	Provider       *api.ProviderPreferences
+}
+
+// apply copies the settings onto a request.
+func (s chatSettings) apply(req *api.ChatRequest) {
+	req.SamplingParams = s.Params
+	req.Reasoning = s.Reasoning
+	req.ResponseFormat = s.ResponseFormat
+	req.Provider = s.Provider
+}
+
+// parameterNames returns the API parameter names the settings will send.
+func (s chatSettings) parameterNames() []string {
+	var req api.ChatRequest
+	s.apply(&req)
+	return req.ParameterNames()
+}
+
+// promptOptions holds the settings for a single-turn request.
+type promptOptions struct {
+	// Models is the model fallback chain, primary first
+	Models []string
+	Prompt string
+	// Attachments are sent with the prompt as content parts
+	Attachments []*attachment.Attachment
+	Stream      bool
+	Settings    chatSettings
+	ShowUsage   bool
+	// Format is the output format, one of the output* constants
+	Format string
+	// Output requires and validates JSON output when set
+	Output *structuredOutput
+	// DryRun prints the estimate and request body instead of sending
+	DryRun bool
+}
+
+// responseInfo describes a single-turn response and how it was produced.
+// It is printed as-is by --output json.
+type responseInfo struct {
+	ID           string     `json:"id,omitempty"`
+	Model        string     `json:"model,omitempty"`
+	Provider     string     `json:"provider,omitempty"`
+	Content      string     `json:"content"`
+	FinishReason string     `json:"finish_reason,omitempty"`
+	Usage        *api.Usage `json:"usage,omitempty"`
+}
+
+// record copies any response metadata reported in a chunk or response.
+func (r *responseInfo) record(id, model, provider string, finishReason *string, usage *api.Usage) {
+	if id != "" {
+		r.ID = id
+	}
+	if model != "" {
+		r.Model = model
+	}
+	if provider != "" {
+		r.Provider = provider
+	}
+	if finishReason != nil {
+		r.FinishReason = *finishReason
+	}
+	if usage != nil {
+		r.Usage = usage
+	}
+}
+
+// recordResponse copies the metadata of a non-streaming response.
+func (r *responseInfo) recordResponse(resp *api.ChatResponse, usage *api.Usage) {
+	var finishReason *string
+	if len(resp.Choices) > 0 {
+		finishReason = resp.Choices[0].FinishReason
+	}
+	r.record(resp.ID, resp.Model, resp.Provider, finishReason, usage)
+}
+
+// newPromptRequest builds the request for a single prompt. Structured
+// output is never streamed.
+func newPromptRequest(opts promptOptions) *api.ChatRequest {
 	req := &api.ChatRequest{
-		Model: model,
-		Messages: []api.Message{
-			{Role: "user", Content: prompt},
-		},
-		Stream: stream,
+		Messages: api.WithSystemPrompt(opts.Settings.System, []api.Message{
+			attachment.Message(opts.Prompt, opts.Attachments),
+		}),
+		Stream: opts.Stream && opts.Output == nil,
 	}
+	req.SetModels(opts.Models)
+	opts.Settings.apply(req)
+	if opts.ShowUsage || isJSONOutput(opts.Format) {
+		req.Usage = &api.UsageOptions{Include: true}
+	}
+	return req
+}
 
-	if stream {
-		reader, err := client.ChatStream(context.Background(), req)
-		if err != nil {
-			return err
+// runPrompt sends a single prompt to the API and prints the response.
+func runPrompt(apiKey string, opts promptOptions) error {
+	client := newClient(apiKey)
+	req := newPromptRequest(opts)
+
+	// Interrupting cancels the request, including a stream in progress
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if opts.DryRun {
+		return dryRunPrompt(ctx, client, req, opts.Models, os.Stdout, os.Stderr)
+	}
+
+	// Report retries on stderr rather than blocking silently
+	ctx = api.WithRetryNotifier(ctx, func(event api.RetryEvent) {
+		fmt.Fprintln(os.Stderr, tui.FormatRetry(event))
+	})
+
+	out := &responseWriter{w: os.Stdout, format: opts.Format}
+	var info responseInfo
+	var err error
+	switch {
+	case opts.Output != nil:
+		// Structured output is printed as compact JSON unless it is
+		// wrapped in a JSON response object
+		w := io.Writer(os.Stdout)
+		if isJSONOutput(opts.Format) {
+			w = io.Discard
 		}
-		defer reader.Close()
-
-		// Read and print content as it streams
-		var fullContent string
-		for {
-			chunk, err := reader.Next()
-			if err != nil {
-				return err
+		info, err = runStructuredPrompt(ctx, client, req, opts.Output, w)
+		if isJSONOutput(opts.Format) && info.Content != "" {
+			if printErr := out.finish(info); printErr != nil && err == nil {
+				err = printErr
 			}
-			if chunk == nil || chunk.Done {
-				break
-			}
-			fmt.Print(chunk.Content)
-			fullContent += chunk.Content
 		}
+	case opts.Stream:
+		info, err = streamPrompt(ctx, client, req, out)
+	default:
+		info, err = sendPrompt(ctx, client, req, out)
+	}
+	if err != nil && opts.Output == nil {
+		return err
+	}
 
-		// Render final markdown
-		if fullContent != "" {
-			fmt.Print("\r\033[K") // Clear current line
-			renderer, err := tui.NewMarkdownRenderer(80)
-			if err == nil {
-				rendered, renderErr := renderer.Render(fullContent)
-				if renderErr == nil {
-					fmt.Print(rendered)
-					return nil
-				}
-			}
-			fmt.Println(fullContent)
-		} else {
-			fmt.Println()
+	printResponseInfo(os.Stderr, opts, info)
+	return err
+}
+
+// streamPrompt streams the response to out as it arrives.
+func streamPrompt(ctx context.Context, client api.Client, req *api.ChatRequest, out *responseWriter) (responseInfo, error) {
+	var info responseInfo
+	reader, err := client.ChatStream(ctx, req)
+	if err != nil {
+		return info, err
+	}
+	defer reader.Close()
+
+	var content strings.Builder
+	for {
+		chunk, err := reader.Next()
+		if err != nil {
+			return info, err
+		}
+		if chunk == nil || chunk.Done {
+			break
+		}
+		info.record(chunk.ID, chunk.Model, chunk.Provider, chunk.FinishReason, chunk.Usage)
+		content.WriteString(chunk.Content)
+		if err := out.chunk(chunk); err != nil {
+			return info, err
 		}
-		return nil
 	}
 
-	// Non-streaming request
-	resp, err := client.Chat(context.Background(), req)
+	info.Content = content.String()
+	return info, out.finish(info)
+}
+
+// sendPrompt makes a non-streaming request and prints the response to out.
+func sendPrompt(ctx context.Context, client api.Client, req *api.ChatRequest, out *responseWriter) (responseInfo, error) {
+	var info responseInfo
+	resp, err := client.Chat(ctx, req)
 	if err != nil {
-		return err
+		return info, err
 	}
+	info.recordResponse(resp, resp.Usage)
 
 	if len(resp.Choices) > 0 {
-		content := resp.Choices[0].Message.Content
-
-		renderer, err := tui.NewMarkdownRenderer(80)
-		if err == nil {
-			rendered, renderErr := renderer.Render(content)
-			if renderErr == nil {
-				fmt.Print(rendered)
-				return nil
-			}
+		info.Content = resp.Choices[0].Message.Content
+	}
+	return info, out.finish(info)
+}
+
+// printResponseInfo reports which model in a fallback chain answered and,
+// when requested, the token usage.
+func printResponseInfo(w io.Writer, opts promptOptions, info responseInfo) {
+	if len(opts.Models) > 1 && info.Model != "" {
+		answered := info.Model
+		if info.Provider != "" {
+			answered += " via " + info.Provider
 		}
-		fmt.Println(content)
+		fmt.Fprintf(w, "Answered by: %s\n", answered)
+	}
+	if opts.ShowUsage {
+		printUsage(w, info.Usage)
 	}
+}
 
-	return nil
+// printUsage writes a one-line token and cost summary.
+func printUsage(w io.Writer, usage *api.Usage) {
+	if usage == nil {
+		fmt.Fprintln(w, "Usage: not reported")
+		return
+	}
+
+	prompt := fmt.Sprintf("%d prompt", usage.PromptTokens)
+	if cached := usage.CachedTokens(); cached > 0 {
+		prompt += fmt.Sprintf(" (%d cached)", cached)
+	}
+	completion := fmt.Sprintf("%d completion", usage.CompletionTokens)
+	if reasoning := usage.ReasoningTokens(); reasoning > 0 {
+		completion += fmt.Sprintf(" (%d reasoning)", reasoning)
+	}
+	fmt.Fprintf(w, "Usage: %s + %s = %d tokens, cost %s\n",
+		prompt, completion, usage.TotalTokens, tui.FormatCost(usage.Cost))
 }
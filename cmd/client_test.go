@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+func TestPrintUsage(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage *api.Usage
+		want  string
+	}{
+		{
+			name:  "not reported",
+			usage: nil,
+			want:  "Usage: not reported\n",
+		},
+		{
+			name:  "basic",
+			usage: &api.Usage{PromptTokens: 12, CompletionTokens: 30, TotalTokens: 42, Cost: 0.0021},
+			want:  "Usage: 12 prompt + 30 completion = 42 tokens, cost $0.0021\n",
+		},
+		{
+			name: "with details",
+			usage: &api.Usage{
+				PromptTokens:            100,
+				CompletionTokens:        50,
+				TotalTokens:             150,
+				Cost:                    1.5,
+				PromptTokensDetails:     &api.PromptTokensDetails{CachedTokens: 80},
+				CompletionTokensDetails: &api.CompletionTokensDetails{ReasoningTokens: 20},
+			},
+			want: "Usage: 100 prompt (80 cached) + 50 completion (20 reasoning) = 150 tokens, cost $1.50\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			printUsage(&buf, tt.usage)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("printUsage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintResponseInfo(t *testing.T) {
+	info := responseInfo{Model: "b/fallback", Provider: "Groq"}
+
+	var buf bytes.Buffer
+	printResponseInfo(&buf, promptOptions{Models: []string{"a/primary", "b/fallback"}}, info)
+	if got, want := buf.String(), "Answered by: b/fallback via Groq\n"; got != want {
+		t.Errorf("printResponseInfo() = %q, want %q", got, want)
+	}
+
+	// A single model needs no report
+	buf.Reset()
+	printResponseInfo(&buf, promptOptions{Models: []string{"a/primary"}}, info)
+	if buf.Len() != 0 {
+		t.Errorf("printResponseInfo() = %q, want no output", buf.String())
+	}
+}
@@ -5,11 +5,11 @@ import (
 	"encoding/base64"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/attachment"
 	"github.com/vstratful/openrouter-cli/internal/config"
 )
 
@@ -21,6 +21,7 @@ var (
 	imageAspectRatio string
 	imageSize        string
 	imageInput       string
+	imageProvider    providerFlags
 )
 
 var imageCmd = &cobra.Command{
@@ -64,6 +65,7 @@ func init() {
 	imageCmd.Flags().StringVarP(&imageInput, "input", "i", "", "Input image file for editing/refinement")
 	imageCmd.Flags().StringVar(&imageAspectRatio, "aspect-ratio", "", "Aspect ratio (default: 1:1)")
 	imageCmd.Flags().StringVar(&imageSize, "size", "", "Image resolution (default: 1K)")
+	imageProvider.register(imageCmd)
 
 	imageCmd.MarkFlagRequired("prompt")
 }
@@ -91,8 +93,13 @@ func runImage(cmd *cobra.Command, args []string) error {
 		imageModel = cfg.DefaultImageModel
 	}
 
-	client := api.DefaultClient(apiKey, timeout)
-	imageClient := api.ImageClient(apiKey, timeout)
+	provider, err := imageProvider.preferences(cmd, cfg.Provider)
+	if err != nil {
+		return err
+	}
+
+	client := newClient(apiKey)
+	imageClient := newImageClient(apiKey)
 
 	// Fetch models and validate the selected model
 	models, err := client.ListModels(context.Background(), nil)
@@ -140,7 +147,7 @@ func runImage(cmd *cobra.Command, args []string) error {
 		}
 
 		// Read and encode the input image
-		mime, err := detectImageMIME(imageInput)
+		mime, err := attachment.ImageMIMEType(imageInput)
 		if err != nil {
 			return err
 		}
@@ -148,7 +155,7 @@ func runImage(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to read input image: %w", err)
 		}
-		dataURL := fmt.Sprintf("data:%s;base64,%s", mime, base64.StdEncoding.EncodeToString(imgData))
+		dataURL := api.EncodeDataURL(mime, imgData)
 
 		userMessage = api.Message{
 			Role: "user",
@@ -166,6 +173,7 @@ func runImage(cmd *cobra.Command, args []string) error {
 		Model:      imageModel,
 		Messages:   []api.Message{userMessage},
 		Modalities: []string{"image", "text"},
+		Provider:   provider,
 	}
 
 	// Add image config if specified
@@ -231,23 +239,6 @@ func runImage(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// detectImageMIME returns the MIME type for a supported image file based on extension.
-func detectImageMIME(path string) (string, error) {
-	ext := strings.ToLower(filepath.Ext(path))
-	switch ext {
-	case ".png":
-		return "image/png", nil
-	case ".jpg", ".jpeg":
-		return "image/jpeg", nil
-	case ".webp":
-		return "image/webp", nil
-	case ".gif":
-		return "image/gif", nil
-	default:
-		return "", fmt.Errorf("unsupported image format %q; supported formats: png, jpg, jpeg, webp, gif", ext)
-	}
-}
-
 // parseDataURL extracts the base64 data from a data URL.
 // Expected format: data:<mediatype>;base64,<data>
 func parseDataURL(dataURL string) (string, error) {
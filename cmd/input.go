@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vstratful/openrouter-cli/internal/attachment"
+)
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// readInput reads text piped to the command, up to attachment.MaxSize.
+func readInput(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, attachment.MaxSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %w", err)
+	}
+	if len(data) > attachment.MaxSize {
+		return "", fmt.Errorf("stdin input is larger than the %s limit; attach large files with --file or trim the input",
+			attachment.FormatSize(attachment.MaxSize))
+	}
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("stdin input is not text; attach binary files with --file")
+	}
+	return string(data), nil
+}
+
+// combinePrompt joins the --prompt text with piped input, the instruction
+// first.
+func combinePrompt(prompt, input string) string {
+	input = strings.TrimRight(input, "\r\n")
+	switch {
+	case strings.TrimSpace(input) == "":
+		return prompt
+	case prompt == "":
+		return input
+	default:
+		return prompt + "\n\n" + input
+	}
+}
+
+// loadFiles loads the files given with --file.
+func loadFiles(paths []string) ([]*attachment.Attachment, error) {
+	var files []*attachment.Attachment
+	for _, path := range paths {
+		a, err := attachment.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, a)
+	}
+	return files, nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vstratful/openrouter-cli/internal/attachment"
+)
+
+func TestCombinePrompt(t *testing.T) {
+	tests := []struct {
+		name   string
+		prompt string
+		input  string
+		want   string
+	}{
+		{name: "prompt only", prompt: "hi", want: "hi"},
+		{name: "input only", input: "diff\n", want: "diff"},
+		{name: "both", prompt: "Review this", input: "diff\r\n", want: "Review this\n\ndiff"},
+		{name: "blank input", prompt: "hi", input: " \n\n", want: "hi"},
+		{name: "neither", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := combinePrompt(tt.prompt, tt.input); got != tt.want {
+				t.Errorf("combinePrompt(%q, %q) = %q, want %q", tt.prompt, tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "text", input: "hello\nworld\n"},
+		{name: "empty", input: ""},
+		{name: "too large", input: strings.Repeat("a", attachment.MaxSize+1), wantErr: "larger than the 20.0 MiB limit"},
+		{name: "binary", input: "\xff\xfe\x00", wantErr: "not text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readInput(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readInput() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readInput() error = %v", err)
+			}
+			if got != tt.input {
+				t.Errorf("readInput() = %q, want %q", got, tt.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/tui"
+)
+
+// Single-turn output formats.
+const (
+	outputText     = "text"
+	outputMarkdown = "markdown"
+	outputJSON     = "json"
+	outputJSONL    = "jsonl"
+)
+
+// parseOutputFormat validates an --output value. An empty value selects
+// markdown on a terminal and raw text otherwise, so piped output is never
+// decorated.
+func parseOutputFormat(value string, terminal bool) (string, error) {
+	switch value {
+	case "":
+		if terminal {
+			return outputMarkdown, nil
+		}
+		return outputText, nil
+	case outputText, outputMarkdown, outputJSON, outputJSONL:
+		return value, nil
+	default:
+		return "", fmt.Errorf("invalid output format %q: must be text, markdown, json or jsonl", value)
+	}
+}
+
+// isJSONOutput reports whether format prints machine-readable JSON.
+func isJSONOutput(format string) bool {
+	return format == outputJSON || format == outputJSONL
+}
+
+// chunkEvent is one line of jsonl output, describing a streamed chunk.
+type chunkEvent struct {
+	ID           string     `json:"id,omitempty"`
+	Model        string     `json:"model,omitempty"`
+	Provider     string     `json:"provider,omitempty"`
+	Content      string     `json:"content,omitempty"`
+	Reasoning    string     `json:"reasoning,omitempty"`
+	FinishReason string     `json:"finish_reason,omitempty"`
+	Usage        *api.Usage `json:"usage,omitempty"`
+}
+
+// responseWriter prints a single-turn response in the selected format.
+type responseWriter struct {
+	w      io.Writer
+	format string
+	// streamed is true once anything has been written while streaming
+	streamed bool
+}
+
+// chunk prints a streamed chunk. Text and markdown output show content as it
+// arrives; jsonl writes one event per chunk; json waits for the full response.
+func (p *responseWriter) chunk(c *api.StreamChunk) error {
+	switch p.format {
+	case outputJSONL:
+		event := chunkEvent{
+			ID:        c.ID,
+			Model:     c.Model,
+			Provider:  c.Provider,
+			Content:   c.Content,
+			Reasoning: c.Reasoning,
+			Usage:     c.Usage,
+		}
+		if c.FinishReason != nil {
+			event.FinishReason = *c.FinishReason
+		}
+		p.streamed = true
+		return json.NewEncoder(p.w).Encode(event)
+	case outputText, outputMarkdown:
+		if c.Content != "" {
+			p.streamed = true
+			fmt.Fprint(p.w, c.Content)
+		}
+	}
+	return nil
+}
+
+// finish prints the complete response. Streamed markdown is re-rendered in
+// place of the raw text; streamed text only needs a final newline.
+func (p *responseWriter) finish(info responseInfo) error {
+	switch p.format {
+	case outputJSON:
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode response: %w", err)
+		}
+		fmt.Fprintln(p.w, string(data))
+	case outputJSONL:
+		if p.streamed {
+			return nil
+		}
+		return json.NewEncoder(p.w).Encode(info)
+	case outputMarkdown:
+		if info.Content == "" {
+			fmt.Fprintln(p.w)
+			return nil
+		}
+		if p.streamed {
+			fmt.Fprint(p.w, "\r\033[K") // Clear current line
+		}
+		printMarkdown(p.w, info.Content)
+	default:
+		if !p.streamed {
+			fmt.Fprint(p.w, info.Content)
+		}
+		if !strings.HasSuffix(info.Content, "\n") {
+			fmt.Fprintln(p.w)
+		}
+	}
+	return nil
+}
+
+// printMarkdown renders content as markdown, falling back to plain text on error.
+func printMarkdown(w io.Writer, content string) {
+	renderer, err := tui.NewMarkdownRenderer(80)
+	if err == nil {
+		rendered, renderErr := renderer.Render(content)
+		if renderErr == nil {
+			fmt.Fprint(w, rendered)
+			return
+		}
+	}
+	fmt.Fprintln(w, content)
+}
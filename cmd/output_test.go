@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	tests := []struct {
+		value    string
+		terminal bool
+		want     string
+		wantErr  bool
+	}{
+		{value: "", terminal: true, want: outputMarkdown},
+		{value: "", terminal: false, want: outputText},
+		{value: "json", terminal: true, want: outputJSON},
+		{value: "markdown", terminal: false, want: outputMarkdown},
+		{value: "yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseOutputFormat(tt.value, tt.terminal)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseOutputFormat(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseOutputFormat(%q, %v) = %q, want %q", tt.value, tt.terminal, got, tt.want)
+		}
+	}
+}
+
+// mockStream returns a mock client that streams the given SSE body.
+func mockStream(body string) *api.MockClient {
+	client := api.NewMockClient()
+	client.ChatStreamFunc = func(ctx context.Context, req *api.ChatRequest) (*api.StreamReader, error) {
+		return api.NewStreamReader(io.NopCloser(strings.NewReader(body))), nil
+	}
+	return client
+}
+
+func TestStreamPrompt_Output(t *testing.T) {
+	body := "data: {\"id\":\"gen-1\",\"model\":\"m\",\"provider\":\"P\",\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n" +
+		"data: {\"id\":\"gen-1\",\"choices\":[{\"delta\":{\"content\":\" world\"},\"finish_reason\":\"stop\"}]}\n\n" +
+		"data: {\"id\":\"gen-1\",\"choices\":[],\"usage\":{\"total_tokens\":7}}\n\n" +
+		"data: [DONE]\n\n"
+	req := &api.ChatRequest{Model: "m", Stream: true}
+
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		info, err := streamPrompt(context.Background(), mockStream(body), req, &responseWriter{w: &buf, format: outputText})
+		if err != nil {
+			t.Fatalf("streamPrompt() error = %v", err)
+		}
+		if got := buf.String(); got != "Hello world\n" {
+			t.Errorf("output = %q, want raw content with no escape codes", got)
+		}
+		if info.ID != "gen-1" || info.FinishReason != "stop" || info.Usage == nil || info.Usage.TotalTokens != 7 {
+			t.Errorf("info = %+v", info)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if _, err := streamPrompt(context.Background(), mockStream(body), req, &responseWriter{w: &buf, format: outputJSON}); err != nil {
+			t.Fatalf("streamPrompt() error = %v", err)
+		}
+		var got responseInfo
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+		}
+		want := responseInfo{ID: "gen-1", Model: "m", Provider: "P", Content: "Hello world", FinishReason: "stop"}
+		if got.Usage == nil || got.Usage.TotalTokens != 7 {
+			t.Errorf("usage = %+v, want 7 tokens", got.Usage)
+		}
+		got.Usage = nil
+		if got != want {
+			t.Errorf("output = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		var buf bytes.Buffer
+		if _, err := streamPrompt(context.Background(), mockStream(body), req, &responseWriter{w: &buf, format: outputJSONL}); err != nil {
+			t.Fatalf("streamPrompt() error = %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("got %d lines, want one per chunk:\n%s", len(lines), buf.String())
+		}
+		var event chunkEvent
+		if err := json.Unmarshal([]byte(lines[1]), &event); err != nil {
+			t.Fatal(err)
+		}
+		if event.Content != " world" || event.FinishReason != "stop" {
+			t.Errorf("event = %+v", event)
+		}
+	})
+}
+
+func TestSendPrompt_Output(t *testing.T) {
+	stop := "stop"
+	client := api.NewMockClient()
+	client.ChatFunc = func(ctx context.Context, req *api.ChatRequest) (*api.ChatResponse, error) {
+		return &api.ChatResponse{
+			ID:      "gen-2",
+			Model:   "m",
+			Choices: []api.Choice{{Message: api.ChoiceMessage{Content: "Hi"}, FinishReason: &stop}},
+		}, nil
+	}
+
+	var buf bytes.Buffer
+	if _, err := sendPrompt(context.Background(), client, &api.ChatRequest{Model: "m"}, &responseWriter{w: &buf, format: outputJSONL}); err != nil {
+		t.Fatalf("sendPrompt() error = %v", err)
+	}
+	want := `{"id":"gen-2","model":"m","content":"Hi","finish_reason":"stop"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
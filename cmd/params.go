@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/attachment"
+)
+
+// samplingFlags holds the raw values of the sampling parameter flags.
+// Only flags explicitly set by the user are sent with the request.
+type samplingFlags struct {
+	temperature       float64
+	topP              float64
+	topK              int
+	maxTokens         int
+	stop              []string
+	seed              int
+	frequencyPenalty  float64
+	presencePenalty   float64
+	repetitionPenalty float64
+	minP              float64
+}
+
+// register adds the sampling parameter flags to a command.
+func (f *samplingFlags) register(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.Float64Var(&f.temperature, "temperature", 0, "Sampling temperature (0-2)")
+	flags.Float64Var(&f.topP, "top-p", 0, "Nucleus sampling probability mass (0-1)")
+	flags.IntVar(&f.topK, "top-k", 0, "Sample only from the top K tokens")
+	flags.IntVar(&f.maxTokens, "max-tokens", 0, "Maximum number of tokens to generate")
+	flags.StringArrayVar(&f.stop, "stop", nil, "Stop sequence (repeatable)")
+	flags.IntVar(&f.seed, "seed", 0, "Seed for deterministic sampling")
+	flags.Float64Var(&f.frequencyPenalty, "frequency-penalty", 0, "Penalize tokens by frequency (-2 to 2)")
+	flags.Float64Var(&f.presencePenalty, "presence-penalty", 0, "Penalize tokens already present (-2 to 2)")
+	flags.Float64Var(&f.repetitionPenalty, "repetition-penalty", 0, "Penalize repeated tokens (0-2)")
+	flags.Float64Var(&f.minP, "min-p", 0, "Minimum token probability relative to the most likely (0-1)")
+}
+
+// params builds SamplingParams from the flags that were explicitly set.
+func (f *samplingFlags) params(cmd *cobra.Command) (api.SamplingParams, error) {
+	var p api.SamplingParams
+	changed := cmd.Flags().Changed
+
+	if changed("temperature") {
+		p.Temperature = &f.temperature
+	}
+	if changed("top-p") {
+		p.TopP = &f.topP
+	}
+	if changed("top-k") {
+		p.TopK = &f.topK
+	}
+	if changed("max-tokens") {
+		p.MaxTokens = &f.maxTokens
+	}
+	if changed("stop") {
+		p.Stop = f.stop
+	}
+	if changed("seed") {
+		p.Seed = &f.seed
+	}
+	if changed("frequency-penalty") {
+		p.FrequencyPenalty = &f.frequencyPenalty
+	}
+	if changed("presence-penalty") {
+		p.PresencePenalty = &f.presencePenalty
+	}
+	if changed("repetition-penalty") {
+		p.RepetitionPenalty = &f.repetitionPenalty
+	}
+	if changed("min-p") {
+		p.MinP = &f.minP
+	}
+
+	if err := p.Validate(); err != nil {
+		return api.SamplingParams{}, err
+	}
+	return p, nil
+}
+
+// reasoningFlags holds the raw values of the reasoning flags.
+type reasoningFlags struct {
+	effort    string
+	maxTokens int
+	exclude   bool
+}
+
+// register adds the reasoning flags to a command.
+func (f *reasoningFlags) register(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVar(&f.effort, "reasoning-effort", "", "Reasoning effort for thinking models (high, medium, low, minimal)")
+	flags.IntVar(&f.maxTokens, "reasoning-max-tokens", 0, "Token budget for reasoning (alternative to --reasoning-effort)")
+	flags.BoolVar(&f.exclude, "reasoning-exclude", false, "Let the model reason but omit reasoning from the response")
+}
+
+// options builds ReasoningOptions from the flags that were explicitly set.
+// Returns nil when no reasoning flag was given.
+func (f *reasoningFlags) options(cmd *cobra.Command) (*api.ReasoningOptions, error) {
+	changed := cmd.Flags().Changed
+	if !changed("reasoning-effort") && !changed("reasoning-max-tokens") && !changed("reasoning-exclude") {
+		return nil, nil
+	}
+
+	opts := &api.ReasoningOptions{
+		Effort:  f.effort,
+		Exclude: f.exclude,
+	}
+	if changed("reasoning-max-tokens") {
+		opts.MaxTokens = &f.maxTokens
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// parseModelChain builds a model fallback chain from repeated and
+// comma-separated --model values, dropping blanks and duplicates.
+func parseModelChain(values []string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, id := range strings.Split(value, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+			chain = append(chain, id)
+		}
+	}
+	return chain
+}
+
+// validateModels checks the named request parameters against the supported
+// parameters of each model in the chain, and the attachments against its
+// input modalities. Without the model list, or for models not in it, the
+// checks are skipped with a warning to warn, leaving the API to reject the
+// request.
+func validateModels(client api.Client, modelIDs []string, names []string, attachments []*attachment.Attachment, warn io.Writer) error {
+	if len(names) == 0 && len(attachments) == 0 {
+		return nil
+	}
+
+	models, err := client.ListModels(context.Background(), nil)
+	if err != nil {
+		fmt.Fprintf(warn, "Warning: not checking the models' parameters and inputs: failed to fetch models: %v\n", err)
+		return nil
+	}
+
+	for _, id := range modelIDs {
+		model := findModel(models, id)
+		if model == nil {
+			fmt.Fprintf(warn, "Warning: not checking the parameters and inputs of '%s': model not found\n", id)
+			continue
+		}
+		if err := model.ValidateParameters(names); err != nil {
+			return err
+		}
+		for _, a := range attachments {
+			if err := attachment.Check(model, a); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// providerFlags holds the raw values of the provider routing flags.
+type providerFlags struct {
+	order              []string
+	only               []string
+	ignore             []string
+	allowFallbacks     bool
+	requireParameters  bool
+	denyDataCollection bool
+	quantizations      []string
+	sort               string
+}
+
+// register adds the provider routing flags to a command.
+func (f *providerFlags) register(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringSliceVar(&f.order, "provider-order", nil, "Providers to try in order (comma-separated or repeatable)")
+	flags.StringSliceVar(&f.only, "provider-only", nil, "Only allow these providers")
+	flags.StringSliceVar(&f.ignore, "provider-ignore", nil, "Never use these providers")
+	flags.BoolVar(&f.allowFallbacks, "allow-fallbacks", true, "Allow providers outside --provider-order as backups")
+	flags.BoolVar(&f.requireParameters, "require-parameters", false, "Only use providers that support every request parameter")
+	flags.BoolVar(&f.denyDataCollection, "deny-data-collection", false, "Only use providers that do not store or train on data")
+	flags.StringSliceVar(&f.quantizations, "quantizations", nil, "Allowed quantization levels (e.g. fp8,bf16)")
+	flags.StringVar(&f.sort, "provider-sort", "", "Sort providers by price, throughput or latency")
+}
+
+// preferences merges the explicitly set flags over the configured defaults.
+// Returns nil when neither sets any preference.
+func (f *providerFlags) preferences(cmd *cobra.Command, defaults *api.ProviderPreferences) (*api.ProviderPreferences, error) {
+	changed := cmd.Flags().Changed
+	var override api.ProviderPreferences
+
+	if changed("provider-order") {
+		override.Order = f.order
+	}
+	if changed("provider-only") {
+		override.Only = f.only
+	}
+	if changed("provider-ignore") {
+		override.Ignore = f.ignore
+	}
+	if changed("allow-fallbacks") {
+		override.AllowFallbacks = &f.allowFallbacks
+	}
+	if changed("require-parameters") {
+		override.RequireParameters = &f.requireParameters
+	}
+	if changed("deny-data-collection") {
+		override.DataCollection = api.DataCollectionAllow
+		if f.denyDataCollection {
+			override.DataCollection = api.DataCollectionDeny
+		}
+	}
+	if changed("quantizations") {
+		override.Quantizations = f.quantizations
+	}
+	if changed("provider-sort") {
+		override.Sort = f.sort
+	}
+
+	prefs := defaults.Merge(&override)
+	if prefs.IsZero() {
+		return nil, nil
+	}
+	if err := prefs.Validate(); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+func TestProviderFlags_Preferences(t *testing.T) {
+	deny := &api.ProviderPreferences{DataCollection: api.DataCollectionDeny, Order: []string{"openai"}}
+
+	tests := []struct {
+		name     string
+		args     []string
+		defaults *api.ProviderPreferences
+		want     *api.ProviderPreferences
+		wantErr  bool
+	}{
+		{name: "no flags or defaults", want: nil},
+		{name: "defaults only", defaults: deny, want: deny},
+		{
+			name:     "flags override defaults",
+			args:     []string{"--provider-order", "groq,together", "--allow-fallbacks=false", "--provider-sort", "throughput"},
+			defaults: deny,
+			want: &api.ProviderPreferences{
+				Order:          []string{"groq", "together"},
+				AllowFallbacks: new(bool),
+				DataCollection: api.DataCollectionDeny,
+				Sort:           api.ProviderSortThroughput,
+			},
+		},
+		{
+			name: "deny data collection and quantizations",
+			args: []string{"--deny-data-collection", "--quantizations", "fp8", "--quantizations", "bf16"},
+			want: &api.ProviderPreferences{
+				DataCollection: api.DataCollectionDeny,
+				Quantizations:  []string{"fp8", "bf16"},
+			},
+		},
+		{name: "invalid sort", args: []string{"--provider-sort", "cheap"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var flags providerFlags
+			cmd := &cobra.Command{Use: "test"}
+			flags.register(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+
+			got, err := flags.preferences(cmd, tt.defaults)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("preferences() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("preferences() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseModelChain(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{name: "none", values: nil, want: nil},
+		{name: "single", values: []string{"openai/gpt-4o"}, want: []string{"openai/gpt-4o"}},
+		{name: "repeated", values: []string{"a/one", "b/two"}, want: []string{"a/one", "b/two"}},
+		{name: "comma-separated", values: []string{"a/one, b/two", "c/three"}, want: []string{"a/one", "b/two", "c/three"}},
+		{name: "blanks and duplicates", values: []string{"a/one,,a/one", " "}, want: []string{"a/one"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseModelChain(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseModelChain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateModels(t *testing.T) {
+	models := []api.Model{{ID: "a/model", SupportedParameters: []string{"temperature"}}}
+	tests := []struct {
+		name     string
+		models   []string
+		params   []string
+		listErr  error
+		wantErr  bool
+		wantWarn string
+	}{
+		{name: "supported", models: []string{"a/model"}, params: []string{"temperature"}},
+		{name: "unsupported", models: []string{"a/model"}, params: []string{"top_k"}, wantErr: true},
+		{name: "models unavailable", models: []string{"a/model"}, params: []string{"top_k"},
+			listErr: errors.New("connection reset"), wantWarn: "failed to fetch models: connection reset"},
+		{name: "model not listed", models: []string{"b/model"}, params: []string{"top_k"}, wantWarn: "'b/model': model not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := api.NewMockClient()
+			client.ListModelsFunc = func(ctx context.Context, opts *api.ListModelsOptions) ([]api.Model, error) {
+				return models, tt.listErr
+			}
+			var warn bytes.Buffer
+			err := validateModels(client, tt.models, tt.params, nil, &warn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateModels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantWarn == "" && warn.Len() > 0 || !strings.Contains(warn.String(), tt.wantWarn) {
+				t.Errorf("warning = %q, want %q", warn.String(), tt.wantWarn)
+			}
+		})
+	}
+}
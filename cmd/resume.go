@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
@@ -11,8 +12,9 @@ import (
 )
 
 var (
-	lastSession    bool
-	resumeModelArg string
+	lastSession  bool
+	forkSession  bool
+	resumeModels []string
 )
 
 var resumeCmd = &cobra.Command{
@@ -21,16 +23,21 @@ var resumeCmd = &cobra.Command{
 	Long: `Resume a previous chat session.
 
 Usage:
-  openrouter resume           # Opens session picker TUI
-  openrouter resume <id>      # Resumes session directly by ID
-  openrouter resume --last    # Resumes most recent session`,
+  openrouter resume              # Opens session picker TUI
+  openrouter resume <id>         # Resumes session directly by ID
+  openrouter resume --last       # Resumes most recent session
+  openrouter resume --fork <id>  # Continues in a new session copied from <id>
+
+With --fork the original session is left unchanged; the copy keeps its
+messages, branches, models and system prompt.`,
 	RunE: runResume,
 }
 
 func init() {
 	rootCmd.AddCommand(resumeCmd)
 	resumeCmd.Flags().BoolVar(&lastSession, "last", false, "Resume most recent session")
-	resumeCmd.Flags().StringVarP(&resumeModelArg, "model", "m", "", "Model to use (overrides session's model)")
+	resumeCmd.Flags().BoolVar(&forkSession, "fork", false, "Continue in a new session copied from the chosen one")
+	resumeCmd.Flags().StringArrayVarP(&resumeModels, "model", "m", nil, "Model to use, or a fallback chain (overrides session's models)")
 }
 
 func runResume(cmd *cobra.Command, args []string) error {
@@ -48,10 +55,10 @@ func runResume(cmd *cobra.Command, args []string) error {
 
 	// Determine which session to resume
 	if len(args) > 0 {
-		// Direct ID provided
-		session, err = config.LoadSession(args[0])
+		// Direct ID provided, in full or as a unique prefix
+		session, err = loadSessionArg(args[0])
 		if err != nil {
-			return fmt.Errorf("failed to load session: %w", err)
+			return err
 		}
 	} else if lastSession {
 		// --last flag: get most recent
@@ -82,21 +89,30 @@ func runResume(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Determine model: if user provided -m flag, use that; otherwise use session's model
-	modelName := resumeModelArg
-	if modelName == "" {
-		modelName = session.Model
+	if forkSession {
+		fork := session.Fork()
+		if err := fork.Save(); err != nil {
+			return fmt.Errorf("failed to save forked session: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Forked session %s as %s\n", session.ID, fork.ID)
+		session = fork
 	}
-	if modelName == "" {
-		modelName = cfg.DefaultModel
+
+	// Determine models: if user provided -m flag, use that; otherwise use session's chain
+	models := parseModelChain(resumeModels)
+	if len(models) == 0 {
+		models = session.ModelChain()
+	}
+	if len(models) == 0 {
+		models = []string{cfg.DefaultModel}
 	}
 
-	return runChatWithSession(apiKey, modelName, session)
+	return runChatWithSession(apiKey, models, chatSettings{CompactModel: cfg.CompactModel, Provider: cfg.Provider}, session)
 }
 
 // sessionPickerModel is a standalone picker for the resume command.
 type sessionPickerModel struct {
-	picker   picker.Model
+	picker   picker.SessionPicker
 	selected *config.SessionSummary
 }
 
@@ -107,6 +123,11 @@ func (m sessionPickerModel) Init() tea.Cmd {
 func (m sessionPickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// Confirming a delete or editing a title takes every key
+		if m.picker.Busy() {
+			break
+		}
+
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
@@ -133,7 +154,7 @@ func (m sessionPickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m sessionPickerModel) View() string {
-	return m.picker.View() + "\n" + tui.HelpStyle.Render("Enter: select | Esc/q: cancel | /: filter")
+	return m.picker.View() + "\n" + tui.HelpStyle.Render(m.picker.Help("Enter: select | Esc/q: cancel | /: filter"))
 }
 
 // runSessionPicker shows the session picker TUI and returns the selected session
@@ -6,10 +6,16 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/vstratful/openrouter-cli/internal/api"
 	"github.com/vstratful/openrouter-cli/internal/config"
 )
 
-var timeout time.Duration
+var (
+	timeout      time.Duration
+	maxRetryWait time.Duration
+	streamIdle   time.Duration
+	strictStream bool
+)
 
 // version is injected at build time by GoReleaser
 var version = "dev"
@@ -24,6 +30,7 @@ Commands:
   image     Generate images with image-capable models
   models    List and explore available models
   resume    Continue a previous chat session
+  sessions  List and manage saved chat sessions
 
 Examples:
   openrouter chat                       # Interactive chat mode
@@ -35,6 +42,29 @@ Examples:
 func init() {
 	rootCmd.Version = version
 	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 5*time.Minute, "HTTP timeout for API requests (e.g. 30s, 2m, 10m)")
+	rootCmd.PersistentFlags().DurationVar(&maxRetryWait, "max-retry-wait", api.DefaultMaxRetryWait, "Longest wait honored from Retry-After and rate limit headers before retrying")
+	rootCmd.PersistentFlags().DurationVar(&streamIdle, "stream-idle-timeout", api.DefaultStreamIdleTimeout, "Longest wait for data while streaming before giving up (0 to disable)")
+	rootCmd.PersistentFlags().BoolVar(&strictStream, "strict-stream", false, "Fail on malformed stream chunks instead of skipping them")
+}
+
+// newClient creates an API client using the global timeout and retry flags.
+func newClient(apiKey string) api.Client {
+	cfg := api.DefaultClientConfig(apiKey, timeout)
+	cfg.Retry.MaxRetryWait = maxRetryWait
+	cfg.StreamIdleTimeout = streamIdle
+	if streamIdle == 0 {
+		cfg.StreamIdleTimeout = -1 // disabled
+	}
+	cfg.StrictStream = strictStream
+	return api.NewClient(cfg)
+}
+
+// newImageClient creates an API client for image generation using the global
+// timeout and retry flags.
+func newImageClient(apiKey string) api.Client {
+	cfg := api.ImageClientConfig(apiKey, timeout)
+	cfg.Retry.MaxRetryWait = maxRetryWait
+	return api.NewClient(cfg)
 }
 
 func Execute() error {
@@ -72,6 +102,7 @@ func getAPIKey() (string, *config.Config, bool, error) {
 	cfg.APIKey = key
 	cfg.DefaultModel = config.DefaultModel
 	cfg.DefaultImageModel = config.DefaultImageModel
+	cfg.CompactModel = config.DefaultCompactModel
 	if err := config.Save(cfg); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to save config: %v\n", err)
 	}
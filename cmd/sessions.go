@@ -0,0 +1,725 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vstratful/openrouter-cli/internal/config"
+	"github.com/vstratful/openrouter-cli/internal/export"
+	"github.com/vstratful/openrouter-cli/internal/importer"
+	"github.com/vstratful/openrouter-cli/internal/tui"
+)
+
+var (
+	sessionsJSON   bool
+	sessionsModel  string
+	sessionsTag    string
+	sessionsFilter string
+	sessionsSince  string
+	sessionsLimit  int
+	sessionsOffset int
+	untagSessions  bool
+	pruneOlderThan string
+	pruneEmpty     bool
+	pruneDryRun    bool
+	exportFormat   string
+	exportOutput   string
+	importFormat   string
+	importTags     []string
+	searchRole     string
+	searchUntil    string
+	searchLimit    int
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List and manage saved chat sessions",
+	Long: `List, inspect, rename, tag and delete saved chat sessions.
+
+Sessions can be named by their full ID or any unique prefix of it.
+
+Examples:
+  openrouter sessions list                     # List sessions, most recent first
+  openrouter sessions list --tag work --json   # Filter by tag, print JSON
+  openrouter sessions search "migration script" # Search message content
+  openrouter sessions show 1a2b3c4d            # Print a session's conversation
+  openrouter sessions rename 1a2b "Go review"  # Give a session a title
+  openrouter sessions tag 1a2b work go         # Add tags (--remove to remove)
+  openrouter sessions export 1a2b -o chat.html # Export a transcript
+  openrouter sessions import conversations.json # Import a ChatGPT export
+  openrouter sessions rm 1a2b                  # Delete a session
+  openrouter sessions prune --older-than 30d   # Delete sessions idle for 30 days
+  openrouter sessions prune --empty            # Delete sessions without messages`,
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List saved sessions, most recent first",
+	Args:    cobra.NoArgs,
+	RunE:    runSessionsList,
+}
+
+var sessionsSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search the content of saved sessions",
+	Long: `Search the messages and titles of saved sessions, best matches first.
+
+Every word of the query must occur in a session, ignoring case; quote a
+phrase to match it as a whole. Rarer words and repeated matches rank
+higher. Each result shows the best matching messages with the query
+highlighted.
+
+Examples:
+  openrouter sessions search migration script
+  openrouter sessions search '"connection pool"' --role assistant
+  openrouter sessions search retry --model claude --since 30d
+  openrouter sessions search deploy --since 2025-01-01 --until 2025-02-01 --json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSessionsSearch,
+}
+
+var sessionsShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Print a session's details and conversation",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSessionsShow,
+}
+
+var sessionsExportCmd = &cobra.Command{
+	Use:   "export <id>",
+	Short: "Export a session's conversation as Markdown, HTML, JSON or text",
+	Long: `Export the active branch of a session as a transcript with models,
+timestamps, usage and attachments.
+
+The format defaults to the output file's extension, or Markdown. HTML
+transcripts are a single self-contained page with highlighted code and
+embedded images; JSON transcripts can be read back with "sessions import".
+
+Examples:
+  openrouter sessions export 1a2b                  # Markdown to stdout
+  openrouter sessions export 1a2b -o review.html   # Self-contained HTML page
+  openrouter sessions export 1a2b --format json    # JSON to stdout`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSessionsExport,
+}
+
+var sessionsImportCmd = &cobra.Command{
+	Use:   "import <file>...",
+	Short: "Import conversations from ChatGPT, OpenAI messages or a JSON transcript",
+	Long: `Import conversations as sessions that can be listed and resumed.
+
+Supported formats, detected from the content unless --format is given:
+  chatgpt     conversations.json from a ChatGPT data export, with every branch
+  openai      a chat completions messages array, a request body with
+              "messages", or JSONL with one of either per line
+  transcript  a JSON transcript written by "sessions export --format json"
+
+Only text, images and files are imported; tool calls are left out, and
+ChatGPT uploads, which its export doesn't contain, become placeholders.
+Conversations that were imported before are skipped. Use "-" to read
+from stdin.
+
+Examples:
+  openrouter sessions import conversations.json
+  openrouter sessions import chat.json --format openai --tag work
+  openrouter sessions export 1a2b --format json | openrouter sessions import -`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSessionsImport,
+}
+
+var sessionsRmCmd = &cobra.Command{
+	Use:   "rm <id>...",
+	Short: "Delete sessions",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runSessionsRm,
+}
+
+var sessionsRenameCmd = &cobra.Command{
+	Use:   "rename <id> <title>",
+	Short: `Set a session's title ("" removes it)`,
+	Args:  cobra.ExactArgs(2),
+	RunE:  runSessionsRename,
+}
+
+var sessionsTagCmd = &cobra.Command{
+	Use:   "tag <id> <tag>...",
+	Short: "Add tags to a session, or remove them with --remove",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runSessionsTag,
+}
+
+var sessionsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old or empty sessions and unused attachments",
+	Args:  cobra.NoArgs,
+	RunE:  runSessionsPrune,
+}
+
+func init() {
+	rootCmd.AddCommand(sessionsCmd)
+	sessionsCmd.AddCommand(sessionsListCmd, sessionsSearchCmd, sessionsShowCmd, sessionsExportCmd, sessionsImportCmd, sessionsRmCmd, sessionsRenameCmd, sessionsTagCmd, sessionsPruneCmd)
+
+	sessionsListCmd.Flags().StringVarP(&sessionsModel, "model", "m", "", "Only sessions whose model contains this text")
+	sessionsListCmd.Flags().StringVarP(&sessionsTag, "tag", "t", "", "Only sessions with this tag")
+	sessionsListCmd.Flags().StringVarP(&sessionsFilter, "filter", "f", "", "Only sessions whose title or first message contains this text")
+	sessionsListCmd.Flags().StringVar(&sessionsSince, "since", "", "Only sessions updated within this long (e.g. 12h, 7d, 2w)")
+	sessionsListCmd.Flags().IntVarP(&sessionsLimit, "limit", "n", 0, "Show at most this many sessions")
+	sessionsListCmd.Flags().IntVar(&sessionsOffset, "offset", 0, "Skip this many sessions, to page through them with --limit")
+	sessionsListCmd.Flags().BoolVar(&sessionsJSON, "json", false, "Print sessions as JSON")
+
+	sessionsSearchCmd.Flags().StringVarP(&sessionsModel, "model", "m", "", "Only sessions whose model contains this text")
+	sessionsSearchCmd.Flags().StringVar(&searchRole, "role", "", "Only match messages from this role: user or assistant")
+	sessionsSearchCmd.Flags().StringVarP(&sessionsTag, "tag", "t", "", "Only sessions with this tag")
+	sessionsSearchCmd.Flags().StringVar(&sessionsSince, "since", "", "Only sessions updated since this date (2025-01-31) or within this long (7d)")
+	sessionsSearchCmd.Flags().StringVar(&searchUntil, "until", "", "Only sessions last updated before this date (2025-01-31) or this long ago (7d)")
+	sessionsSearchCmd.Flags().IntVarP(&searchLimit, "limit", "n", 10, "Show at most this many sessions")
+	sessionsSearchCmd.Flags().BoolVar(&sessionsJSON, "json", false, "Print results as JSON")
+
+	sessionsShowCmd.Flags().BoolVar(&sessionsJSON, "json", false, "Print the session file, including every branch")
+
+	sessionsExportCmd.Flags().StringVar(&exportFormat, "format", "", "Transcript format: md, html, json or txt (default from --output, else md)")
+	sessionsExportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write to this file instead of stdout")
+
+	sessionsImportCmd.Flags().StringVar(&importFormat, "format", importer.FormatAuto, "Input format: auto, chatgpt, openai or transcript")
+	sessionsImportCmd.Flags().StringSliceVarP(&importTags, "tag", "t", nil, "Add these tags to the imported sessions (repeatable)")
+
+	sessionsTagCmd.Flags().BoolVar(&untagSessions, "remove", false, "Remove the tags instead of adding them")
+
+	sessionsPruneCmd.Flags().StringVar(&pruneOlderThan, "older-than", "", "Delete sessions not updated within this long (e.g. 30d, 12w)")
+	sessionsPruneCmd.Flags().BoolVar(&pruneEmpty, "empty", false, "Delete sessions without messages")
+	sessionsPruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "List the sessions that would be deleted without deleting them")
+}
+
+// parseAge parses a duration that may also be given in days (d) or weeks (w).
+func parseAge(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(value, suffix); ok {
+			count, err := strconv.ParseFloat(n, 64)
+			if err != nil || count < 0 {
+				break
+			}
+			return time.Duration(count * float64(unit)), nil
+		}
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q: use a duration such as 12h, 7d or 2w", value)
+	}
+	return age, nil
+}
+
+// parseTimeArg parses a point in time given as a date, a date and time, or
+// an age before now as accepted by parseAge. Dates are in local time.
+func parseTimeArg(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006-01-02T15:04", "2006-01-02 15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	age, err := parseAge(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use a date such as 2025-01-31 or an age such as 7d", value)
+	}
+	return time.Now().Add(-age), nil
+}
+
+// sessionFilter selects sessions for listing. Zero fields match everything.
+type sessionFilter struct {
+	Model string
+	Tag   string
+	Text  string
+	Since time.Time
+}
+
+// match reports whether a session passes the filter.
+func (f sessionFilter) match(s config.SessionSummary) bool {
+	contains := func(s, substr string) bool {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	}
+	if f.Model != "" && !contains(s.Model, f.Model) {
+		return false
+	}
+	if f.Tag != "" && !containsFold(s.Tags, f.Tag) {
+		return false
+	}
+	if f.Text != "" && !contains(s.Title, f.Text) && !contains(s.Preview, f.Text) {
+		return false
+	}
+	return f.Since.IsZero() || s.UpdatedAt.After(f.Since)
+}
+
+// containsFold reports whether values holds s, ignoring case.
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func runSessionsList(cmd *cobra.Command, args []string) error {
+	filter := sessionFilter{Model: sessionsModel, Tag: sessionsTag, Text: sessionsFilter}
+	if sessionsSince != "" {
+		age, err := parseAge(sessionsSince)
+		if err != nil {
+			return err
+		}
+		filter.Since = time.Now().Add(-age)
+	}
+
+	if sessionsOffset < 0 {
+		return fmt.Errorf("--offset must not be negative")
+	}
+	page, err := config.DefaultStore.List(config.ListOptions{
+		Filter: filter.match,
+		Offset: sessionsOffset,
+		Limit:  sessionsLimit,
+	})
+	if err != nil {
+		return err
+	}
+	summaries := page.Sessions
+	if summaries == nil {
+		summaries = []config.SessionSummary{}
+	}
+
+	w := cmd.OutOrStdout()
+	if sessionsJSON {
+		return writeJSON(w, summaries)
+	}
+	if len(summaries) == 0 {
+		fmt.Fprintln(w, "No sessions found.")
+		return nil
+	}
+	printSessionList(w, summaries)
+	if shown := sessionsOffset + len(summaries); shown < page.Total {
+		fmt.Fprintf(os.Stderr, "Showing %d-%d of %d sessions; use --offset %d for more\n",
+			sessionsOffset+1, shown, page.Total, shown)
+	}
+	return nil
+}
+
+func runSessionsSearch(cmd *cobra.Command, args []string) error {
+	opts := config.SearchOptions{Model: sessionsModel, Tag: sessionsTag, Limit: searchLimit}
+	switch searchRole {
+	case "", "user", "assistant":
+		opts.Role = searchRole
+	default:
+		return fmt.Errorf("invalid role %q: must be user or assistant", searchRole)
+	}
+	var err error
+	if sessionsSince != "" {
+		if opts.Since, err = parseTimeArg(sessionsSince); err != nil {
+			return err
+		}
+	}
+	if searchUntil != "" {
+		if opts.Until, err = parseTimeArg(searchUntil); err != nil {
+			return err
+		}
+	}
+
+	results, err := config.SearchSessions(strings.Join(args, " "), opts)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	if sessionsJSON {
+		if results == nil {
+			results = []config.SearchResult{}
+		}
+		return writeJSON(w, results)
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(w, "No matching sessions.")
+		return nil
+	}
+	highlight := func(s string) string { return s }
+	if f, ok := w.(*os.File); ok && isTerminal(f) {
+		highlight = func(s string) string { return tui.SearchMatchStyle.Render(s) }
+	}
+	for i, r := range results {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		printSessionList(w, []config.SessionSummary{r.Session})
+		for _, m := range r.Matches {
+			fmt.Fprintf(w, "    %-9s %s\n", m.Role+":", highlightRanges(m.Snippet, m.Highlights, highlight))
+		}
+	}
+	return nil
+}
+
+// highlightRanges applies style to the given byte ranges of s, which must be
+// sorted and may overlap.
+func highlightRanges(s string, ranges [][2]int, style func(string) string) string {
+	var b strings.Builder
+	pos := 0
+	for _, r := range ranges {
+		start := max(r[0], pos)
+		if start >= r[1] {
+			continue
+		}
+		b.WriteString(s[pos:start])
+		b.WriteString(style(s[start:r[1]]))
+		pos = r[1]
+	}
+	b.WriteString(s[pos:])
+	return b.String()
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// shortID is the ID prefix shown in lists, enough to name a session.
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// printSessionList writes one line per session: short ID, last update,
+// message count, model and title or preview with tags.
+func printSessionList(w io.Writer, summaries []config.SessionSummary) {
+	for _, s := range summaries {
+		name := fmt.Sprintf("%q", s.Preview)
+		if s.Title != "" {
+			name = s.Title
+		}
+		if len(s.Tags) > 0 {
+			name += " #" + strings.Join(s.Tags, " #")
+		}
+		fmt.Fprintf(w, "%-8s  %s  %4d msgs  %-30s  %s\n",
+			shortID(s.ID), s.UpdatedAt.Format("2006-01-02 15:04"), s.MessageCount, s.Model, name)
+	}
+}
+
+// loadSessionArg loads the session named by a full ID or unique prefix.
+func loadSessionArg(id string) (*config.Session, error) {
+	id, err := config.FindSession(id)
+	if err != nil {
+		return nil, err
+	}
+	session, err := config.LoadSession(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	if session.Recovered() {
+		fmt.Fprintf(os.Stderr, "Warning: session %s was cut short by a crash; recovered %d messages, anything after them is lost\n",
+			shortID(id), len(session.Messages))
+	}
+	return session, nil
+}
+
+func runSessionsShow(cmd *cobra.Command, args []string) error {
+	session, err := loadSessionArg(args[0])
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if sessionsJSON {
+		return writeJSON(w, session)
+	}
+	printSession(w, session)
+	return nil
+}
+
+// printSession writes a session's details followed by its active branch.
+func printSession(w io.Writer, session *config.Session) {
+	fmt.Fprintf(w, "ID:       %s\n", session.ID)
+	if session.Title != "" {
+		fmt.Fprintf(w, "Title:    %s\n", session.Title)
+	}
+	if models := session.ModelChain(); len(models) > 0 {
+		fmt.Fprintf(w, "Models:   %s\n", strings.Join(models, ", "))
+	}
+	if len(session.Tags) > 0 {
+		fmt.Fprintf(w, "Tags:     %s\n", strings.Join(session.Tags, ", "))
+	}
+	fmt.Fprintf(w, "Created:  %s\n", session.CreatedAt.Format("2006-01-02 15:04"))
+	fmt.Fprintf(w, "Updated:  %s\n", session.UpdatedAt.Format("2006-01-02 15:04"))
+	messages := fmt.Sprintf("%d", len(session.Messages))
+	if branches := len(session.Branches()); branches > 1 {
+		messages += fmt.Sprintf(" on the active branch of %d", branches)
+	}
+	fmt.Fprintf(w, "Messages: %s\n", messages)
+	if usage := session.TotalUsage(); usage.TotalTokens > 0 {
+		printUsage(w, &usage)
+	}
+	if session.SystemPrompt != "" {
+		fmt.Fprintf(w, "\nSystem:\n%s\n", session.SystemPrompt)
+	}
+
+	for _, msg := range session.Messages {
+		role := "User"
+		if msg.Role == "assistant" {
+			role = "Assistant"
+			if msg.Model != "" {
+				role += " (" + msg.Model + ")"
+			}
+		}
+		fmt.Fprintf(w, "\n%s:\n%s\n", role, msg.Content)
+		for _, part := range msg.Parts {
+			if part.Type != config.SessionPartText {
+				fmt.Fprintf(w, "[%s attachment: %s]\n", part.Type, attachmentName(part))
+			}
+		}
+		if len(msg.Images) > 0 {
+			fmt.Fprintf(w, "[%d generated images]\n", len(msg.Images))
+		}
+	}
+}
+
+// attachmentName names a stored attachment for display.
+func attachmentName(part config.SessionPart) string {
+	switch {
+	case part.Filename != "":
+		return part.Filename
+	case part.URL != "":
+		return part.URL
+	}
+	return part.MIMEType
+}
+
+func runSessionsExport(cmd *cobra.Command, args []string) error {
+	session, err := loadSessionArg(args[0])
+	if err != nil {
+		return err
+	}
+	format := export.FormatForPath(exportOutput)
+	if exportFormat != "" {
+		if format, err = export.ParseFormat(exportFormat); err != nil {
+			return err
+		}
+	}
+
+	transcript, err := export.New(session)
+	if err != nil {
+		// Export what is available; missing attachments are left out
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if exportOutput == "" {
+		return export.Write(cmd.OutOrStdout(), transcript, format)
+	}
+
+	f, err := os.Create(exportOutput)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := export.Write(f, transcript, format); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported session %s to %s\n", session.ID, exportOutput)
+	return nil
+}
+
+func runSessionsImport(cmd *cobra.Command, args []string) error {
+	format, err := importer.ParseFormat(importFormat)
+	if err != nil {
+		return err
+	}
+
+	// Sources of sessions imported earlier, to skip them
+	summaries, err := config.ListAllSessions()
+	if err != nil {
+		return err
+	}
+	imported := make(map[string]bool)
+	for _, s := range summaries {
+		if s.Source != "" {
+			imported[s.Source] = true
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	var added, skipped int
+	for _, path := range args {
+		data, err := readImportFile(cmd, path)
+		if err != nil {
+			return err
+		}
+		sessions, err := importer.Parse(data, format)
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", path, err)
+		}
+		for _, session := range sessions {
+			if imported[session.Source] {
+				skipped++
+				continue
+			}
+			session.SetTags(append(session.Tags, importTags...))
+			if err := session.SaveImported(); err != nil {
+				return err
+			}
+			imported[session.Source] = true
+			added++
+			printSessionList(w, []config.SessionSummary{session.Summary()})
+		}
+	}
+
+	fmt.Fprintf(w, "Imported %d sessions", added)
+	if skipped > 0 {
+		fmt.Fprintf(w, ", skipped %d already imported", skipped)
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+// readImportFile reads a file to import, or stdin for "-".
+func readImportFile(cmd *cobra.Command, path string) ([]byte, error) {
+	if path == "-" {
+		data, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return data, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import file: %w", err)
+	}
+	return data, nil
+}
+
+func runSessionsRm(cmd *cobra.Command, args []string) error {
+	w := cmd.OutOrStdout()
+	for _, arg := range args {
+		id, err := config.FindSession(arg)
+		if err != nil {
+			return err
+		}
+		if err := config.DeleteSession(id); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Deleted session %s\n", id)
+	}
+	return pruneBlobs(w)
+}
+
+// pruneBlobs removes attachments no session uses any more.
+func pruneBlobs(w io.Writer) error {
+	removed, err := config.PruneBlobs()
+	if err != nil {
+		return err
+	}
+	if removed > 0 {
+		fmt.Fprintf(w, "Removed %d unused attachments\n", removed)
+	}
+	return nil
+}
+
+func runSessionsRename(cmd *cobra.Command, args []string) error {
+	session, err := loadSessionArg(args[0])
+	if err != nil {
+		return err
+	}
+	session.Title = strings.TrimSpace(args[1])
+	if err := session.Save(); err != nil {
+		return err
+	}
+	if session.Title == "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed the title of session %s\n", session.ID)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "Renamed session %s to %q\n", session.ID, session.Title)
+	}
+	return nil
+}
+
+func runSessionsTag(cmd *cobra.Command, args []string) error {
+	session, err := loadSessionArg(args[0])
+	if err != nil {
+		return err
+	}
+	tags := args[1:]
+	if untagSessions {
+		var kept []string
+		for _, tag := range session.Tags {
+			if !containsFold(tags, tag) {
+				kept = append(kept, tag)
+			}
+		}
+		session.SetTags(kept)
+	} else {
+		session.SetTags(append(session.Tags, tags...))
+	}
+	if err := session.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Session %s tags: %s\n", session.ID, strings.Join(session.Tags, ", "))
+	return nil
+}
+
+func runSessionsPrune(cmd *cobra.Command, args []string) error {
+	if pruneOlderThan == "" && !pruneEmpty {
+		return fmt.Errorf("prune needs --older-than, --empty or both")
+	}
+	var cutoff time.Time
+	if pruneOlderThan != "" {
+		age, err := parseAge(pruneOlderThan)
+		if err != nil {
+			return err
+		}
+		cutoff = time.Now().Add(-age)
+	}
+
+	summaries, err := config.ListAllSessions()
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	count := 0
+	for _, s := range summaries {
+		old := !cutoff.IsZero() && s.UpdatedAt.Before(cutoff)
+		// A session whose active branch is empty may have others
+		empty := pruneEmpty && s.Nodes == 0
+		if !old && !empty {
+			continue
+		}
+		count++
+		if pruneDryRun {
+			fmt.Fprintf(w, "Would delete ")
+		} else {
+			if err := config.DeleteSession(s.ID); err != nil {
+				if errors.Is(err, config.ErrSessionLocked) {
+					// Still in use, so not idle after all
+					fmt.Fprintf(os.Stderr, "Skipped session %s: open in another process\n", shortID(s.ID))
+					count--
+					continue
+				}
+				return err
+			}
+			fmt.Fprintf(w, "Deleted ")
+		}
+		printSessionList(w, []config.SessionSummary{s})
+	}
+	if count == 0 {
+		fmt.Fprintln(w, "No sessions to prune.")
+	}
+	if pruneDryRun {
+		return nil
+	}
+	return pruneBlobs(w)
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/vstratful/openrouter-cli/internal/config"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "90m", want: 90 * time.Minute},
+		{value: "7d", want: 7 * 24 * time.Hour},
+		{value: "1.5d", want: 36 * time.Hour},
+		{value: "2w", want: 14 * 24 * time.Hour},
+		{value: "d", wantErr: true},
+		{value: "-1d", wantErr: true},
+		{value: "-1h", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseAge(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAge() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionFilter(t *testing.T) {
+	now := time.Now()
+	session := config.SessionSummary{
+		Title:     "Code review",
+		Model:     "anthropic/claude-sonnet-4",
+		Tags:      []string{"go", "work"},
+		Preview:   "Look at this diff",
+		UpdatedAt: now.Add(-48 * time.Hour),
+	}
+
+	tests := []struct {
+		name   string
+		filter sessionFilter
+		want   bool
+	}{
+		{name: "empty", filter: sessionFilter{}, want: true},
+		{name: "model", filter: sessionFilter{Model: "Claude"}, want: true},
+		{name: "other model", filter: sessionFilter{Model: "gpt"}, want: false},
+		{name: "tag", filter: sessionFilter{Tag: "Work"}, want: true},
+		{name: "partial tag", filter: sessionFilter{Tag: "wor"}, want: false},
+		{name: "title text", filter: sessionFilter{Text: "review"}, want: true},
+		{name: "preview text", filter: sessionFilter{Text: "diff"}, want: true},
+		{name: "since", filter: sessionFilter{Since: now.Add(-72 * time.Hour)}, want: true},
+		{name: "too old", filter: sessionFilter{Since: now.Add(-24 * time.Hour)}, want: false},
+		{name: "all", filter: sessionFilter{Model: "sonnet", Tag: "go", Text: "code"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.match(session); got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeArg(t *testing.T) {
+	date, err := parseTimeArg("2025-01-31")
+	if err != nil || !date.Equal(time.Date(2025, 1, 31, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("parseTimeArg(2025-01-31) = %v, %v", date, err)
+	}
+	ago, err := parseTimeArg("7d")
+	if want := time.Now().Add(-7 * 24 * time.Hour); err != nil || ago.Sub(want).Abs() > time.Minute {
+		t.Errorf("parseTimeArg(7d) = %v, %v, want about %v", ago, err, want)
+	}
+	if _, err := parseTimeArg("last tuesday"); err == nil {
+		t.Error("parseTimeArg(last tuesday) succeeded")
+	}
+}
+
+func TestHighlightRanges(t *testing.T) {
+	mark := func(s string) string { return "[" + s + "]" }
+	tests := []struct {
+		s      string
+		ranges [][2]int
+		want   string
+	}{
+		{s: "run the migration script", ranges: [][2]int{{8, 17}, {18, 24}}, want: "run the [migration] [script]"},
+		{s: "abcdef", ranges: [][2]int{{0, 3}, {2, 5}}, want: "[abc][de]f"},
+		{s: "abc", ranges: nil, want: "abc"},
+	}
+	for _, tt := range tests {
+		if got := highlightRanges(tt.s, tt.ranges, mark); got != tt.want {
+			t.Errorf("highlightRanges(%q, %v) = %q, want %q", tt.s, tt.ranges, got, tt.want)
+		}
+	}
+}
+
+func TestSessionsPruneEmpty(t *testing.T) {
+	originalGetSessionDir := config.GetSessionDir
+	dir := t.TempDir()
+	config.GetSessionDir = func() (string, error) { return dir, nil }
+	defer func() { config.GetSessionDir = originalGetSessionDir }()
+	defer func(empty bool) { pruneEmpty = empty }(pruneEmpty)
+	pruneEmpty = true
+
+	empty := config.NewSession()
+	if err := empty.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// A new branch started before the first message leaves the active
+	// branch empty while the other still holds the conversation
+	branched := config.NewSession()
+	if err := branched.AppendMessage("user", "Keep me"); err != nil {
+		t.Fatal(err)
+	}
+	branched.Head, branched.Messages = 0, nil
+	if err := branched.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	sessionsPruneCmd.SetOut(&out)
+	defer sessionsPruneCmd.SetOut(nil)
+	if err := runSessionsPrune(sessionsPruneCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.LoadSession(empty.ID); err == nil {
+		t.Error("session without messages was not pruned")
+	}
+	if _, err := config.LoadSession(branched.ID); err != nil {
+		t.Errorf("session with an empty active branch was pruned: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/schema"
+)
+
+// structuredAttempts is the number of requests made before giving up on
+// output that fails validation: the original plus one corrective retry.
+const structuredAttempts = 2
+
+// structuredOutput describes the JSON a single-turn response must contain.
+type structuredOutput struct {
+	Format *api.ResponseFormat
+	Schema *schema.Schema // nil when any JSON object is accepted
+}
+
+// invalidSchemaName matches characters not allowed in a response format name.
+var invalidSchemaName = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// loadSchemaFile reads a JSON Schema file and builds a json_schema response format.
+// The format name is taken from the schema title, falling back to the file name.
+func loadSchemaFile(path string, strict bool) (*structuredOutput, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+	s, err := schema.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema file %s: %w", path, err)
+	}
+
+	var meta struct {
+		Title string `json:"title"`
+	}
+	json.Unmarshal(data, &meta)
+	name := meta.Title
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	name = strings.Trim(invalidSchemaName.ReplaceAllString(name, "_"), "_")
+	if name == "" {
+		name = "response"
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+
+	return &structuredOutput{
+		Format: api.NewJSONSchemaFormat(name, s.Raw(), strict),
+		Schema: s,
+	}, nil
+}
+
+// check extracts the JSON document from content and validates it.
+// Returns the compacted JSON, which is also returned alongside a validation
+// error when the content is JSON but does not match the schema.
+func (o *structuredOutput) check(content string) (string, error) {
+	doc := stripCodeFence(content)
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(doc)); err != nil {
+		return "", fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	if o.Schema == nil {
+		if !strings.HasPrefix(compact.String(), "{") {
+			return compact.String(), fmt.Errorf("response is not a JSON object")
+		}
+		return compact.String(), nil
+	}
+	if err := o.Schema.ValidateJSON(compact.Bytes()); err != nil {
+		return compact.String(), fmt.Errorf("response does not match schema: %w", err)
+	}
+	return compact.String(), nil
+}
+
+// stripCodeFence removes a surrounding markdown code fence, which some
+// models add even when asked for raw JSON.
+func stripCodeFence(content string) string {
+	s := strings.TrimSpace(content)
+	if !strings.HasPrefix(s, "```") || !strings.HasSuffix(s, "```") || len(s) < 6 {
+		return s
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "```"), "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:] // Drop the language tag line
+	}
+	return strings.TrimSpace(s)
+}
+
+// runStructuredPrompt sends req and validates the response content, retrying
+// once with the validation error fed back to the model. The final output is
+// written to w as compact JSON. Returns the last response's metadata with the
+// combined usage of all attempts, and an error if the output never validated.
+func runStructuredPrompt(ctx context.Context, client api.Client, req *api.ChatRequest, out *structuredOutput, w io.Writer) (responseInfo, error) {
+	messages := append([]api.Message(nil), req.Messages...)
+	var info responseInfo
+	var usage *api.Usage
+	var output string
+	var checkErr error
+
+	for attempt := 0; attempt < structuredAttempts; attempt++ {
+		attemptReq := *req
+		attemptReq.Messages = messages
+		attemptReq.Stream = false
+		attemptReq.ResponseFormat = out.Format
+
+		resp, err := client.Chat(ctx, &attemptReq)
+		if err != nil {
+			return info, err
+		}
+		if resp.Usage != nil {
+			if usage == nil {
+				usage = &api.Usage{}
+			}
+			usage.Add(resp.Usage)
+		}
+		info.recordResponse(resp, usage)
+		if len(resp.Choices) == 0 {
+			return info, fmt.Errorf("no response from model")
+		}
+
+		content := resp.Choices[0].Message.Content
+		output, checkErr = out.check(content)
+		if checkErr == nil {
+			break
+		}
+		if output == "" {
+			output = strings.TrimSpace(content)
+		}
+
+		messages = append(messages,
+			api.Message{Role: "assistant", Content: content},
+			api.Message{Role: "user", Content: fmt.Sprintf(
+				"Your previous response was rejected: %v. Respond again with only the corrected JSON.", checkErr)},
+		)
+	}
+
+	if output != "" {
+		info.Content = output
+		fmt.Fprintln(w, output)
+	}
+	return info, checkErr
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+const testSchema = `{
+	"title": "Person Record",
+	"type": "object",
+	"properties": {"name": {"type": "string"}, "age": {"type": "integer"}},
+	"required": ["name", "age"]
+}`
+
+func writeTestSchema(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "person.json")
+	if err := os.WriteFile(path, []byte(testSchema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// mockResponses returns a mock client that replies with each content in turn.
+func mockResponses(contents ...string) *api.MockClient {
+	client := api.NewMockClient()
+	client.ChatFunc = func(ctx context.Context, req *api.ChatRequest) (*api.ChatResponse, error) {
+		content := contents[len(client.ChatCalls)-1]
+		return &api.ChatResponse{
+			Choices: []api.Choice{{Message: api.ChoiceMessage{Content: content}}},
+			Usage:   &api.Usage{TotalTokens: 10},
+		}, nil
+	}
+	return client
+}
+
+func TestLoadSchemaFile(t *testing.T) {
+	out, err := loadSchemaFile(writeTestSchema(t), true)
+	if err != nil {
+		t.Fatalf("loadSchemaFile() error = %v", err)
+	}
+	if out.Format.Type != api.ResponseFormatJSONSchema {
+		t.Errorf("Type = %q, want json_schema", out.Format.Type)
+	}
+	if out.Format.JSONSchema.Name != "Person_Record" {
+		t.Errorf("Name = %q, want Person_Record", out.Format.JSONSchema.Name)
+	}
+	if !out.Format.JSONSchema.Strict {
+		t.Error("Strict should be true")
+	}
+
+	if _, err := loadSchemaFile(filepath.Join(t.TempDir(), "missing.json"), true); err == nil {
+		t.Error("loadSchemaFile() should fail for a missing file")
+	}
+}
+
+func TestStripCodeFence(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: `{"a":1}`, want: `{"a":1}`},
+		{in: "```json\n{\"a\":1}\n```", want: `{"a":1}`},
+		{in: "```\n{\"a\":1}\n```", want: `{"a":1}`},
+		{in: "  {\"a\":1}\n", want: `{"a":1}`},
+	}
+	for _, tt := range tests {
+		if got := stripCodeFence(tt.in); got != tt.want {
+			t.Errorf("stripCodeFence(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRunStructuredPrompt(t *testing.T) {
+	out, err := loadSchemaFile(writeTestSchema(t), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &api.ChatRequest{
+		Model:    "test-model",
+		Messages: []api.Message{{Role: "user", Content: "Who?"}},
+		Stream:   true,
+	}
+
+	t.Run("valid first time", func(t *testing.T) {
+		client := mockResponses("```json\n{\n  \"name\": \"Ada\",\n  \"age\": 36\n}\n```")
+		var buf bytes.Buffer
+		info, err := runStructuredPrompt(context.Background(), client, req, out, &buf)
+		if err != nil {
+			t.Fatalf("runStructuredPrompt() error = %v", err)
+		}
+		if got := buf.String(); got != "{\"name\":\"Ada\",\"age\":36}\n" {
+			t.Errorf("output = %q", got)
+		}
+		if len(client.ChatCalls) != 1 {
+			t.Errorf("got %d calls, want 1", len(client.ChatCalls))
+		}
+		sent := client.ChatCalls[0].Req
+		if sent.Stream || sent.ResponseFormat != out.Format {
+			t.Error("request should be non-streaming with the schema response format")
+		}
+		if info.Usage == nil || info.Usage.TotalTokens != 10 {
+			t.Errorf("usage = %+v, want 10 tokens", info.Usage)
+		}
+	})
+
+	t.Run("retry with feedback", func(t *testing.T) {
+		client := mockResponses(`{"name":"Ada"}`, `{"name":"Ada","age":36}`)
+		var buf bytes.Buffer
+		info, err := runStructuredPrompt(context.Background(), client, req, out, &buf)
+		if err != nil {
+			t.Fatalf("runStructuredPrompt() error = %v", err)
+		}
+		if len(client.ChatCalls) != 2 {
+			t.Fatalf("got %d calls, want 2", len(client.ChatCalls))
+		}
+		retry := client.ChatCalls[1].Req.Messages
+		if len(retry) != 3 || retry[1].Role != "assistant" || !strings.Contains(retry[2].Content, `missing required property "age"`) {
+			t.Errorf("retry messages = %+v", retry)
+		}
+		if len(req.Messages) != 1 {
+			t.Error("original request messages should not be modified")
+		}
+		if info.Usage.TotalTokens != 20 {
+			t.Errorf("TotalTokens = %d, want 20", info.Usage.TotalTokens)
+		}
+		if got := buf.String(); got != "{\"name\":\"Ada\",\"age\":36}\n" {
+			t.Errorf("output = %q", got)
+		}
+	})
+
+	t.Run("fails after retry", func(t *testing.T) {
+		client := mockResponses(`{"name": 1}`, `{"name": 2}`)
+		var buf bytes.Buffer
+		_, err := runStructuredPrompt(context.Background(), client, req, out, &buf)
+		if err == nil || !strings.Contains(err.Error(), "does not match schema") {
+			t.Errorf("error = %v, want schema mismatch", err)
+		}
+		if got := buf.String(); got != "{\"name\":2}\n" {
+			t.Errorf("output = %q, want last response as compact JSON", got)
+		}
+	})
+
+	t.Run("json object mode", func(t *testing.T) {
+		client := mockResponses(`not json`, `[1]`)
+		var buf bytes.Buffer
+		_, err := runStructuredPrompt(context.Background(), client, req, &structuredOutput{Format: api.NewJSONObjectFormat()}, &buf)
+		if err == nil || !strings.Contains(err.Error(), "not a JSON object") {
+			t.Errorf("error = %v, want not a JSON object", err)
+		}
+	})
+}
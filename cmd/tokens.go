@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/config"
+	"github.com/vstratful/openrouter-cli/internal/tokens"
+	"github.com/vstratful/openrouter-cli/internal/tui"
+)
+
+var (
+	tokensModel string
+	tokensFiles []string
+)
+
+var tokensCmd = &cobra.Command{
+	Use:   "tokens [text]",
+	Short: "Estimate the tokens and cost of text and files for a model",
+	Long: `Estimate how many tokens text and files take up for a model, and what
+sending them as a prompt would cost, without sending anything.
+
+Counts are estimated locally from the model's tokenizer family and are
+approximate; the usage reported after a request is exact.
+
+Examples:
+  openrouter tokens "How many tokens is this?"
+  openrouter tokens -f report.md -f chart.png -m anthropic/claude-sonnet-4
+  git diff | openrouter tokens`,
+	RunE: runTokens,
+}
+
+func init() {
+	rootCmd.AddCommand(tokensCmd)
+	tokensCmd.Flags().StringVarP(&tokensModel, "model", "m", "", "Model to estimate for (default: "+config.DefaultModel+")")
+	tokensCmd.Flags().StringArrayVarP(&tokensFiles, "file", "f", nil, "Count an image, PDF or text file (repeatable)")
+}
+
+// tokenInput is a piece of text or a file to count.
+type tokenInput struct {
+	Label string
+	Part  api.ContentPart
+}
+
+func runTokens(cmd *cobra.Command, args []string) error {
+	apiKey, cfg, isFirstRun, err := getAPIKey()
+	if err != nil {
+		return err
+	}
+	if isFirstRun {
+		printFirstRunHelp()
+		return nil
+	}
+
+	var inputs []tokenInput
+	if text := strings.Join(args, " "); text != "" {
+		inputs = append(inputs, tokenInput{Label: "text", Part: api.ContentPart{Type: "text", Text: text}})
+	}
+	if !isTerminal(os.Stdin) {
+		text, err := readInput(os.Stdin)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(text) != "" {
+			inputs = append(inputs, tokenInput{Label: "stdin", Part: api.ContentPart{Type: "text", Text: text}})
+		}
+	}
+	files, err := loadFiles(tokensFiles)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		inputs = append(inputs, tokenInput{Label: f.Name, Part: f.ContentPart()})
+	}
+	if len(inputs) == 0 {
+		return fmt.Errorf("nothing to count; give text, --file or piped input")
+	}
+
+	modelID := tokensModel
+	if modelID == "" {
+		modelID = cfg.DefaultModel
+	}
+	models, err := newClient(apiKey).ListModels(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch models: %w", err)
+	}
+	model := findModel(models, modelID)
+	if model == nil {
+		return fmt.Errorf("model '%s' not found; run 'openrouter models' to list models", modelID)
+	}
+
+	printTokenCounts(os.Stdout, model, inputs)
+	return nil
+}
+
+// printTokenCounts writes the estimated tokens of each input and what
+// sending them together as a prompt to model would take up and cost.
+func printTokenCounts(w io.Writer, model *api.Model, inputs []tokenInput) {
+	tokenizer := tokens.ForModel(model)
+	fmt.Fprintf(w, "Model: %s (%s tokenizer)\n\n", model.ID, tokenizer.Name)
+
+	width := 0
+	for _, in := range inputs {
+		width = max(width, len(in.Label))
+	}
+	msg := api.Message{Role: "user"}
+	for _, in := range inputs {
+		fmt.Fprintf(w, "  %-*s  ~%d tokens\n", width, in.Label, tokenizer.Part(in.Part))
+		msg.ContentParts = append(msg.ContentParts, in.Part)
+	}
+
+	fmt.Fprintf(w, "\nAs a prompt: %s\n", describeEstimate(model, tokenizer.Messages([]api.Message{msg}), 0))
+}
+
+// describeEstimate summarizes the estimated prompt tokens of a request to
+// model and their cost, plus the cost of completionTokens when positive,
+// noting when the prompt doesn't fit the model's context window.
+func describeEstimate(model *api.Model, promptTokens, completionTokens int) string {
+	desc := fmt.Sprintf("~%d tokens", promptTokens)
+	if limit := model.ContextLength; limit != nil && *limit > 0 {
+		if promptTokens > *limit {
+			desc += fmt.Sprintf(", over the %d-token context window", *limit)
+		} else {
+			desc += fmt.Sprintf(", %d%% of the %d-token context window", promptTokens*100 / *limit, *limit)
+		}
+	}
+
+	cost, err := tokens.Cost(model.Pricing, promptTokens, 0)
+	switch {
+	case errors.Is(err, tokens.ErrVariablePricing):
+		return desc + ", cost varies by the model routed to"
+	case err != nil:
+		return desc + ", cost unknown"
+	}
+	desc += ", ~" + tui.FormatCost(cost)
+	if completionTokens > 0 {
+		if total, err := tokens.Cost(model.Pricing, promptTokens, completionTokens); err == nil {
+			desc += fmt.Sprintf(", up to %s with %d completion tokens", tui.FormatCost(total), completionTokens)
+		}
+	}
+	return desc
+}
+
+// findModel returns the model with the given ID, or nil.
+func findModel(models []api.Model, id string) *api.Model {
+	for i := range models {
+		if models[i].ID == id {
+			return &models[i]
+		}
+	}
+	return nil
+}
+
+// dryRunPrompt prints what a single-turn request would send instead of
+// sending it: the estimated prompt tokens and cost for each model in the
+// chain to info, and the request body to out.
+func dryRunPrompt(ctx context.Context, client api.Client, req *api.ChatRequest, models []string, out, info io.Writer) error {
+	var completionTokens int
+	if req.MaxTokens != nil {
+		completionTokens = *req.MaxTokens
+	}
+
+	// The body is still worth seeing when the models can't be listed
+	list, err := client.ListModels(ctx, nil)
+	if err != nil {
+		fmt.Fprintf(info, "~%d prompt tokens; failed to fetch models for pricing: %v\n", tokens.Messages(req.Messages), err)
+		list, models = nil, nil
+	}
+	for _, id := range models {
+		model := findModel(list, id)
+		if model == nil {
+			fmt.Fprintf(info, "%s: ~%d prompt tokens; model not found\n", id, tokens.Messages(req.Messages))
+			continue
+		}
+		tokenizer := tokens.ForModel(model)
+		fmt.Fprintf(info, "%s (%s tokenizer): %s\n", id, tokenizer.Name,
+			describeEstimate(model, tokenizer.Messages(req.Messages), completionTokens))
+	}
+	return writeJSON(out, req)
+}
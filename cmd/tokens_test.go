@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+func testModel(id, tokenizer string, contextLength int, pricing api.ModelPricing) api.Model {
+	return api.Model{
+		ID:            id,
+		ContextLength: &contextLength,
+		Pricing:       pricing,
+		Architecture:  api.ModelArchitecture{Tokenizer: tokenizer},
+	}
+}
+
+func TestDescribeEstimate(t *testing.T) {
+	priced := api.ModelPricing{Prompt: "0.000003", Completion: "0.000015"}
+	tests := []struct {
+		name             string
+		model            api.Model
+		promptTokens     int
+		completionTokens int
+		want             string
+	}{
+		{
+			name:         "prompt only",
+			model:        testModel("a/model", "Claude", 200_000, priced),
+			promptTokens: 1000,
+			want:         "~1000 tokens, 0% of the 200000-token context window, ~$0.0030",
+		},
+		{
+			name:             "with completion",
+			model:            testModel("a/model", "Claude", 200_000, priced),
+			promptTokens:     10_000,
+			completionTokens: 2000,
+			want:             "~10000 tokens, 5% of the 200000-token context window, ~$0.03, up to $0.06 with 2000 completion tokens",
+		},
+		{
+			name:         "too long",
+			model:        testModel("a/model", "Claude", 8000, priced),
+			promptTokens: 9000,
+			want:         "~9000 tokens, over the 8000-token context window, ~$0.03",
+		},
+		{
+			name:         "router",
+			model:        testModel("openrouter/auto", "Router", 0, api.ModelPricing{Prompt: "-1", Completion: "-1"}),
+			promptTokens: 10,
+			want:         "~10 tokens, cost varies by the model routed to",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeEstimate(&tt.model, tt.promptTokens, tt.completionTokens); got != tt.want {
+				t.Errorf("describeEstimate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintTokenCounts(t *testing.T) {
+	model := testModel("a/model", "GPT", 128_000, api.ModelPricing{Prompt: "0", Completion: "0"})
+	inputs := []tokenInput{
+		{Label: "text", Part: api.ContentPart{Type: "text", Text: strings.Repeat("a", 400)}},
+		{Label: "cat.png", Part: api.ContentPart{Type: "image_url", ImageURL: &api.ImageURL{URL: "data:image/png;base64,AAAA"}}},
+	}
+
+	var buf bytes.Buffer
+	printTokenCounts(&buf, &model, inputs)
+	got := buf.String()
+	for _, want := range []string{
+		"Model: a/model (GPT tokenizer)",
+		"  text     ~100 tokens\n",
+		"  cat.png  ~1000 tokens\n",
+		"As a prompt: ~1107 tokens",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("printTokenCounts() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestDryRunPrompt(t *testing.T) {
+	maxTokens := 500
+	opts := promptOptions{
+		Models: []string{"a/model", "b/missing"},
+		Prompt: "Hello",
+		Stream: true,
+		Settings: chatSettings{
+			System: "Be brief.",
+			Params: api.SamplingParams{MaxTokens: &maxTokens},
+		},
+	}
+	req := newPromptRequest(opts)
+
+	client := api.NewMockClient()
+	client.ListModelsFunc = func(ctx context.Context, opts *api.ListModelsOptions) ([]api.Model, error) {
+		return []api.Model{testModel("a/model", "Claude", 200_000, api.ModelPricing{Prompt: "0.000003", Completion: "0.000015"})}, nil
+	}
+	var out, info bytes.Buffer
+	if err := dryRunPrompt(context.Background(), client, req, opts.Models, &out, &info); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.ChatCalls) != 0 || len(client.ChatStreamCalls) != 0 {
+		t.Error("dryRunPrompt() sent the request")
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(out.Bytes(), &body); err != nil {
+		t.Fatalf("body is not JSON: %v\n%s", err, out.String())
+	}
+	if body["model"] != "a/model" || body["stream"] != true || body["max_tokens"] != float64(500) {
+		t.Errorf("body = %v, want the request as it would be sent", body)
+	}
+	if got := info.String(); !strings.Contains(got, "a/model (Claude tokenizer): ~") ||
+		!strings.Contains(got, "with 500 completion tokens") || !strings.Contains(got, "b/missing: ~") {
+		t.Errorf("estimate = %q, want one line per model", got)
+	}
+
+	// The body is printed even without pricing
+	client.ListModelsFunc = func(ctx context.Context, opts *api.ListModelsOptions) ([]api.Model, error) {
+		return nil, errors.New("offline")
+	}
+	out.Reset()
+	info.Reset()
+	if err := dryRunPrompt(context.Background(), client, req, opts.Models, &out, &info); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() == 0 || !strings.Contains(info.String(), "offline") {
+		t.Errorf("out = %q, info = %q, want the body and the error", out.String(), info.String())
+	}
+}
+
+func TestNewPromptRequest_Structured(t *testing.T) {
+	opts := promptOptions{Models: []string{"a/model"}, Prompt: "List colors", Stream: true, Output: &structuredOutput{Format: api.NewJSONObjectFormat()}}
+	if req := newPromptRequest(opts); req.Stream {
+		t.Error("structured output requests should not stream")
+	}
+}
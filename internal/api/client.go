@@ -32,6 +32,14 @@ const (
 
 	// DefaultMaxBackoff is the default maximum backoff duration.
 	DefaultMaxBackoff = 5 * time.Second
+
+	// DefaultMaxRetryWait is the default cap on a wait requested by the
+	// server through Retry-After or rate limit headers.
+	DefaultMaxRetryWait = 60 * time.Second
+
+	// DefaultStreamIdleTimeout is the default longest gap allowed between
+	// lines of a streaming response before it is considered hung.
+	DefaultStreamIdleTimeout = 30 * time.Second
 )
 
 // Client is the interface for interacting with the OpenRouter API.
@@ -56,6 +64,10 @@ type RetryConfig struct {
 
 	// MaxBackoff is the maximum backoff duration.
 	MaxBackoff time.Duration
+
+	// MaxRetryWait caps the wait requested by the server on rate limited
+	// responses. Defaults to DefaultMaxRetryWait.
+	MaxRetryWait time.Duration
 }
 
 // DefaultRetryConfig returns the default retry configuration.
@@ -64,6 +76,7 @@ func DefaultRetryConfig() RetryConfig {
 		MaxRetries:     DefaultMaxRetries,
 		InitialBackoff: DefaultInitialBackoff,
 		MaxBackoff:     DefaultMaxBackoff,
+		MaxRetryWait:   DefaultMaxRetryWait,
 	}
 }
 
@@ -83,6 +96,15 @@ type ClientConfig struct {
 	// Defaults to DefaultStreamTimeout.
 	StreamTimeout time.Duration
 
+	// StreamIdleTimeout is the longest gap allowed between lines of a
+	// streaming response. Defaults to DefaultStreamIdleTimeout; a negative
+	// value disables it.
+	StreamIdleTimeout time.Duration
+
+	// StrictStream makes malformed stream chunks fail the stream instead of
+	// being skipped.
+	StrictStream bool
+
 	// HTTPClient is an optional custom HTTP client.
 	// If nil, a new client will be created.
 	HTTPClient *http.Client
@@ -99,27 +121,38 @@ type ClientConfig struct {
 
 // DefaultClient creates a new client with default configuration.
 func DefaultClient(apiKey string, timeout time.Duration) Client {
+	return NewClient(DefaultClientConfig(apiKey, timeout))
+}
+
+// DefaultClientConfig returns the configuration used by DefaultClient, for
+// callers that need to adjust it before calling NewClient.
+func DefaultClientConfig(apiKey string, timeout time.Duration) ClientConfig {
 	retryConfig := DefaultRetryConfig()
-	return NewClient(ClientConfig{
+	return ClientConfig{
 		APIKey:        apiKey,
 		Timeout:       timeout,
 		StreamTimeout: timeout,
 		Referer:       "https://github.com/vstratful/openrouter-cli",
 		Title:         "OpenRouter CLI",
 		Retry:         &retryConfig,
-	})
+	}
 }
 
 // ImageClient creates a new client configured for image generation.
 func ImageClient(apiKey string, timeout time.Duration) Client {
+	return NewClient(ImageClientConfig(apiKey, timeout))
+}
+
+// ImageClientConfig returns the configuration used by ImageClient.
+func ImageClientConfig(apiKey string, timeout time.Duration) ClientConfig {
 	retryConfig := DefaultRetryConfig()
-	return NewClient(ClientConfig{
+	return ClientConfig{
 		APIKey:  apiKey,
 		Timeout: timeout,
 		Referer: "https://github.com/vstratful/openrouter-cli",
 		Title:   "OpenRouter CLI",
 		Retry:   &retryConfig,
-	})
+	}
 }
 
 // NewClient creates a new API client with the given configuration.
@@ -133,6 +166,12 @@ func NewClient(cfg ClientConfig) Client {
 	if cfg.StreamTimeout == 0 {
 		cfg.StreamTimeout = DefaultStreamTimeout
 	}
+	if cfg.StreamIdleTimeout == 0 {
+		cfg.StreamIdleTimeout = DefaultStreamIdleTimeout
+	}
+	if cfg.StreamIdleTimeout < 0 {
+		cfg.StreamIdleTimeout = 0
+	}
 
 	httpClient := cfg.HTTPClient
 	if httpClient == nil {
@@ -151,6 +190,8 @@ func NewClient(cfg ClientConfig) Client {
 		baseURL:      cfg.BaseURL,
 		httpClient:   httpClient,
 		streamClient: streamClient,
+		streamIdle:   cfg.StreamIdleTimeout,
+		strict:       cfg.StrictStream,
 		referer:      cfg.Referer,
 		title:        cfg.Title,
 		retry:        cfg.Retry,
@@ -162,6 +203,8 @@ type client struct {
 	baseURL      string
 	httpClient   *http.Client
 	streamClient *http.Client
+	streamIdle   time.Duration
+	strict       bool
 	referer      string
 	title        string
 	retry        *RetryConfig
@@ -295,7 +338,9 @@ func (c *client) ChatStream(ctx context.Context, req *ChatRequest) (*StreamReade
 		},
 		func(resp *http.Response) (*StreamReader, error) {
 			// Note: don't close resp.Body here, StreamReader owns it
-			return NewStreamReader(resp.Body), nil
+			reader := NewStreamReaderContext(ctx, resp.Body, c.streamIdle)
+			reader.SetStrict(c.strict)
+			return reader, nil
 		},
 	)
 }
@@ -22,10 +22,7 @@ func TestClient_Chat(t *testing.T) {
 			response: ChatResponse{
 				Choices: []Choice{
 					{
-						Message: struct {
-							Content string         `json:"content"`
-							Images  []ImageContent `json:"images,omitempty"`
-						}{
+						Message: ChoiceMessage{
 							Content: "Hello, world!",
 						},
 					},
@@ -37,9 +34,7 @@ func TestClient_Chat(t *testing.T) {
 		{
 			name: "api error in response",
 			response: ChatResponse{
-				Error: &struct {
-					Message string `json:"message"`
-				}{
+				Error: &ResponseError{
 					Message: "rate limit exceeded",
 				},
 			},
@@ -357,10 +352,7 @@ func TestClient_Chat_MultipartContent(t *testing.T) {
 		json.NewEncoder(w).Encode(ChatResponse{
 			Choices: []Choice{
 				{
-					Message: struct {
-						Content string         `json:"content"`
-						Images  []ImageContent `json:"images,omitempty"`
-					}{
+					Message: ChoiceMessage{
 						Content: "Done",
 					},
 				},
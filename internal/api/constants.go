@@ -6,3 +6,7 @@ const (
 	SSECommentPrefix = ":"
 	StreamEndSignal  = "[DONE]"
 )
+
+// FinishReasonError is the finish reason of a chunk whose generation failed
+// midway.
+const FinishReasonError = "error"
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// EncodeDataURL returns data as a base64 data URL with the given MIME type.
+func EncodeDataURL(mimeType string, data []byte) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// DecodeDataURL returns the MIME type and decoded content of a base64 data
+// URL of the form data:<mediatype>;base64,<data>.
+func DecodeDataURL(url string) (string, []byte, error) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return "", nil, fmt.Errorf("not a data URL")
+	}
+	mimeType, encoded, ok := strings.Cut(rest, ";base64,")
+	if !ok {
+		return "", nil, fmt.Errorf("data URL is not base64 encoded")
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decode data URL: %w", err)
+	}
+	return mimeType, data, nil
+}
+
+// IsDataURL reports whether url is an inline data URL.
+func IsDataURL(url string) bool {
+	return strings.HasPrefix(url, "data:")
+}
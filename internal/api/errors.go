@@ -1,9 +1,12 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // Sentinel errors for common API error conditions.
@@ -12,13 +15,18 @@ var (
 	ErrRateLimited        = errors.New("rate limited")
 	ErrServiceUnavailable = errors.New("service unavailable")
 	ErrStreamClosed       = errors.New("stream closed")
+	ErrStreamIdle         = errors.New("stream idle timeout")
+	ErrMalformedChunk     = errors.New("malformed stream chunk")
 )
 
 // APIError represents an error from the OpenRouter API.
+// RetryAfter and RateLimit are set from the response headers when present.
 type APIError struct {
 	StatusCode int
 	Message    string
 	Body       string
+	RetryAfter time.Duration
+	RateLimit  *RateLimit
 }
 
 func (e *APIError) Error() string {
@@ -28,9 +36,29 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
 }
 
+// RetryDelay returns how long the server asked the client to wait before
+// retrying: the Retry-After header, or the time until the rate limit resets
+// when no requests remain. Returns 0 if the server gave no guidance.
+func (e *APIError) RetryDelay() time.Duration {
+	if e.RetryAfter > 0 {
+		return e.RetryAfter
+	}
+	if e.RateLimit != nil && e.RateLimit.Remaining == 0 && !e.RateLimit.Reset.IsZero() {
+		if d := time.Until(e.RateLimit.Reset); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
 // Unwrap returns the underlying sentinel error based on status code.
 func (e *APIError) Unwrap() error {
-	switch e.StatusCode {
+	return statusError(e.StatusCode)
+}
+
+// statusError returns the sentinel error for an HTTP status code, or nil.
+func statusError(code int) error {
+	switch code {
 	case http.StatusUnauthorized:
 		return ErrUnauthorized
 	case http.StatusTooManyRequests:
@@ -58,3 +86,93 @@ func (e *StreamError) Error() string {
 func (e *StreamError) Unwrap() error {
 	return e.Cause
 }
+
+// ResponseError is the error object of a response body. It replaces the
+// response on failure, or appears in a stream chunk when the provider fails
+// after streaming has started.
+// Code is an HTTP status or a provider error code such as "server_error".
+type ResponseError struct {
+	Code     string          `json:"code"`
+	Message  string          `json:"message"`
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
+
+// UnmarshalJSON accepts the code as either a number or a string.
+func (e *ResponseError) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Code     json.RawMessage `json:"code"`
+		Message  string          `json:"message"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	e.Message = raw.Message
+	e.Metadata = raw.Metadata
+	e.Code = ""
+	if len(raw.Code) > 0 && string(raw.Code) != "null" {
+		var code string
+		if err := json.Unmarshal(raw.Code, &code); err != nil {
+			code = string(raw.Code)
+		}
+		e.Code = code
+	}
+	return nil
+}
+
+// ProviderError reports a failure signalled inside a stream, through an
+// error object or a finish_reason of "error". Content received before it is
+// still valid.
+type ProviderError struct {
+	Code     string
+	Message  string
+	Model    string
+	Provider string
+}
+
+func (e *ProviderError) Error() string {
+	msg := "provider error"
+	if e.Provider != "" {
+		msg += " from " + e.Provider
+	}
+	if e.Code != "" {
+		msg += fmt.Sprintf(" (code %s)", e.Code)
+	}
+	return msg + ": " + e.Message
+}
+
+// Unwrap returns the sentinel error for numeric HTTP status codes.
+func (e *ProviderError) Unwrap() error {
+	status, err := strconv.Atoi(e.Code)
+	if err != nil {
+		return nil
+	}
+	return statusError(status)
+}
+
+// MalformedChunkError is returned in strict mode when a stream event's data
+// is not a valid chunk.
+type MalformedChunkError struct {
+	Data string
+	Err  error
+}
+
+// maxMalformedData bounds how much of a malformed chunk is quoted in errors.
+const maxMalformedData = 200
+
+func (e *MalformedChunkError) Error() string {
+	data := e.Data
+	if len(data) > maxMalformedData {
+		data = data[:maxMalformedData] + "..."
+	}
+	return fmt.Sprintf("%v %q: %v", ErrMalformedChunk, data, e.Err)
+}
+
+// Is reports whether target is ErrMalformedChunk.
+func (e *MalformedChunkError) Is(target error) bool {
+	return target == ErrMalformedChunk
+}
+
+func (e *MalformedChunkError) Unwrap() error {
+	return e.Err
+}
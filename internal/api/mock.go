@@ -50,10 +50,7 @@ func NewMockClient() *MockClient {
 			return &ChatResponse{
 				Choices: []Choice{
 					{
-						Message: struct {
-							Content string         `json:"content"`
-							Images  []ImageContent `json:"images,omitempty"`
-						}{
+						Message: ChoiceMessage{
 							Content: "mock response",
 						},
 					},
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SamplingParams holds the optional generation parameters of a chat request.
+// Nil fields are omitted from the request so the provider defaults apply.
+type SamplingParams struct {
+	Temperature       *float64 `json:"temperature,omitempty"`
+	TopP              *float64 `json:"top_p,omitempty"`
+	TopK              *int     `json:"top_k,omitempty"`
+	MaxTokens         *int     `json:"max_tokens,omitempty"`
+	Stop              []string `json:"stop,omitempty"`
+	Seed              *int     `json:"seed,omitempty"`
+	FrequencyPenalty  *float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty   *float64 `json:"presence_penalty,omitempty"`
+	RepetitionPenalty *float64 `json:"repetition_penalty,omitempty"`
+	MinP              *float64 `json:"min_p,omitempty"`
+}
+
+// Names returns the API names of the parameters that are set, in request order.
+func (p SamplingParams) Names() []string {
+	var names []string
+	if p.Temperature != nil {
+		names = append(names, "temperature")
+	}
+	if p.TopP != nil {
+		names = append(names, "top_p")
+	}
+	if p.TopK != nil {
+		names = append(names, "top_k")
+	}
+	if p.MaxTokens != nil {
+		names = append(names, "max_tokens")
+	}
+	if len(p.Stop) > 0 {
+		names = append(names, "stop")
+	}
+	if p.Seed != nil {
+		names = append(names, "seed")
+	}
+	if p.FrequencyPenalty != nil {
+		names = append(names, "frequency_penalty")
+	}
+	if p.PresencePenalty != nil {
+		names = append(names, "presence_penalty")
+	}
+	if p.RepetitionPenalty != nil {
+		names = append(names, "repetition_penalty")
+	}
+	if p.MinP != nil {
+		names = append(names, "min_p")
+	}
+	return names
+}
+
+// IsZero returns true if no parameters are set.
+func (p SamplingParams) IsZero() bool {
+	return len(p.Names()) == 0
+}
+
+// Merge returns p with every parameter set in override replacing its value.
+func (p SamplingParams) Merge(override SamplingParams) SamplingParams {
+	merged := p
+	if override.Temperature != nil {
+		merged.Temperature = override.Temperature
+	}
+	if override.TopP != nil {
+		merged.TopP = override.TopP
+	}
+	if override.TopK != nil {
+		merged.TopK = override.TopK
+	}
+	if override.MaxTokens != nil {
+		merged.MaxTokens = override.MaxTokens
+	}
+	if len(override.Stop) > 0 {
+		merged.Stop = override.Stop
+	}
+	if override.Seed != nil {
+		merged.Seed = override.Seed
+	}
+	if override.FrequencyPenalty != nil {
+		merged.FrequencyPenalty = override.FrequencyPenalty
+	}
+	if override.PresencePenalty != nil {
+		merged.PresencePenalty = override.PresencePenalty
+	}
+	if override.RepetitionPenalty != nil {
+		merged.RepetitionPenalty = override.RepetitionPenalty
+	}
+	if override.MinP != nil {
+		merged.MinP = override.MinP
+	}
+	return merged
+}
+
+// Validate checks that every set parameter is within the range the API accepts.
+func (p SamplingParams) Validate() error {
+	checkRange := func(name string, v *float64, lo, hi float64) error {
+		if v != nil && (*v < lo || *v > hi) {
+			return fmt.Errorf("%s must be between %g and %g, got %g", name, lo, hi, *v)
+		}
+		return nil
+	}
+
+	if err := checkRange("temperature", p.Temperature, 0, 2); err != nil {
+		return err
+	}
+	if err := checkRange("top_p", p.TopP, 0, 1); err != nil {
+		return err
+	}
+	if p.TopK != nil && *p.TopK < 0 {
+		return fmt.Errorf("top_k must be 0 or greater, got %d", *p.TopK)
+	}
+	if p.MaxTokens != nil && *p.MaxTokens < 1 {
+		return fmt.Errorf("max_tokens must be 1 or greater, got %d", *p.MaxTokens)
+	}
+	if err := checkRange("frequency_penalty", p.FrequencyPenalty, -2, 2); err != nil {
+		return err
+	}
+	if err := checkRange("presence_penalty", p.PresencePenalty, -2, 2); err != nil {
+		return err
+	}
+	if err := checkRange("repetition_penalty", p.RepetitionPenalty, 0, 2); err != nil {
+		return err
+	}
+	if err := checkRange("min_p", p.MinP, 0, 1); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SupportsParameter returns true if the model lists the parameter as supported.
+func (m *Model) SupportsParameter(name string) bool {
+	for _, p := range m.SupportedParameters {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UnsupportedParameters returns the named parameters the model does not support.
+// Models that do not advertise any supported parameters are not checked.
+func (m *Model) UnsupportedParameters(names []string) []string {
+	if len(m.SupportedParameters) == 0 {
+		return nil
+	}
+	var unsupported []string
+	for _, name := range names {
+		if !m.SupportsParameter(name) {
+			unsupported = append(unsupported, name)
+		}
+	}
+	return unsupported
+}
+
+// ValidateParameters returns an error if the model does not support any of
+// the named parameters.
+func (m *Model) ValidateParameters(names []string) error {
+	if unsupported := m.UnsupportedParameters(names); len(unsupported) > 0 {
+		return fmt.Errorf("model '%s' does not support parameter(s): %s", m.ID, strings.Join(unsupported, ", "))
+	}
+	return nil
+}
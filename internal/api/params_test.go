@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+func intPtr(v int) *int           { return &v }
+
+func TestSamplingParams_Names(t *testing.T) {
+	p := SamplingParams{
+		Temperature: floatPtr(0.7),
+		MaxTokens:   intPtr(100),
+		Stop:        []string{"\n\n"},
+		MinP:        floatPtr(0.1),
+	}
+	want := []string{"temperature", "max_tokens", "stop", "min_p"}
+	if got := p.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+	if p.IsZero() {
+		t.Error("IsZero() should be false when parameters are set")
+	}
+	if !(SamplingParams{}).IsZero() {
+		t.Error("IsZero() should be true for empty params")
+	}
+}
+
+func TestSamplingParams_Merge(t *testing.T) {
+	base := SamplingParams{Temperature: floatPtr(0.2), MaxTokens: intPtr(100), Stop: []string{"END"}}
+	override := SamplingParams{Temperature: floatPtr(1.0), Seed: intPtr(7)}
+
+	got := base.Merge(override)
+	want := SamplingParams{Temperature: floatPtr(1.0), MaxTokens: intPtr(100), Stop: []string{"END"}, Seed: intPtr(7)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %+v, want %+v", got, want)
+	}
+	if *base.Temperature != 0.2 {
+		t.Error("Merge() modified the receiver")
+	}
+}
+
+func TestSamplingParams_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  SamplingParams
+		wantErr bool
+	}{
+		{name: "empty", params: SamplingParams{}},
+		{name: "valid temperature", params: SamplingParams{Temperature: floatPtr(1.5)}},
+		{name: "temperature too high", params: SamplingParams{Temperature: floatPtr(2.5)}, wantErr: true},
+		{name: "top_p too high", params: SamplingParams{TopP: floatPtr(1.1)}, wantErr: true},
+		{name: "negative top_k", params: SamplingParams{TopK: intPtr(-1)}, wantErr: true},
+		{name: "zero max_tokens", params: SamplingParams{MaxTokens: intPtr(0)}, wantErr: true},
+		{name: "negative frequency penalty", params: SamplingParams{FrequencyPenalty: floatPtr(-1.5)}},
+		{name: "presence penalty too low", params: SamplingParams{PresencePenalty: floatPtr(-3)}, wantErr: true},
+		{name: "negative repetition penalty", params: SamplingParams{RepetitionPenalty: floatPtr(-0.1)}, wantErr: true},
+		{name: "min_p out of range", params: SamplingParams{MinP: floatPtr(2)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestModel_UnsupportedParameters(t *testing.T) {
+	params := SamplingParams{Temperature: floatPtr(0.5), TopK: intPtr(40), Seed: intPtr(7)}.Names()
+
+	m := &Model{ID: "test", SupportedParameters: []string{"temperature", "seed", "max_tokens"}}
+	if got := m.UnsupportedParameters(params); !reflect.DeepEqual(got, []string{"top_k"}) {
+		t.Errorf("UnsupportedParameters() = %v, want [top_k]", got)
+	}
+	if err := m.ValidateParameters(params); err == nil {
+		t.Error("ValidateParameters() should fail for unsupported top_k")
+	}
+
+	// Models without advertised parameters are not checked
+	unknown := &Model{ID: "unknown"}
+	if got := unknown.UnsupportedParameters(params); got != nil {
+		t.Errorf("UnsupportedParameters() = %v, want nil", got)
+	}
+}
+
+func TestChatRequest_MarshalSamplingParams(t *testing.T) {
+	req := ChatRequest{
+		Model:    "test-model",
+		Messages: []Message{{Role: "user", Content: "hi"}},
+		SamplingParams: SamplingParams{
+			Temperature: floatPtr(0),
+			Seed:        intPtr(42),
+		},
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal error = %v", err)
+	}
+	var raw map[string]interface{}
+	json.Unmarshal(data, &raw)
+
+	// Parameters are flattened into the request and zero values are kept
+	if v, ok := raw["temperature"]; !ok || v != float64(0) {
+		t.Errorf("temperature = %v, want 0", v)
+	}
+	if raw["seed"] != float64(42) {
+		t.Errorf("seed = %v, want 42", raw["seed"])
+	}
+	if _, ok := raw["top_p"]; ok {
+		t.Error("unset top_p should be omitted")
+	}
+}
+
+func TestChatRequest_ParameterNames(t *testing.T) {
+	req := ChatRequest{
+		SamplingParams: SamplingParams{Temperature: floatPtr(0.2)},
+		Tools:          []Tool{NewFunctionTool("ls", "", nil)},
+		Reasoning:      &ReasoningOptions{Effort: ReasoningEffortLow},
+	}
+	want := []string{"temperature", "tools", "reasoning"}
+	if got := req.ParameterNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParameterNames() = %v, want %v", got, want)
+	}
+}
+
+func TestChatRequest_ResponseFormat(t *testing.T) {
+	schema := json.RawMessage(`{"type":"object"}`)
+	req := ChatRequest{ResponseFormat: NewJSONSchemaFormat("result", schema, true)}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal error = %v", err)
+	}
+	want := `"response_format":{"type":"json_schema","json_schema":{"name":"result","schema":{"type":"object"},"strict":true}}`
+	if !strings.Contains(string(data), want) {
+		t.Errorf("Marshal() = %s, want containing %s", data, want)
+	}
+	if got := req.ParameterNames(); !reflect.DeepEqual(got, []string{"structured_outputs"}) {
+		t.Errorf("ParameterNames() = %v, want [structured_outputs]", got)
+	}
+
+	req.ResponseFormat = NewJSONObjectFormat()
+	if got := req.ParameterNames(); !reflect.DeepEqual(got, []string{"response_format"}) {
+		t.Errorf("ParameterNames() = %v, want [response_format]", got)
+	}
+}
+
+func TestChatRequest_SetModels(t *testing.T) {
+	var req ChatRequest
+	req.SetModels([]string{"a/primary", "b/fallback"})
+	if req.Model != "a/primary" || !reflect.DeepEqual(req.Models, []string{"a/primary", "b/fallback"}) {
+		t.Errorf("Model = %q, Models = %v", req.Model, req.Models)
+	}
+
+	req.SetModels([]string{"a/primary"})
+	if req.Model != "a/primary" || req.Models != nil {
+		t.Errorf("single model: Model = %q, Models = %v, want no models array", req.Model, req.Models)
+	}
+	data, _ := json.Marshal(req)
+	if strings.Contains(string(data), `"models"`) {
+		t.Errorf("Marshal() = %s, should omit models", data)
+	}
+}
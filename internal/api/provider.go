@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Provider sort strategies accepted by the API.
+const (
+	ProviderSortPrice      = "price"
+	ProviderSortThroughput = "throughput"
+	ProviderSortLatency    = "latency"
+)
+
+// Data collection policies accepted by the API.
+const (
+	DataCollectionAllow = "allow"
+	DataCollectionDeny  = "deny"
+)
+
+// validQuantizations lists the quantization levels accepted by the API.
+var validQuantizations = []string{"int4", "int8", "fp4", "fp6", "fp8", "fp16", "bf16", "fp32", "unknown"}
+
+// ProviderPreferences controls which upstream providers may serve a request.
+// Nil and empty fields are omitted so the API defaults apply.
+type ProviderPreferences struct {
+	Order             []string `json:"order,omitempty"`
+	Only              []string `json:"only,omitempty"`
+	Ignore            []string `json:"ignore,omitempty"`
+	AllowFallbacks    *bool    `json:"allow_fallbacks,omitempty"`
+	RequireParameters *bool    `json:"require_parameters,omitempty"`
+	DataCollection    string   `json:"data_collection,omitempty"`
+	Quantizations     []string `json:"quantizations,omitempty"`
+	Sort              string   `json:"sort,omitempty"`
+}
+
+// IsZero returns true if no preference is set.
+func (p *ProviderPreferences) IsZero() bool {
+	return p == nil || (len(p.Order) == 0 && len(p.Only) == 0 && len(p.Ignore) == 0 &&
+		p.AllowFallbacks == nil && p.RequireParameters == nil && p.DataCollection == "" &&
+		len(p.Quantizations) == 0 && p.Sort == "")
+}
+
+// Merge returns a copy of p with every field set in override replacing the
+// corresponding field of p. Either may be nil.
+func (p *ProviderPreferences) Merge(override *ProviderPreferences) *ProviderPreferences {
+	var merged ProviderPreferences
+	if p != nil {
+		merged = *p
+	}
+	if override == nil {
+		return &merged
+	}
+	if len(override.Order) > 0 {
+		merged.Order = override.Order
+	}
+	if len(override.Only) > 0 {
+		merged.Only = override.Only
+	}
+	if len(override.Ignore) > 0 {
+		merged.Ignore = override.Ignore
+	}
+	if override.AllowFallbacks != nil {
+		merged.AllowFallbacks = override.AllowFallbacks
+	}
+	if override.RequireParameters != nil {
+		merged.RequireParameters = override.RequireParameters
+	}
+	if override.DataCollection != "" {
+		merged.DataCollection = override.DataCollection
+	}
+	if len(override.Quantizations) > 0 {
+		merged.Quantizations = override.Quantizations
+	}
+	if override.Sort != "" {
+		merged.Sort = override.Sort
+	}
+	return &merged
+}
+
+// Validate checks the enumerated preference values.
+func (p *ProviderPreferences) Validate() error {
+	if p == nil {
+		return nil
+	}
+	switch p.DataCollection {
+	case "", DataCollectionAllow, DataCollectionDeny:
+	default:
+		return fmt.Errorf("invalid data collection policy %q; valid values: allow, deny", p.DataCollection)
+	}
+	switch p.Sort {
+	case "", ProviderSortPrice, ProviderSortThroughput, ProviderSortLatency:
+	default:
+		return fmt.Errorf("invalid provider sort %q; valid values: price, throughput, latency", p.Sort)
+	}
+	for _, q := range p.Quantizations {
+		if !isValidQuantization(q) {
+			return fmt.Errorf("invalid quantization %q; valid values: %s", q, strings.Join(validQuantizations, ", "))
+		}
+	}
+	return nil
+}
+
+func isValidQuantization(q string) bool {
+	for _, v := range validQuantizations {
+		if q == v {
+			return true
+		}
+	}
+	return false
+}
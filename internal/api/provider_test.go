@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func boolPtr(v bool) *bool { return &v }
+
+func TestProviderPreferences_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefs   *ProviderPreferences
+		wantErr bool
+	}{
+		{name: "nil", prefs: nil},
+		{name: "valid", prefs: &ProviderPreferences{Sort: ProviderSortThroughput, DataCollection: DataCollectionDeny, Quantizations: []string{"fp8", "bf16"}}},
+		{name: "invalid sort", prefs: &ProviderPreferences{Sort: "cheapest"}, wantErr: true},
+		{name: "invalid data collection", prefs: &ProviderPreferences{DataCollection: "never"}, wantErr: true},
+		{name: "invalid quantization", prefs: &ProviderPreferences{Quantizations: []string{"fp8", "q4"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.prefs.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProviderPreferences_Merge(t *testing.T) {
+	base := &ProviderPreferences{
+		Order:          []string{"openai"},
+		AllowFallbacks: boolPtr(true),
+		DataCollection: DataCollectionDeny,
+	}
+	override := &ProviderPreferences{
+		Order:          []string{"groq", "together"},
+		AllowFallbacks: boolPtr(false),
+	}
+
+	merged := base.Merge(override)
+	if !reflect.DeepEqual(merged.Order, []string{"groq", "together"}) {
+		t.Errorf("Order = %v, want override", merged.Order)
+	}
+	if *merged.AllowFallbacks {
+		t.Error("AllowFallbacks should be overridden to false")
+	}
+	if merged.DataCollection != DataCollectionDeny {
+		t.Errorf("DataCollection = %q, want base value kept", merged.DataCollection)
+	}
+	if !*base.AllowFallbacks || len(base.Order) != 1 {
+		t.Error("Merge should not modify the receiver")
+	}
+
+	var none *ProviderPreferences
+	if !none.Merge(nil).IsZero() {
+		t.Error("merging nil preferences should be zero")
+	}
+}
+
+func TestChatRequest_MarshalProvider(t *testing.T) {
+	req := ChatRequest{
+		Model: "test-model",
+		Provider: &ProviderPreferences{
+			Order:          []string{"groq"},
+			AllowFallbacks: boolPtr(false),
+			Sort:           ProviderSortPrice,
+		},
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal error = %v", err)
+	}
+	var raw struct {
+		Provider map[string]any `json:"provider"`
+	}
+	json.Unmarshal(data, &raw)
+
+	want := map[string]any{
+		"order":           []any{"groq"},
+		"allow_fallbacks": false,
+		"sort":            "price",
+	}
+	if !reflect.DeepEqual(raw.Provider, want) {
+		t.Errorf("provider = %v, want %v", raw.Provider, want)
+	}
+}
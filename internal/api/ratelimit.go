@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit holds the X-RateLimit-* headers of a response.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time // Zero if not reported
+}
+
+// parseRateLimit reads the X-RateLimit-* headers. Returns nil if none are present.
+func parseRateLimit(h http.Header) *RateLimit {
+	limit, hasLimit := headerInt(h, "X-RateLimit-Limit")
+	remaining, hasRemaining := headerInt(h, "X-RateLimit-Remaining")
+	reset := parseResetTime(h.Get("X-RateLimit-Reset"), time.Now())
+	if !hasLimit && !hasRemaining && reset.IsZero() {
+		return nil
+	}
+	return &RateLimit{Limit: limit, Remaining: remaining, Reset: reset}
+}
+
+// parseRetryAfter reads the Retry-After header, given either as a number of
+// seconds or as an HTTP date. Returns 0 if absent or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// parseResetTime reads a rate limit reset value, which providers report as a
+// Unix timestamp in milliseconds or seconds, or as seconds from now.
+func parseResetTime(value string, now time.Time) time.Time {
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}
+	}
+	switch {
+	case n > 1e12:
+		return time.UnixMilli(n)
+	case n > 1e9:
+		return time.Unix(n, 0)
+	default:
+		return now.Add(time.Duration(n) * time.Second)
+	}
+}
+
+func headerInt(h http.Header, key string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(h.Get(key)))
+	return n, err == nil
+}
+
+// RetryEvent describes a retry that is about to wait before the next attempt.
+type RetryEvent struct {
+	Attempt     int           // Number of the upcoming retry, starting at 1
+	Delay       time.Duration // How long the client waits before retrying
+	RateLimited bool          // Server rate limited the request or asked for the delay
+	Err         error         // Error that triggered the retry
+}
+
+// RetryNotifier is called before each retry wait.
+type RetryNotifier func(RetryEvent)
+
+type retryNotifierKey struct{}
+
+// WithRetryNotifier returns a context that reports retries of requests made
+// with it to fn, so callers can show progress instead of silently blocking.
+func WithRetryNotifier(ctx context.Context, fn RetryNotifier) context.Context {
+	return context.WithValue(ctx, retryNotifierKey{}, fn)
+}
+
+// notifyRetry reports a retry to the context's notifier, if any.
+func notifyRetry(ctx context.Context, event RetryEvent) {
+	if fn, ok := ctx.Value(retryNotifierKey{}).(RetryNotifier); ok && fn != nil {
+		fn(event)
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: 0},
+		{value: "5", want: 5 * time.Second},
+		{value: "1.5", want: 1500 * time.Millisecond},
+		{value: "-1", want: 0},
+		{value: now.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second},
+		{value: now.Add(-30 * time.Second).Format(http.TimeFormat), want: 0},
+		{value: "soon", want: 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	if parseRateLimit(http.Header{}) != nil {
+		t.Error("parseRateLimit() should return nil without headers")
+	}
+
+	reset := time.Now().Add(10 * time.Second).Truncate(time.Millisecond)
+	h := http.Header{}
+	h.Set("X-RateLimit-Limit", "20")
+	h.Set("X-RateLimit-Remaining", "0")
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(reset.UnixMilli(), 10))
+
+	rl := parseRateLimit(h)
+	if rl == nil || rl.Limit != 20 || rl.Remaining != 0 {
+		t.Fatalf("parseRateLimit() = %+v", rl)
+	}
+	if !rl.Reset.Equal(reset) {
+		t.Errorf("Reset = %v, want %v", rl.Reset, reset)
+	}
+
+	err := &APIError{StatusCode: http.StatusTooManyRequests, RateLimit: rl}
+	if d := err.RetryDelay(); d <= 0 || d > 10*time.Second {
+		t.Errorf("RetryDelay() = %v, want time until reset", d)
+	}
+}
+
+func TestParseResetTime(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	if got := parseResetTime("1700000005", now); !got.Equal(time.Unix(1700000005, 0)) {
+		t.Errorf("seconds timestamp = %v", got)
+	}
+	if got := parseResetTime("1700000005000", now); !got.Equal(time.Unix(1700000005, 0)) {
+		t.Errorf("milliseconds timestamp = %v", got)
+	}
+	if got := parseResetTime("5", now); !got.Equal(now.Add(5 * time.Second)) {
+		t.Errorf("relative seconds = %v", got)
+	}
+	if got := parseResetTime("", now); !got.IsZero() {
+		t.Errorf("empty = %v, want zero", got)
+	}
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	retryConfig := RetryConfig{
+		MaxRetries:     3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		MaxRetryWait:   20 * time.Millisecond, // Caps the 1s Retry-After
+	}
+	client := NewClient(ClientConfig{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+		Retry:   &retryConfig,
+	})
+
+	var events []RetryEvent
+	ctx := WithRetryNotifier(context.Background(), func(e RetryEvent) {
+		events = append(events, e)
+	})
+
+	start := time.Now()
+	if _, err := client.ListModels(ctx, nil); err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("retry took %v, want wait capped by MaxRetryWait", elapsed)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("got %d retry events, want 1", len(events))
+	}
+	e := events[0]
+	if !e.RateLimited || e.Attempt != 1 || e.Delay != 20*time.Millisecond {
+		t.Errorf("event = %+v, want rate limited attempt 1 with capped delay", e)
+	}
+	var apiErr *APIError
+	if !errors.As(e.Err, &apiErr) || apiErr.RetryAfter != time.Second {
+		t.Errorf("event error = %v, want APIError with 1s RetryAfter", e.Err)
+	}
+}
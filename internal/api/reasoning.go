@@ -0,0 +1,65 @@
+package api
+
+import "fmt"
+
+// Reasoning effort levels accepted by the API.
+const (
+	ReasoningEffortHigh    = "high"
+	ReasoningEffortMedium  = "medium"
+	ReasoningEffortLow     = "low"
+	ReasoningEffortMinimal = "minimal"
+)
+
+// ReasoningOptions controls reasoning (thinking) tokens for models that support them.
+// Effort and MaxTokens are alternative ways to size the reasoning budget.
+// Exclude keeps reasoning internal so it is not returned in the response.
+type ReasoningOptions struct {
+	Effort    string `json:"effort,omitempty"`
+	MaxTokens *int   `json:"max_tokens,omitempty"`
+	Exclude   bool   `json:"exclude,omitempty"`
+}
+
+// Validate checks the reasoning options for invalid combinations.
+func (o *ReasoningOptions) Validate() error {
+	switch o.Effort {
+	case "", ReasoningEffortHigh, ReasoningEffortMedium, ReasoningEffortLow, ReasoningEffortMinimal:
+	default:
+		return fmt.Errorf("invalid reasoning effort %q; valid values: high, medium, low, minimal", o.Effort)
+	}
+	if o.Effort != "" && o.MaxTokens != nil {
+		return fmt.Errorf("reasoning effort and reasoning max tokens are mutually exclusive")
+	}
+	if o.MaxTokens != nil && *o.MaxTokens < 1 {
+		return fmt.Errorf("reasoning max tokens must be 1 or greater, got %d", *o.MaxTokens)
+	}
+	return nil
+}
+
+// ReasoningDetail is a structured reasoning block returned by the API.
+type ReasoningDetail struct {
+	Type    string `json:"type"` // "reasoning.text", "reasoning.summary" or "reasoning.encrypted"
+	ID      string `json:"id,omitempty"`
+	Format  string `json:"format,omitempty"`
+	Index   int    `json:"index,omitempty"`
+	Text    string `json:"text,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	Data    string `json:"data,omitempty"`
+}
+
+// reasoningText returns the readable reasoning text, preferring the plain
+// reasoning field and falling back to text and summary details.
+func reasoningText(reasoning string, details []ReasoningDetail) string {
+	if reasoning != "" {
+		return reasoning
+	}
+	var text string
+	for _, d := range details {
+		switch d.Type {
+		case "reasoning.text":
+			text += d.Text
+		case "reasoning.summary":
+			text += d.Summary
+		}
+	}
+	return text
+}
@@ -0,0 +1,42 @@
+package api
+
+import "testing"
+
+func TestReasoningOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ReasoningOptions
+		wantErr bool
+	}{
+		{name: "empty", opts: ReasoningOptions{}},
+		{name: "effort", opts: ReasoningOptions{Effort: ReasoningEffortHigh}},
+		{name: "max tokens", opts: ReasoningOptions{MaxTokens: intPtr(2000)}},
+		{name: "exclude only", opts: ReasoningOptions{Exclude: true}},
+		{name: "invalid effort", opts: ReasoningOptions{Effort: "extreme"}, wantErr: true},
+		{name: "effort and max tokens", opts: ReasoningOptions{Effort: ReasoningEffortLow, MaxTokens: intPtr(100)}, wantErr: true},
+		{name: "zero max tokens", opts: ReasoningOptions{MaxTokens: intPtr(0)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReasoningText(t *testing.T) {
+	details := []ReasoningDetail{
+		{Type: "reasoning.summary", Summary: "Plan. "},
+		{Type: "reasoning.encrypted", Data: "opaque"},
+		{Type: "reasoning.text", Text: "Step one."},
+	}
+	if got := reasoningText("", details); got != "Plan. Step one." {
+		t.Errorf("reasoningText() = %q, want %q", got, "Plan. Step one.")
+	}
+	if got := reasoningText("plain", details); got != "plain" {
+		t.Errorf("reasoningText() = %q, want plain field to take precedence", got)
+	}
+}
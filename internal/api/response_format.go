@@ -0,0 +1,50 @@
+package api
+
+import "encoding/json"
+
+// Response format types accepted by the API.
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// ResponseFormat constrains the format of the model's output.
+type ResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat describes the schema the output must conform to.
+// With Strict set, providers that support it enforce the schema exactly.
+type JSONSchemaFormat struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+	Strict      bool            `json:"strict,omitempty"`
+}
+
+// NewJSONObjectFormat creates a response format that requests any valid JSON object.
+func NewJSONObjectFormat() *ResponseFormat {
+	return &ResponseFormat{Type: ResponseFormatJSONObject}
+}
+
+// NewJSONSchemaFormat creates a response format that requests output matching schema.
+func NewJSONSchemaFormat(name string, schema json.RawMessage, strict bool) *ResponseFormat {
+	return &ResponseFormat{
+		Type: ResponseFormatJSONSchema,
+		JSONSchema: &JSONSchemaFormat{
+			Name:   name,
+			Schema: schema,
+			Strict: strict,
+		},
+	}
+}
+
+// parameterName returns the supported parameter a model must list for this format.
+func (f *ResponseFormat) parameterName() string {
+	if f.Type == ResponseFormatJSONSchema {
+		return "structured_outputs"
+	}
+	return "response_format"
+}
@@ -2,9 +2,11 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 )
 
 // requestFunc creates and executes an HTTP request.
@@ -33,7 +35,7 @@ func doWithRetry[T any](
 		if err != nil {
 			lastErr = fmt.Errorf("sending request: %w", err)
 			if c.shouldRetry(err, 0, attempt) {
-				if sleepErr := sleep(ctx, c.calculateBackoff(attempt)); sleepErr != nil {
+				if sleepErr := c.waitToRetry(ctx, attempt, lastErr); sleepErr != nil {
 					return zero, sleepErr
 				}
 				continue
@@ -47,20 +49,20 @@ func doWithRetry[T any](
 			body, readErr := io.ReadAll(resp.Body)
 			resp.Body.Close()
 
+			apiErr := &APIError{
+				StatusCode: statusCode,
+				RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+				RateLimit:  parseRateLimit(resp.Header),
+			}
 			if readErr != nil {
-				lastErr = &APIError{
-					StatusCode: statusCode,
-					Message:    fmt.Sprintf("failed to read error body: %v", readErr),
-				}
+				apiErr.Message = fmt.Sprintf("failed to read error body: %v", readErr)
 			} else {
-				lastErr = &APIError{
-					StatusCode: statusCode,
-					Body:       string(body),
-				}
+				apiErr.Body = string(body)
 			}
+			lastErr = apiErr
 
 			if c.shouldRetry(nil, statusCode, attempt) {
-				if sleepErr := sleep(ctx, c.calculateBackoff(attempt)); sleepErr != nil {
+				if sleepErr := c.waitToRetry(ctx, attempt, lastErr); sleepErr != nil {
 					return zero, sleepErr
 				}
 				continue
@@ -72,3 +74,33 @@ func doWithRetry[T any](
 	}
 }
 
+// waitToRetry notifies the context's retry notifier and sleeps before the
+// next attempt.
+func (c *client) waitToRetry(ctx context.Context, attempt int, err error) error {
+	delay, requested := c.retryDelay(attempt, err)
+	notifyRetry(ctx, RetryEvent{
+		Attempt:     attempt + 1,
+		Delay:       delay,
+		RateLimited: requested || errors.Is(err, ErrRateLimited),
+		Err:         err,
+	})
+	return sleep(ctx, delay)
+}
+
+// retryDelay returns how long to wait before retrying. A delay requested by
+// the server through Retry-After or rate limit headers takes precedence over
+// exponential backoff, capped at MaxRetryWait. The second result reports
+// whether the server requested the delay.
+func (c *client) retryDelay(attempt int, err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if delay := apiErr.RetryDelay(); delay > 0 {
+			maxWait := DefaultMaxRetryWait
+			if c.retry != nil && c.retry.MaxRetryWait > 0 {
+				maxWait = c.retry.MaxRetryWait
+			}
+			return min(delay, maxWait), true
+		}
+	}
+	return c.calculateBackoff(attempt), false
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// sseEvent is a dispatched server-sent event.
+// Type is empty for the default "message" event type.
+type sseEvent struct {
+	Type string
+	Data string
+	ID   string
+}
+
+// sseLineReader reads event stream lines terminated by CRLF, LF or a lone
+// CR. Unlike bufio.Scanner it has no line length limit, so large events such
+// as base64 images or long tool call arguments are read whole.
+type sseLineReader struct {
+	r       *bufio.Reader
+	pending []string
+}
+
+func newSSELineReader(r io.Reader) *sseLineReader {
+	return &sseLineReader{r: bufio.NewReader(r)}
+}
+
+// ReadLine returns the next line without its terminator.
+// Returns io.EOF once the stream is exhausted.
+func (l *sseLineReader) ReadLine() (string, error) {
+	if len(l.pending) > 0 {
+		line := l.pending[0]
+		l.pending = l.pending[1:]
+		return line, nil
+	}
+
+	var buf []byte
+	for {
+		chunk, err := l.r.ReadSlice('\n')
+		buf = append(buf, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && len(buf) > 0 {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		break
+	}
+
+	buf = bytes.TrimSuffix(buf, []byte("\n"))
+	buf = bytes.TrimSuffix(buf, []byte("\r"))
+
+	// A lone CR also ends a line
+	lines := strings.Split(string(buf), "\r")
+	l.pending = lines[1:]
+	return lines[0], nil
+}
+
+// sseEventBuilder accumulates fields until a blank line dispatches the event,
+// following the event stream interpretation rules of the HTML standard:
+// comment lines start with a colon, a single space after the field name's
+// colon is dropped and multiple data fields are joined with newlines.
+type sseEventBuilder struct {
+	typ     string
+	id      string
+	data    strings.Builder
+	hasData bool
+}
+
+// add processes a line and returns the event it dispatches, if any.
+func (b *sseEventBuilder) add(line string) (sseEvent, bool) {
+	if line == "" {
+		return b.flush()
+	}
+	if strings.HasPrefix(line, SSECommentPrefix) {
+		return sseEvent{}, false
+	}
+
+	field, value, _ := strings.Cut(line, ":")
+	value = strings.TrimPrefix(value, " ")
+
+	switch field {
+	case "data":
+		if b.hasData {
+			b.data.WriteByte('\n')
+		}
+		b.data.WriteString(value)
+		b.hasData = true
+	case "event":
+		b.typ = value
+	case "id":
+		if !strings.Contains(value, "\x00") {
+			b.id = value
+		}
+	}
+	// "retry" and unknown fields are ignored
+	return sseEvent{}, false
+}
+
+// flush dispatches the pending event. Events without data are discarded.
+func (b *sseEventBuilder) flush() (sseEvent, bool) {
+	event := sseEvent{Type: b.typ, Data: b.data.String(), ID: b.id}
+	ok := b.hasData
+	b.typ = ""
+	b.data.Reset()
+	b.hasData = false
+	// The last event ID persists across events
+	return event, ok
+}
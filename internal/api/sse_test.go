@@ -0,0 +1,117 @@
+package api
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSSELineReader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"LF", "a\nb\n", []string{"a", "b"}},
+		{"CRLF", "a\r\nb\r\n", []string{"a", "b"}},
+		{"lone CR", "a\rb\r\n\n", []string{"a", "b", ""}},
+		{"no final terminator", "a\nb", []string{"a", "b"}},
+		{"blank lines", "a\n\n\nb\n", []string{"a", "", "", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := newSSELineReader(strings.NewReader(tt.input))
+			var got []string
+			for {
+				line, err := lines.ReadLine()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("ReadLine() error = %v", err)
+				}
+				got = append(got, line)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSELineReader_LongLine(t *testing.T) {
+	long := strings.Repeat("x", 1<<20)
+	lines := newSSELineReader(strings.NewReader(long + "\nnext\n"))
+
+	line, err := lines.ReadLine()
+	if err != nil {
+		t.Fatalf("ReadLine() error = %v", err)
+	}
+	if len(line) != len(long) {
+		t.Errorf("len(line) = %d, want %d", len(line), len(long))
+	}
+	if line, _ := lines.ReadLine(); line != "next" {
+		t.Errorf("next line = %q, want %q", line, "next")
+	}
+}
+
+func TestSSEEventBuilder(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []sseEvent
+	}{
+		{
+			name:  "single data field",
+			lines: []string{"data: hello", ""},
+			want:  []sseEvent{{Data: "hello"}},
+		},
+		{
+			name:  "multi-line data joined with newlines",
+			lines: []string{"data: {", "data:  \"a\": 1", "data: }", ""},
+			want:  []sseEvent{{Data: "{\n \"a\": 1\n}"}},
+		},
+		{
+			name:  "no space after colon",
+			lines: []string{"data:hello", ""},
+			want:  []sseEvent{{Data: "hello"}},
+		},
+		{
+			name:  "comments ignored",
+			lines: []string{": keepalive", "data: x", ": more", ""},
+			want:  []sseEvent{{Data: "x"}},
+		},
+		{
+			name:  "event type and id",
+			lines: []string{"event: ping", "id: 7", "data: {}", "", "data: y", ""},
+			want:  []sseEvent{{Type: "ping", ID: "7", Data: "{}"}, {ID: "7", Data: "y"}},
+		},
+		{
+			name:  "events without data discarded",
+			lines: []string{"event: ping", "", "retry: 100", ""},
+			want:  nil,
+		},
+		{
+			name:  "field without colon",
+			lines: []string{"data", ""},
+			want:  []sseEvent{{Data: ""}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b sseEventBuilder
+			var got []sseEvent
+			for _, line := range tt.lines {
+				if event, ok := b.add(line); ok {
+					got = append(got, event)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,33 +1,157 @@
 package api
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"strings"
+	"sync"
+	"time"
 )
 
 // StreamReader reads SSE events from a stream.
+// Lines are read from the body on a separate goroutine so that Next can
+// return as soon as the context is cancelled or the idle timeout expires.
+// By default events whose data is not valid JSON are skipped; in strict mode
+// they end the stream with a MalformedChunkError.
 type StreamReader struct {
-	scanner *bufio.Scanner
-	body    io.ReadCloser
-	done    bool
-	err     error
+	body        io.ReadCloser
+	ctx         context.Context
+	idleTimeout time.Duration
+	strict      bool
+	lines       chan streamLine
+	stop        chan struct{}
+	stopOnce    sync.Once
+	event       sseEventBuilder
+	eof         bool
+	done        bool
+	finishing   bool  // pending tool calls flushed, Done chunk still owed
+	err         error // error to return after the chunk preceding it
+	toolCalls   toolCallAccumulator
+}
+
+// streamLine is a line read from the body, or the error that ended reading.
+type streamLine struct {
+	text string
+	err  error // io.EOF at the end of the body
 }
 
 // NewStreamReader creates a new StreamReader from an io.ReadCloser.
+// The reader has no idle timeout and is not tied to a context.
 func NewStreamReader(body io.ReadCloser) *StreamReader {
-	return &StreamReader{
-		scanner: bufio.NewScanner(body),
-		body:    body,
+	return NewStreamReaderContext(context.Background(), body, 0)
+}
+
+// NewStreamReaderContext creates a StreamReader that stops when ctx is done
+// and fails with ErrStreamIdle when no line arrives within idleTimeout.
+// A zero idleTimeout disables idle detection.
+func NewStreamReaderContext(ctx context.Context, body io.ReadCloser, idleTimeout time.Duration) *StreamReader {
+	r := &StreamReader{
+		body:        body,
+		ctx:         ctx,
+		idleTimeout: idleTimeout,
+		lines:       make(chan streamLine),
+		stop:        make(chan struct{}),
+	}
+	go r.scan()
+	return r
+}
+
+// SetStrict sets whether malformed chunks end the stream with an error
+// instead of being skipped. It must be called before the first Next.
+func (r *StreamReader) SetStrict(strict bool) {
+	r.strict = strict
+}
+
+// scan reads lines from the body until it ends or the reader is closed.
+func (r *StreamReader) scan() {
+	lines := newSSELineReader(r.body)
+	for {
+		text, err := lines.ReadLine()
+		select {
+		case r.lines <- streamLine{text: text, err: err}:
+		case <-r.stop:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// nextEvent returns the next dispatched event, or io.EOF at the end of the
+// body. A final event missing its terminating blank line is still dispatched.
+func (r *StreamReader) nextEvent() (sseEvent, error) {
+	for !r.eof {
+		line, err := r.readLine()
+		if err == io.EOF {
+			r.eof = true
+			break
+		}
+		if err != nil {
+			return sseEvent{}, err
+		}
+		if event, ok := r.event.add(line); ok {
+			return event, nil
+		}
+	}
+	if event, ok := r.event.flush(); ok {
+		return event, nil
+	}
+	return sseEvent{}, io.EOF
+}
+
+// readLine waits for the next line, the context to be done or the idle
+// timeout to expire, whichever comes first.
+func (r *StreamReader) readLine() (string, error) {
+	var idle <-chan time.Time
+	if r.idleTimeout > 0 {
+		timer := time.NewTimer(r.idleTimeout)
+		defer timer.Stop()
+		idle = timer.C
+	}
+
+	select {
+	case line := <-r.lines:
+		if line.err != nil && line.err != io.EOF {
+			// A cancelled request surfaces as a read error on the body
+			if ctxErr := r.ctx.Err(); ctxErr != nil {
+				return "", &StreamError{Message: "cancelled", Cause: ctxErr}
+			}
+			return "", &StreamError{Message: "reading stream", Cause: line.err}
+		}
+		return line.text, line.err
+	case <-r.ctx.Done():
+		return "", &StreamError{Message: "cancelled", Cause: r.ctx.Err()}
+	case <-idle:
+		return "", &StreamError{
+			Message: fmt.Sprintf("no data received for %s", r.idleTimeout),
+			Cause:   ErrStreamIdle,
+		}
 	}
 }
 
 // StreamChunk represents a chunk of streamed content.
+// ToolCalls is only populated once the model has finished a turn, with each
+// call fully assembled from its streamed argument fragments.
+// Reasoning carries incremental reasoning (thinking) text, kept separate from
+// the answer in Content.
+// Images carries images generated by the model.
+// Usage is set on the final chunk when the API reports it.
+// Model and Provider name the model answering and the upstream provider
+// serving the stream, and ID is the generation ID.
 type StreamChunk struct {
+	ID           string
 	Content      string
+	Reasoning    string
+	Images       []ImageContent
 	Done         bool
 	FinishReason *string
+	ToolCalls    []ToolCall
+	Usage        *Usage
+	Model        string
+	Provider     string
 }
 
 // Next reads the next chunk from the stream.
@@ -37,67 +161,141 @@ func (r *StreamReader) Next() (*StreamChunk, error) {
 	if r.done {
 		return nil, nil
 	}
+	if r.finishing {
+		r.done = true
+		return &StreamChunk{Done: true}, nil
+	}
 
-	for r.scanner.Scan() {
-		line := r.scanner.Text()
+	if r.err != nil {
+		err := r.err
+		r.Close()
+		return nil, err
+	}
 
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, SSECommentPrefix) {
-			continue
+	for {
+		event, err := r.nextEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			r.Close()
+			return nil, err
 		}
 
-		// SSE format: "data: {...}"
-		if !strings.HasPrefix(line, SSEDataPrefix) {
+		// Only default "message" events carry completion chunks
+		if event.Type != "" && event.Type != "message" {
 			continue
 		}
 
-		data := strings.TrimPrefix(line, SSEDataPrefix)
-
 		// Stream end signal
-		if data == StreamEndSignal {
-			r.done = true
-			return &StreamChunk{Done: true}, nil
+		if event.Data == StreamEndSignal {
+			return r.finish(), nil
 		}
 
 		var response ChatResponse
-		if err := json.Unmarshal([]byte(data), &response); err != nil {
-			// Skip malformed chunks
-			continue
-		}
-
-		if response.Error != nil {
-			r.done = true
-			return nil, &APIError{
-				Message: response.Error.Message,
+		if err := json.Unmarshal([]byte(event.Data), &response); err != nil {
+			if r.strict {
+				r.Close()
+				return nil, &MalformedChunkError{Data: event.Data, Err: err}
 			}
+			continue
 		}
 
 		if len(response.Choices) > 0 {
 			choice := response.Choices[0]
-			return &StreamChunk{
+			if len(choice.Delta.ToolCalls) > 0 {
+				r.toolCalls.add(choice.Delta.ToolCalls)
+			}
+			chunk := &StreamChunk{
 				Content:      choice.Delta.Content,
+				Reasoning:    reasoningText(choice.Delta.Reasoning, choice.Delta.ReasoningDetails),
+				Images:       choice.Delta.Images,
 				FinishReason: choice.FinishReason,
-			}, nil
+			}
+			chunk.ID = response.ID
+			chunk.Usage = response.Usage
+			chunk.Model = response.Model
+			chunk.Provider = response.Provider
+
+			if err := streamError(&response); err != nil {
+				return r.fail(chunk, err)
+			}
+			if choice.FinishReason != nil {
+				chunk.ToolCalls = r.toolCalls.flush()
+			}
+			return chunk, nil
+		}
+
+		if err := streamError(&response); err != nil {
+			return r.fail(&StreamChunk{Usage: response.Usage}, err)
+		}
+
+		// Usage is typically reported in a final chunk with no choices
+		if response.Usage != nil {
+			return &StreamChunk{ID: response.ID, Usage: response.Usage, Model: response.Model, Provider: response.Provider}, nil
 		}
 	}
 
-	if err := r.scanner.Err(); err != nil {
-		r.done = true
-		return nil, &StreamError{
-			Message: "reading stream",
-			Cause:   err,
+	// Body ended without [DONE] signal
+	return r.finish(), nil
+}
+
+// streamError returns the provider error reported in a chunk, if any.
+func streamError(response *ChatResponse) error {
+	failed := len(response.Choices) > 0 && response.Choices[0].FinishReason != nil &&
+		*response.Choices[0].FinishReason == FinishReasonError
+	if response.Error == nil && !failed {
+		return nil
+	}
+
+	err := &ProviderError{
+		Model:    response.Model,
+		Provider: response.Provider,
+		Message:  "generation stopped with an error",
+	}
+	if response.Error != nil {
+		err.Code = response.Error.Code
+		if response.Error.Message != "" {
+			err.Message = response.Error.Message
 		}
 	}
+	return err
+}
 
-	// Scanner finished without [DONE] signal
+// fail ends the stream with err. If the failing chunk carries content,
+// reasoning, images or usage, it is returned first and err follows on the
+// next call.
+func (r *StreamReader) fail(chunk *StreamChunk, err error) (*StreamChunk, error) {
+	if chunk.Content != "" || chunk.Reasoning != "" || len(chunk.Images) > 0 || chunk.Usage != nil {
+		r.err = err
+		return chunk, nil
+	}
+	r.Close()
+	return nil, err
+}
+
+// finish ends the stream. If tool call fragments are still pending (no
+// finish_reason was received), they are returned first and the Done chunk
+// follows on the next call to Next.
+func (r *StreamReader) finish() *StreamChunk {
+	if r.toolCalls.pending() {
+		r.finishing = true
+		return &StreamChunk{ToolCalls: r.toolCalls.flush()}
+	}
 	r.done = true
-	return &StreamChunk{Done: true}, nil
+	return &StreamChunk{Done: true}
 }
 
-// Close closes the underlying stream.
+// Close closes the underlying stream and stops the reading goroutine.
+// It is safe to call more than once.
 func (r *StreamReader) Close() error {
 	r.done = true
-	return r.body.Close()
+	var err error
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		err = r.body.Close()
+	})
+	return err
 }
 
 // ReadAll reads all content from the stream and returns it as a string.
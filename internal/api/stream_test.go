@@ -1,9 +1,12 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"io"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestStreamReader_ReadAll(t *testing.T) {
@@ -33,7 +36,7 @@ func TestStreamReader_ReadAll(t *testing.T) {
 		},
 		{
 			name:    "malformed json skipped",
-			input:   "data: {invalid}\ndata: {\"choices\":[{\"delta\":{\"content\":\"valid\"}}]}\ndata: [DONE]\n",
+			input:   "data: {invalid}\n\ndata: {\"choices\":[{\"delta\":{\"content\":\"valid\"}}]}\n\ndata: [DONE]\n",
 			want:    "valid",
 			wantErr: false,
 		},
@@ -129,3 +132,339 @@ func TestStreamReader_Close(t *testing.T) {
 		t.Error("Next() after close should return nil")
 	}
 }
+
+func TestStreamReader_ToolCalls(t *testing.T) {
+	input := "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"get_weather\",\"arguments\":\"\"}}]}}]}\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"{\\\"city\\\":\"}}]}}]}\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":1,\"id\":\"call_2\",\"type\":\"function\",\"function\":{\"name\":\"get_time\",\"arguments\":\"{}\"}}]}}]}\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"\\\"Paris\\\"}\"}}]}}]}\n\n" +
+		"data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"tool_calls\"}]}\n\n" +
+		"data: [DONE]\n"
+
+	reader := NewStreamReader(io.NopCloser(strings.NewReader(input)))
+	defer reader.Close()
+
+	var calls []ToolCall
+	for {
+		chunk, err := reader.Next()
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if chunk == nil || chunk.Done {
+			break
+		}
+		if len(chunk.ToolCalls) > 0 {
+			if chunk.FinishReason == nil || *chunk.FinishReason != "tool_calls" {
+				t.Errorf("tool calls should arrive with finish_reason, got %v", chunk.FinishReason)
+			}
+			calls = append(calls, chunk.ToolCalls...)
+		}
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %d", len(calls))
+	}
+	if calls[0].ID != "call_1" || calls[0].Function.Name != "get_weather" {
+		t.Errorf("first call = %+v", calls[0])
+	}
+	if calls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("first call arguments = %q, want %q", calls[0].Function.Arguments, `{"city":"Paris"}`)
+	}
+	if calls[1].ID != "call_2" || calls[1].Function.Name != "get_time" || calls[1].Function.Arguments != "{}" {
+		t.Errorf("second call = %+v", calls[1])
+	}
+}
+
+func TestStreamReader_ToolCallsWithoutFinishReason(t *testing.T) {
+	input := "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"function\":{\"name\":\"ls\",\"arguments\":\"{}\"}}]}}]}\n\n" +
+		"data: [DONE]\n"
+
+	reader := NewStreamReader(io.NopCloser(strings.NewReader(input)))
+	defer reader.Close()
+
+	// Fragment chunk
+	if _, err := reader.Next(); err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+
+	// Pending calls are flushed before the done signal
+	chunk, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if chunk.Done {
+		t.Fatal("pending tool calls should be flushed before Done")
+	}
+	if len(chunk.ToolCalls) != 1 || chunk.ToolCalls[0].Type != "function" {
+		t.Fatalf("ToolCalls = %+v, want one function call", chunk.ToolCalls)
+	}
+
+	chunk, err = reader.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if chunk == nil || !chunk.Done {
+		t.Error("expected Done chunk after flushed tool calls")
+	}
+}
+
+func TestStreamReader_Usage(t *testing.T) {
+	input := "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"},\"finish_reason\":\"stop\"}]}\n\n" +
+		"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":5,\"total_tokens\":15,\"cost\":0.0003,\"completion_tokens_details\":{\"reasoning_tokens\":2}}}\n\n" +
+		"data: [DONE]\n"
+
+	reader := NewStreamReader(io.NopCloser(strings.NewReader(input)))
+	defer reader.Close()
+
+	var usage *Usage
+	for {
+		chunk, err := reader.Next()
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if chunk == nil || chunk.Done {
+			break
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+
+	if usage == nil {
+		t.Fatal("expected usage from final chunk")
+	}
+	if usage.PromptTokens != 10 || usage.CompletionTokens != 5 || usage.TotalTokens != 15 {
+		t.Errorf("usage = %+v", usage)
+	}
+	if usage.Cost != 0.0003 {
+		t.Errorf("Cost = %v, want 0.0003", usage.Cost)
+	}
+	if usage.ReasoningTokens() != 2 {
+		t.Errorf("ReasoningTokens() = %d, want 2", usage.ReasoningTokens())
+	}
+}
+
+func TestStreamReader_Reasoning(t *testing.T) {
+	input := "data: {\"choices\":[{\"delta\":{\"content\":\"\",\"reasoning\":\"Let me think. \"}}]}\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"content\":\"\",\"reasoning_details\":[{\"type\":\"reasoning.text\",\"text\":\"Done.\"}]}}]}\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"content\":\"42\"}}]}\n\n" +
+		"data: [DONE]\n"
+
+	reader := NewStreamReader(io.NopCloser(strings.NewReader(input)))
+	defer reader.Close()
+
+	var content, reasoning string
+	for {
+		chunk, err := reader.Next()
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if chunk == nil || chunk.Done {
+			break
+		}
+		content += chunk.Content
+		reasoning += chunk.Reasoning
+	}
+
+	if reasoning != "Let me think. Done." {
+		t.Errorf("reasoning = %q, want %q", reasoning, "Let me think. Done.")
+	}
+	if content != "42" {
+		t.Errorf("content = %q, want %q", content, "42")
+	}
+}
+
+func TestStreamReader_Provider(t *testing.T) {
+	input := "data: {\"id\":\"gen-123\",\"provider\":\"Groq\",\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n" +
+		"data: [DONE]\n"
+
+	reader := NewStreamReader(io.NopCloser(strings.NewReader(input)))
+	defer reader.Close()
+
+	chunk, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if chunk.Provider != "Groq" {
+		t.Errorf("Provider = %q, want Groq", chunk.Provider)
+	}
+	if chunk.ID != "gen-123" {
+		t.Errorf("ID = %q, want gen-123", chunk.ID)
+	}
+}
+
+func TestStreamReader_IdleTimeout(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	reader := NewStreamReaderContext(context.Background(), pr, 50*time.Millisecond)
+	defer reader.Close()
+
+	go func() {
+		// Keepalive comments count as activity
+		for i := 0; i < 3; i++ {
+			io.WriteString(pw, ": OPENROUTER PROCESSING\n")
+			time.Sleep(20 * time.Millisecond)
+		}
+		io.WriteString(pw, "data: {\"choices\":[{\"delta\":{\"content\":\"late\"}}]}\n\n")
+	}()
+
+	chunk, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if chunk.Content != "late" {
+		t.Errorf("Content = %q, want %q", chunk.Content, "late")
+	}
+
+	_, err = reader.Next()
+	if !errors.Is(err, ErrStreamIdle) {
+		t.Fatalf("Next() error = %v, want ErrStreamIdle", err)
+	}
+	var streamErr *StreamError
+	if !errors.As(err, &streamErr) {
+		t.Errorf("Next() error = %T, want *StreamError", err)
+	}
+
+	// The reader stays finished after the timeout
+	if chunk, err := reader.Next(); chunk != nil || err != nil {
+		t.Errorf("Next() after timeout = %v, %v, want nil, nil", chunk, err)
+	}
+}
+
+func TestStreamReader_ContextCancel(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	reader := NewStreamReaderContext(ctx, pr, 0)
+	defer reader.Close()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := reader.Next()
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Next() error = %v, want context.Canceled", err)
+	}
+	if errors.Is(err, ErrStreamIdle) {
+		t.Error("cancellation should not be reported as an idle timeout")
+	}
+}
+
+func TestStreamReader_LargeEvent(t *testing.T) {
+	content := strings.Repeat("a", 256*1024)
+	input := "data: {\"choices\":[{\"delta\":{\"content\":\"" + content + "\"}}]}\n\ndata: [DONE]\n\n"
+	reader := NewStreamReader(io.NopCloser(strings.NewReader(input)))
+	defer reader.Close()
+
+	got, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if got != content {
+		t.Errorf("len(ReadAll()) = %d, want %d", len(got), len(content))
+	}
+}
+
+func TestStreamReader_MultiLineData(t *testing.T) {
+	input := "data: {\"choices\":[{\"delta\":\r\ndata: {\"content\":\"Hi\"}}]}\r\n\r\n" +
+		"event: ping\r\ndata: {\"choices\":[{\"delta\":{\"content\":\"ignored\"}}]}\r\n\r\n" +
+		"data: [DONE]\r\n\r\n"
+	reader := NewStreamReader(io.NopCloser(strings.NewReader(input)))
+	defer reader.Close()
+
+	got, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if got != "Hi" {
+		t.Errorf("ReadAll() = %q, want %q", got, "Hi")
+	}
+}
+
+func TestStreamReader_Strict(t *testing.T) {
+	input := "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\ndata: {invalid}\n\ndata: [DONE]\n\n"
+
+	lenient := NewStreamReader(io.NopCloser(strings.NewReader(input)))
+	if got, err := lenient.ReadAll(); err != nil || got != "ok" {
+		t.Errorf("lenient ReadAll() = %q, %v, want %q, nil", got, err, "ok")
+	}
+
+	strict := NewStreamReader(io.NopCloser(strings.NewReader(input)))
+	strict.SetStrict(true)
+	got, err := strict.ReadAll()
+	if !errors.Is(err, ErrMalformedChunk) {
+		t.Fatalf("strict ReadAll() error = %v, want ErrMalformedChunk", err)
+	}
+	var malformed *MalformedChunkError
+	if !errors.As(err, &malformed) || malformed.Data != "{invalid}" {
+		t.Errorf("error = %#v, want MalformedChunkError with data %q", err, "{invalid}")
+	}
+	if got != "ok" {
+		t.Errorf("content before error = %q, want %q", got, "ok")
+	}
+}
+
+func TestStreamReader_MidStreamError(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantContent string
+		wantCode    string
+		wantMessage string
+		wantIs      error
+	}{
+		{
+			name: "error object with finish_reason error",
+			input: "data: {\"choices\":[{\"delta\":{\"content\":\"partial\"}}]}\n\n" +
+				"data: {\"provider\":\"Acme\",\"error\":{\"code\":\"server_error\",\"message\":\"Provider disconnected\"},\"choices\":[{\"delta\":{\"content\":\"\"},\"finish_reason\":\"error\"}]}\n\n" +
+				"data: [DONE]\n\n",
+			wantContent: "partial",
+			wantCode:    "server_error",
+			wantMessage: "Provider disconnected",
+		},
+		{
+			name: "numeric code",
+			input: "data: {\"error\":{\"code\":429,\"message\":\"rate limit\"}}\n\n" +
+				"data: [DONE]\n\n",
+			wantCode:    "429",
+			wantMessage: "rate limit",
+			wantIs:      ErrRateLimited,
+		},
+		{
+			name: "finish_reason error without error object",
+			input: "data: {\"choices\":[{\"delta\":{\"content\":\"last words\"},\"finish_reason\":\"error\"}]}\n\n" +
+				"data: [DONE]\n\n",
+			wantContent: "last words",
+			wantMessage: "generation stopped with an error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewStreamReader(io.NopCloser(strings.NewReader(tt.input)))
+			defer reader.Close()
+
+			got, err := reader.ReadAll()
+			if got != tt.wantContent {
+				t.Errorf("content = %q, want %q", got, tt.wantContent)
+			}
+			var providerErr *ProviderError
+			if !errors.As(err, &providerErr) {
+				t.Fatalf("error = %v, want *ProviderError", err)
+			}
+			if providerErr.Code != tt.wantCode || providerErr.Message != tt.wantMessage {
+				t.Errorf("error = %+v, want code %q message %q", providerErr, tt.wantCode, tt.wantMessage)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.wantIs)
+			}
+
+			// The stream stays finished after the error
+			if chunk, err := reader.Next(); chunk != nil || err != nil {
+				t.Errorf("Next() after error = %v, %v, want nil, nil", chunk, err)
+			}
+		})
+	}
+}
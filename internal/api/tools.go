@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Tool choice modes accepted by the API.
+const (
+	ToolChoiceAuto     = "auto"
+	ToolChoiceNone     = "none"
+	ToolChoiceRequired = "required"
+)
+
+// Tool describes a tool the model may call.
+type Tool struct {
+	Type     string             `json:"type"` // "function"
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition describes a callable function and its JSON Schema parameters.
+type FunctionDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Strict      *bool           `json:"strict,omitempty"`
+}
+
+// NewFunctionTool creates a function tool with the given JSON Schema parameters.
+func NewFunctionTool(name, description string, parameters json.RawMessage) Tool {
+	return Tool{
+		Type: "function",
+		Function: FunctionDefinition{
+			Name:        name,
+			Description: description,
+			Parameters:  parameters,
+		},
+	}
+}
+
+// ToolCall represents a complete tool invocation requested by the model.
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"` // "function"
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall holds the function name and its JSON-encoded arguments.
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolCallDelta is a fragment of a tool call received while streaming.
+// Fragments sharing the same Index belong to the same call.
+type ToolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments,omitempty"`
+	} `json:"function"`
+}
+
+// ToolChoice controls whether and which tool the model calls.
+// Mode is one of ToolChoiceAuto, ToolChoiceNone or ToolChoiceRequired.
+// When FunctionName is set, the model is forced to call that function and
+// Mode is ignored.
+type ToolChoice struct {
+	Mode         string
+	FunctionName string
+}
+
+// MarshalJSON implements custom JSON marshaling for ToolChoice.
+// A mode is serialized as a string, a forced function as an object.
+func (c ToolChoice) MarshalJSON() ([]byte, error) {
+	if c.FunctionName != "" {
+		return json.Marshal(struct {
+			Type     string `json:"type"`
+			Function struct {
+				Name string `json:"name"`
+			} `json:"function"`
+		}{
+			Type: "function",
+			Function: struct {
+				Name string `json:"name"`
+			}{Name: c.FunctionName},
+		})
+	}
+	return json.Marshal(c.Mode)
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for ToolChoice.
+func (c *ToolChoice) UnmarshalJSON(data []byte) error {
+	var mode string
+	if err := json.Unmarshal(data, &mode); err == nil {
+		c.Mode = mode
+		c.FunctionName = ""
+		return nil
+	}
+
+	var obj struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	c.Mode = ""
+	c.FunctionName = obj.Function.Name
+	return nil
+}
+
+// toolCallAccumulator assembles streamed tool call fragments into complete calls.
+type toolCallAccumulator struct {
+	calls map[int]*ToolCall
+}
+
+// add merges a batch of fragments into the accumulated calls.
+func (a *toolCallAccumulator) add(deltas []ToolCallDelta) {
+	if a.calls == nil {
+		a.calls = make(map[int]*ToolCall)
+	}
+	for _, d := range deltas {
+		call, ok := a.calls[d.Index]
+		if !ok {
+			call = &ToolCall{Type: "function"}
+			a.calls[d.Index] = call
+		}
+		if d.ID != "" {
+			call.ID = d.ID
+		}
+		if d.Type != "" {
+			call.Type = d.Type
+		}
+		if d.Function.Name != "" && call.Function.Name == "" {
+			call.Function.Name = d.Function.Name
+		}
+		call.Function.Arguments += d.Function.Arguments
+	}
+}
+
+// pending returns true if any fragments have been accumulated.
+func (a *toolCallAccumulator) pending() bool {
+	return len(a.calls) > 0
+}
+
+// flush returns the assembled calls ordered by index and resets the accumulator.
+func (a *toolCallAccumulator) flush() []ToolCall {
+	if len(a.calls) == 0 {
+		return nil
+	}
+	indices := make([]int, 0, len(a.calls))
+	for i := range a.calls {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+
+	calls := make([]ToolCall, 0, len(indices))
+	for _, i := range indices {
+		calls = append(calls, *a.calls[i])
+	}
+	a.calls = nil
+	return calls
+}
@@ -4,57 +4,99 @@ package api
 import "encoding/json"
 
 // ContentPart represents a single part of a multipart message content.
+// Type is "text", "image_url" or "file".
 type ContentPart struct {
-	Type     string    `json:"type"`
-	Text     string    `json:"text,omitempty"`
-	ImageURL *ImageURL `json:"image_url,omitempty"`
+	Type     string       `json:"type"`
+	Text     string       `json:"text,omitempty"`
+	ImageURL *ImageURL    `json:"image_url,omitempty"`
+	File     *FileContent `json:"file,omitempty"`
+}
+
+// FileContent is a document attached to a message, such as a PDF.
+type FileContent struct {
+	Filename string `json:"filename"`
+	FileData string `json:"file_data"` // data:application/pdf;base64,... or a URL
 }
 
 // Message represents a chat message.
 // Content is used for simple string messages. ContentParts is used for
 // multipart messages (e.g., text + images). When both are set, ContentParts
 // takes precedence during marshaling.
+// ToolCalls is set on assistant messages that invoke tools, and ToolCallID
+// links a "tool" role message to the call it answers.
+// Reasoning holds the model's reasoning text for display and is never sent
+// back to the API. Images holds images generated by the model and is likewise
+// never sent back.
 type Message struct {
-	Role         string        `json:"role"`
-	Content      string        `json:"-"`
-	ContentParts []ContentPart `json:"-"`
+	Role         string         `json:"role"`
+	Content      string         `json:"-"`
+	ContentParts []ContentPart  `json:"-"`
+	ToolCalls    []ToolCall     `json:"-"`
+	ToolCallID   string         `json:"-"`
+	Name         string         `json:"-"`
+	Reasoning    string         `json:"-"`
+	Images       []ImageContent `json:"-"`
+}
+
+// WithSystemPrompt returns messages preceded by a system message holding
+// prompt. Messages are returned unchanged when prompt is empty.
+func WithSystemPrompt(prompt string, messages []Message) []Message {
+	if prompt == "" {
+		return messages
+	}
+	return append([]Message{{Role: "system", Content: prompt}}, messages...)
+}
+
+// messageJSON is the wire representation of a Message.
+type messageJSON struct {
+	Role       string     `json:"role"`
+	Content    any        `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Name       string     `json:"name,omitempty"`
 }
 
 // MarshalJSON implements custom JSON marshaling for Message.
 // When ContentParts is populated, content is serialized as an array.
-// Otherwise, content is serialized as a string.
+// Otherwise, content is serialized as a string. Assistant messages that
+// only carry tool calls serialize content as null.
 func (m Message) MarshalJSON() ([]byte, error) {
-	if len(m.ContentParts) > 0 {
-		return json.Marshal(struct {
-			Role    string        `json:"role"`
-			Content []ContentPart `json:"content"`
-		}{
-			Role:    m.Role,
-			Content: m.ContentParts,
-		})
-	}
-	return json.Marshal(struct {
-		Role    string `json:"role"`
-		Content string `json:"content"`
-	}{
-		Role:    m.Role,
-		Content: m.Content,
-	})
+	out := messageJSON{
+		Role:       m.Role,
+		ToolCalls:  m.ToolCalls,
+		ToolCallID: m.ToolCallID,
+		Name:       m.Name,
+	}
+	switch {
+	case len(m.ContentParts) > 0:
+		out.Content = m.ContentParts
+	case m.Content == "" && len(m.ToolCalls) > 0:
+		out.Content = nil
+	default:
+		out.Content = m.Content
+	}
+	return json.Marshal(out)
 }
 
 // UnmarshalJSON implements custom JSON unmarshaling for Message.
 // It detects whether content is a string or array and populates fields accordingly.
 func (m *Message) UnmarshalJSON(data []byte) error {
 	var raw struct {
-		Role    string          `json:"role"`
-		Content json.RawMessage `json:"content"`
+		Role       string          `json:"role"`
+		Content    json.RawMessage `json:"content"`
+		ToolCalls  []ToolCall      `json:"tool_calls"`
+		ToolCallID string          `json:"tool_call_id"`
+		Name       string          `json:"name"`
 	}
 	if err := json.Unmarshal(data, &raw); err != nil {
 		return err
 	}
 	m.Role = raw.Role
+	m.ToolCalls = raw.ToolCalls
+	m.ToolCallID = raw.ToolCallID
+	m.Name = raw.Name
 
-	if len(raw.Content) == 0 {
+	if len(raw.Content) == 0 || string(raw.Content) == "null" {
 		return nil
 	}
 
@@ -88,12 +130,73 @@ type ImageConfig struct {
 }
 
 // ChatRequest represents a request to the chat completions API.
+// Models lists fallback models to try in order when Model is unavailable.
 type ChatRequest struct {
 	Model       string       `json:"model"`
+	Models      []string     `json:"models,omitempty"`
 	Messages    []Message    `json:"messages"`
 	Stream      bool         `json:"stream"`
 	Modalities  []string     `json:"modalities,omitempty"`
 	ImageConfig *ImageConfig `json:"image_config,omitempty"`
+
+	// Sampling and generation parameters
+	SamplingParams
+
+	// Tool calling
+	Tools             []Tool      `json:"tools,omitempty"`
+	ToolChoice        *ToolChoice `json:"tool_choice,omitempty"`
+	ParallelToolCalls *bool       `json:"parallel_tool_calls,omitempty"`
+
+	// Reasoning configures reasoning tokens for models that support them
+	Reasoning *ReasoningOptions `json:"reasoning,omitempty"`
+
+	// ResponseFormat requests JSON output, optionally matching a schema
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+
+	// Provider sets routing preferences for the upstream providers
+	Provider *ProviderPreferences `json:"provider,omitempty"`
+
+	// Usage requests token and cost accounting in the response
+	Usage *UsageOptions `json:"usage,omitempty"`
+
+	// Transforms lists OpenRouter prompt transforms, such as TransformMiddleOut
+	Transforms []string `json:"transforms,omitempty"`
+}
+
+// TransformMiddleOut asks OpenRouter to drop messages from the middle of a
+// prompt that doesn't fit the model's context window.
+const TransformMiddleOut = "middle-out"
+
+// SetModels sets the primary model and any fallbacks from an ordered chain.
+// A single-model chain sends only Model.
+func (r *ChatRequest) SetModels(chain []string) {
+	r.Model, r.Models = "", nil
+	if len(chain) == 0 {
+		return
+	}
+	r.Model = chain[0]
+	if len(chain) > 1 {
+		r.Models = chain
+	}
+}
+
+// ParameterNames returns the API names of the optional parameters set on the
+// request, for checking against a model's supported parameters.
+func (r *ChatRequest) ParameterNames() []string {
+	names := r.SamplingParams.Names()
+	if len(r.Tools) > 0 {
+		names = append(names, "tools")
+	}
+	if r.ToolChoice != nil {
+		names = append(names, "tool_choice")
+	}
+	if r.Reasoning != nil {
+		names = append(names, "reasoning")
+	}
+	if r.ResponseFormat != nil {
+		names = append(names, r.ResponseFormat.parameterName())
+	}
+	return names
 }
 
 // ImageURL represents an image URL in the response.
@@ -107,24 +210,47 @@ type ImageContent struct {
 	ImageURL ImageURL `json:"image_url"`
 }
 
+// ChoiceDelta is the incremental content of a streamed choice.
+type ChoiceDelta struct {
+	Content          string            `json:"content"`
+	Reasoning        string            `json:"reasoning,omitempty"`
+	ReasoningDetails []ReasoningDetail `json:"reasoning_details,omitempty"`
+	Images           []ImageContent    `json:"images,omitempty"`
+	ToolCalls        []ToolCallDelta   `json:"tool_calls,omitempty"`
+}
+
+// ChoiceMessage is the complete message of a non-streamed choice.
+type ChoiceMessage struct {
+	Content          string            `json:"content"`
+	Reasoning        string            `json:"reasoning,omitempty"`
+	ReasoningDetails []ReasoningDetail `json:"reasoning_details,omitempty"`
+	Images           []ImageContent    `json:"images,omitempty"`
+	ToolCalls        []ToolCall        `json:"tool_calls,omitempty"`
+}
+
+// ReasoningText returns the readable reasoning of the message, if any.
+func (m *ChoiceMessage) ReasoningText() string {
+	return reasoningText(m.Reasoning, m.ReasoningDetails)
+}
+
 // Choice represents a completion choice in the response.
 type Choice struct {
-	Delta struct {
-		Content string `json:"content"`
-	} `json:"delta"`
-	Message struct {
-		Content string         `json:"content"`
-		Images  []ImageContent `json:"images,omitempty"`
-	} `json:"message"`
-	FinishReason *string `json:"finish_reason"`
+	Delta        ChoiceDelta   `json:"delta"`
+	Message      ChoiceMessage `json:"message"`
+	FinishReason *string       `json:"finish_reason"`
 }
 
 // ChatResponse represents the response from the chat completions API.
+// Model is the model that answered, which may be a fallback from the
+// request's Models list. Provider names the upstream provider that served it.
+// ID is the generation ID, which can be looked up for detailed stats.
 type ChatResponse struct {
-	Choices []Choice `json:"choices"`
-	Error   *struct {
-		Message string `json:"message"`
-	} `json:"error"`
+	ID       string         `json:"id,omitempty"`
+	Model    string         `json:"model,omitempty"`
+	Provider string         `json:"provider,omitempty"`
+	Choices  []Choice       `json:"choices"`
+	Usage    *Usage         `json:"usage,omitempty"`
+	Error    *ResponseError `json:"error"`
 }
 
 // ModelPricing represents pricing information for a model.
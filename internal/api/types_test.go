@@ -272,3 +272,83 @@ func TestMessage_MarshalUnmarshal_RoundTrip(t *testing.T) {
 		}
 	})
 }
+
+func TestMessage_MarshalJSON_ToolCalls(t *testing.T) {
+	msg := Message{
+		Role: "assistant",
+		ToolCalls: []ToolCall{
+			{ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+		},
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	var raw map[string]interface{}
+	json.Unmarshal(data, &raw)
+	if v, ok := raw["content"]; !ok || v != nil {
+		t.Errorf("content = %v, want null for tool-call-only message", v)
+	}
+	calls, ok := raw["tool_calls"].([]interface{})
+	if !ok || len(calls) != 1 {
+		t.Fatalf("tool_calls = %v, want 1 call", raw["tool_calls"])
+	}
+
+	var decoded Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded.ToolCalls, msg.ToolCalls) {
+		t.Errorf("ToolCalls mismatch:\ngot  %+v\nwant %+v", decoded.ToolCalls, msg.ToolCalls)
+	}
+}
+
+func TestMessage_MarshalJSON_ToolResult(t *testing.T) {
+	msg := Message{Role: "tool", ToolCallID: "call_1", Content: `{"temp":21}`}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	var raw map[string]interface{}
+	json.Unmarshal(data, &raw)
+	if raw["tool_call_id"] != "call_1" {
+		t.Errorf("tool_call_id = %v, want call_1", raw["tool_call_id"])
+	}
+	if raw["content"] != `{"temp":21}` {
+		t.Errorf("content = %v", raw["content"])
+	}
+	if _, ok := raw["tool_calls"]; ok {
+		t.Error("tool_calls should be omitted when empty")
+	}
+}
+
+func TestToolChoice_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		choice ToolChoice
+		want   string
+	}{
+		{name: "auto", choice: ToolChoice{Mode: ToolChoiceAuto}, want: `"auto"`},
+		{name: "required", choice: ToolChoice{Mode: ToolChoiceRequired}, want: `"required"`},
+		{name: "function", choice: ToolChoice{FunctionName: "get_weather"}, want: `{"type":"function","function":{"name":"get_weather"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.choice)
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("MarshalJSON() = %s, want %s", data, tt.want)
+			}
+			var decoded ToolChoice
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("UnmarshalJSON() error = %v", err)
+			}
+			if decoded != tt.choice {
+				t.Errorf("round trip = %+v, want %+v", decoded, tt.choice)
+			}
+		})
+	}
+}
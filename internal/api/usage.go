@@ -0,0 +1,66 @@
+package api
+
+// UsageOptions controls usage accounting in the response.
+type UsageOptions struct {
+	Include bool `json:"include"`
+}
+
+// PromptTokensDetails breaks down prompt token usage.
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// CompletionTokensDetails breaks down completion token usage.
+type CompletionTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+}
+
+// Usage reports token counts and cost for a completion.
+// Cost is in credits (USD).
+type Usage struct {
+	PromptTokens            int                      `json:"prompt_tokens"`
+	CompletionTokens        int                      `json:"completion_tokens"`
+	TotalTokens             int                      `json:"total_tokens"`
+	Cost                    float64                  `json:"cost,omitempty"`
+	PromptTokensDetails     *PromptTokensDetails     `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *CompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+// CachedTokens returns the number of prompt tokens served from cache.
+func (u *Usage) CachedTokens() int {
+	if u == nil || u.PromptTokensDetails == nil {
+		return 0
+	}
+	return u.PromptTokensDetails.CachedTokens
+}
+
+// ReasoningTokens returns the number of completion tokens spent on reasoning.
+func (u *Usage) ReasoningTokens() int {
+	if u == nil || u.CompletionTokensDetails == nil {
+		return 0
+	}
+	return u.CompletionTokensDetails.ReasoningTokens
+}
+
+// Add accumulates another usage report into u.
+func (u *Usage) Add(other *Usage) {
+	if other == nil {
+		return
+	}
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.Cost += other.Cost
+	if cached := other.CachedTokens(); cached > 0 {
+		if u.PromptTokensDetails == nil {
+			u.PromptTokensDetails = &PromptTokensDetails{}
+		}
+		u.PromptTokensDetails.CachedTokens += cached
+	}
+	if reasoning := other.ReasoningTokens(); reasoning > 0 {
+		if u.CompletionTokensDetails == nil {
+			u.CompletionTokensDetails = &CompletionTokensDetails{}
+		}
+		u.CompletionTokensDetails.ReasoningTokens += reasoning
+	}
+}
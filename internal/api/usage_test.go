@@ -0,0 +1,42 @@
+package api
+
+import "testing"
+
+func TestUsage_Add(t *testing.T) {
+	var total Usage
+	total.Add(&Usage{
+		PromptTokens:        100,
+		CompletionTokens:    20,
+		TotalTokens:         120,
+		Cost:                0.001,
+		PromptTokensDetails: &PromptTokensDetails{CachedTokens: 40},
+	})
+	total.Add(&Usage{
+		PromptTokens:            50,
+		CompletionTokens:        30,
+		TotalTokens:             80,
+		Cost:                    0.002,
+		CompletionTokensDetails: &CompletionTokensDetails{ReasoningTokens: 10},
+	})
+	total.Add(nil)
+
+	if total.PromptTokens != 150 || total.CompletionTokens != 50 || total.TotalTokens != 200 {
+		t.Errorf("token totals = %+v", total)
+	}
+	if total.Cost < 0.00299 || total.Cost > 0.00301 {
+		t.Errorf("Cost = %v, want 0.003", total.Cost)
+	}
+	if total.CachedTokens() != 40 {
+		t.Errorf("CachedTokens() = %d, want 40", total.CachedTokens())
+	}
+	if total.ReasoningTokens() != 10 {
+		t.Errorf("ReasoningTokens() = %d, want 10", total.ReasoningTokens())
+	}
+}
+
+func TestUsage_NilDetails(t *testing.T) {
+	var u *Usage
+	if u.CachedTokens() != 0 || u.ReasoningTokens() != 0 {
+		t.Error("nil usage should report zero details")
+	}
+}
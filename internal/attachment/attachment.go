@@ -0,0 +1,214 @@
+// Package attachment loads local files for sending to a model as message
+// content: images as image_url parts, PDFs as file parts and text files
+// inline as text.
+package attachment
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+// MaxSize is the largest file that can be attached.
+const MaxSize = 20 << 20 // 20 MiB
+
+// Kind is the kind of content an attachment holds.
+type Kind string
+
+// Attachment kinds.
+const (
+	KindImage Kind = "image"
+	KindPDF   Kind = "pdf"
+	KindText  Kind = "text"
+)
+
+// Attachment is a file loaded for sending with a message.
+type Attachment struct {
+	// Name is the file's base name, sent as the filename of PDFs and
+	// shown in the UI
+	Name     string
+	Kind     Kind
+	MIMEType string
+	Data     []byte
+}
+
+// ImageMIMEType returns the MIME type for a supported image file based on
+// its extension.
+func ImageMIMEType(path string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".png":
+		return "image/png", nil
+	case ".jpg", ".jpeg":
+		return "image/jpeg", nil
+	case ".webp":
+		return "image/webp", nil
+	case ".gif":
+		return "image/gif", nil
+	default:
+		return "", fmt.Errorf("unsupported image format %q; supported formats: png, jpg, jpeg, webp, gif", ext)
+	}
+}
+
+// Load reads a file and determines its kind from the extension. Files that
+// are neither images nor PDFs are attached as text if they are valid UTF-8.
+// A leading ~/ is expanded to the home directory.
+func Load(path string) (*Attachment, error) {
+	path, err := ExpandHome(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("cannot attach %s: is a directory", path)
+	}
+	if info.Size() > MaxSize {
+		return nil, fmt.Errorf("cannot attach %s: file is %s, the limit is %s",
+			path, FormatSize(info.Size()), FormatSize(MaxSize))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	return New(filepath.Base(path), data)
+}
+
+// New creates an attachment from data already in memory, determining its
+// kind from the name's extension like Load.
+func New(name string, data []byte) (*Attachment, error) {
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("cannot attach %s: content is %s, the limit is %s",
+			name, FormatSize(int64(len(data))), FormatSize(MaxSize))
+	}
+
+	a := &Attachment{Name: name, Data: data}
+	if mimeType, err := ImageMIMEType(name); err == nil {
+		a.Kind, a.MIMEType = KindImage, mimeType
+		return a, nil
+	}
+	if strings.EqualFold(filepath.Ext(name), ".pdf") {
+		a.Kind, a.MIMEType = KindPDF, "application/pdf"
+		return a, nil
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return nil, fmt.Errorf("cannot attach %s: not an image, PDF or text file", name)
+	}
+	a.Kind, a.MIMEType = KindText, "text/plain"
+	return a, nil
+}
+
+// ExpandHome replaces a leading ~/ with the user's home directory.
+func ExpandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to expand %s: %w", path, err)
+	}
+	return filepath.Join(home, rest), nil
+}
+
+// ContentPart returns the attachment as message content.
+// Text files are inlined in a fenced block labelled with the file name.
+func (a *Attachment) ContentPart() api.ContentPart {
+	switch a.Kind {
+	case KindImage:
+		return api.ContentPart{
+			Type:     "image_url",
+			ImageURL: &api.ImageURL{URL: api.EncodeDataURL(a.MIMEType, a.Data)},
+		}
+	case KindPDF:
+		return api.ContentPart{
+			Type: "file",
+			File: &api.FileContent{Filename: a.Name, FileData: api.EncodeDataURL(a.MIMEType, a.Data)},
+		}
+	default:
+		return api.ContentPart{
+			Type: "text",
+			Text: fmt.Sprintf("%s:\n```\n%s\n```", a.Name, strings.TrimRight(string(a.Data), "\n")),
+		}
+	}
+}
+
+// FromContentPart recovers an attachment from a content part built by
+// ContentPart. Images keep their data but not their file name.
+func FromContentPart(part api.ContentPart) (*Attachment, error) {
+	switch {
+	case part.Type == "image_url" && part.ImageURL != nil:
+		mimeType, data, err := api.DecodeDataURL(part.ImageURL.URL)
+		if err != nil {
+			return nil, err
+		}
+		return New("image."+strings.TrimPrefix(mimeType, "image/"), data)
+	case part.Type == "file" && part.File != nil:
+		_, data, err := api.DecodeDataURL(part.File.FileData)
+		if err != nil {
+			return nil, err
+		}
+		return New(part.File.Filename, data)
+	case part.Type == "text":
+		name, rest, ok := strings.Cut(part.Text, ":\n```\n")
+		body, closed := strings.CutSuffix(rest, "\n```")
+		if !ok || !closed || name == "" || strings.Contains(name, "\n") {
+			return nil, fmt.Errorf("content part is not an attached file")
+		}
+		return New(name, []byte(body+"\n"))
+	default:
+		return nil, fmt.Errorf("content part is not an attached file")
+	}
+}
+
+// Label describes the attachment for display, e.g. "[image: cat.png]".
+func (a *Attachment) Label() string {
+	return fmt.Sprintf("[%s: %s]", a.Kind, a.Name)
+}
+
+// Message builds a user message from text and attachments. Without
+// attachments the message is plain text.
+func Message(text string, attachments []*Attachment) api.Message {
+	msg := api.Message{Role: "user", Content: text}
+	if len(attachments) == 0 {
+		return msg
+	}
+	if text != "" {
+		msg.ContentParts = append(msg.ContentParts, api.ContentPart{Type: "text", Text: text})
+	}
+	for _, a := range attachments {
+		msg.ContentParts = append(msg.ContentParts, a.ContentPart())
+	}
+	return msg
+}
+
+// Check returns an error if the model can't accept the attachment.
+// Images need image input. PDFs are accepted by every model, since
+// OpenRouter extracts their text for models without native file input.
+func Check(model *api.Model, a *Attachment) error {
+	if a.Kind == KindImage && !model.SupportsImageInput() {
+		return fmt.Errorf("model '%s' does not support image input; remove %s or choose a model with image input modality", model.ID, a.Name)
+	}
+	return nil
+}
+
+// FormatSize formats a byte count for messages, e.g. "1.5 MiB".
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
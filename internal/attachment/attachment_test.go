@@ -0,0 +1,173 @@
+package attachment
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+func TestImageMIMEType(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "png", path: "photo.png", want: "image/png"},
+		{name: "jpg", path: "photo.jpg", want: "image/jpeg"},
+		{name: "jpeg", path: "photo.jpeg", want: "image/jpeg"},
+		{name: "webp", path: "photo.webp", want: "image/webp"},
+		{name: "gif", path: "photo.gif", want: "image/gif"},
+		{name: "uppercase PNG", path: "photo.PNG", want: "image/png"},
+		{name: "bmp unsupported", path: "photo.bmp", wantErr: true},
+		{name: "svg unsupported", path: "photo.svg", wantErr: true},
+		{name: "txt unsupported", path: "notes.txt", wantErr: true},
+		{name: "no extension", path: "photo", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ImageMIMEType(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ImageMIMEType(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ImageMIMEType(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		wantKind Kind
+		wantMIME string
+		wantErr  string
+	}{
+		{name: "image", path: write("cat.PNG", []byte("\x89PNG\r\n")), wantKind: KindImage, wantMIME: "image/png"},
+		{name: "pdf", path: write("report.pdf", []byte("%PDF-1.7")), wantKind: KindPDF, wantMIME: "application/pdf"},
+		{name: "text", path: write("main.go", []byte("package main\n")), wantKind: KindText, wantMIME: "text/plain"},
+		{name: "binary", path: write("blob.bin", []byte{0x00, 0x01, 0x02}), wantErr: "not an image, PDF or text file"},
+		{name: "directory", path: dir, wantErr: "is a directory"},
+		{name: "missing", path: filepath.Join(dir, "nope.txt"), wantErr: "failed to read attachment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := Load(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if a.Kind != tt.wantKind || a.MIMEType != tt.wantMIME {
+				t.Errorf("Load() = %s %s, want %s %s", a.Kind, a.MIMEType, tt.wantKind, tt.wantMIME)
+			}
+			if a.Name != filepath.Base(tt.path) {
+				t.Errorf("Name = %q, want %q", a.Name, filepath.Base(tt.path))
+			}
+		})
+	}
+}
+
+func TestNew_SizeLimit(t *testing.T) {
+	_, err := New("big.txt", make([]byte, MaxSize+1))
+	if err == nil || !strings.Contains(err.Error(), "the limit is 20.0 MiB") {
+		t.Errorf("New() error = %v, want size limit error", err)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	if msg := Message("hi", nil); msg.Content != "hi" || msg.ContentParts != nil {
+		t.Errorf("Message without attachments = %+v, want plain text", msg)
+	}
+
+	image, _ := New("cat.png", []byte("png"))
+	pdf, _ := New("report.pdf", []byte("%PDF"))
+	text, _ := New("notes.md", []byte("# Notes\n"))
+	msg := Message("Summarize", []*Attachment{image, pdf, text})
+
+	if msg.Content != "Summarize" || len(msg.ContentParts) != 4 {
+		t.Fatalf("Message() = %+v, want text plus 3 parts", msg)
+	}
+	if got := msg.ContentParts[1].ImageURL.URL; got != api.EncodeDataURL("image/png", []byte("png")) {
+		t.Errorf("image part URL = %q", got)
+	}
+	if file := msg.ContentParts[2].File; file == nil || file.Filename != "report.pdf" {
+		t.Errorf("pdf part = %+v, want file part named report.pdf", msg.ContentParts[2])
+	}
+	if got := msg.ContentParts[3].Text; got != "notes.md:\n```\n# Notes\n```" {
+		t.Errorf("text part = %q", got)
+	}
+}
+
+func TestFromContentPart(t *testing.T) {
+	image, _ := New("cat.png", []byte("png"))
+	pdf, _ := New("report.pdf", []byte("%PDF"))
+	text, _ := New("notes.md", []byte("# Notes\n"))
+
+	tests := []struct {
+		name     string
+		part     api.ContentPart
+		wantName string
+		wantData string
+		wantErr  bool
+	}{
+		{name: "image", part: image.ContentPart(), wantName: "image.png", wantData: "png"},
+		{name: "pdf", part: pdf.ContentPart(), wantName: "report.pdf", wantData: "%PDF"},
+		{name: "text", part: text.ContentPart(), wantName: "notes.md", wantData: "# Notes\n"},
+		{name: "plain text", part: api.ContentPart{Type: "text", Text: "Summarize"}, wantErr: true},
+		{name: "placeholder", part: api.ContentPart{Type: "text", Text: "[image cat.png unavailable]"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := FromContentPart(tt.part)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromContentPart() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if a.Name != tt.wantName || string(a.Data) != tt.wantData {
+				t.Errorf("FromContentPart() = %s %q, want %s %q", a.Name, a.Data, tt.wantName, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	textOnly := &api.Model{ID: "text/model", Architecture: api.ModelArchitecture{InputModalities: []string{"text"}}}
+	vision := &api.Model{ID: "vision/model", Architecture: api.ModelArchitecture{InputModalities: []string{"text", "image"}}}
+	image, _ := New("cat.png", []byte("png"))
+	pdf, _ := New("report.pdf", []byte("%PDF"))
+
+	if err := Check(textOnly, image); err == nil {
+		t.Error("Check() should reject images for a text-only model")
+	}
+	if err := Check(vision, image); err != nil {
+		t.Errorf("Check() error = %v for a vision model", err)
+	}
+	if err := Check(textOnly, pdf); err != nil {
+		t.Errorf("Check() error = %v, PDFs should be accepted by any model", err)
+	}
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// ErrBlobNotFound is returned when a referenced attachment blob is missing.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// GetBlobDir returns the directory where session attachments are stored.
+// Blobs are content-addressed: each file is named by the SHA-256 of its
+// content, so an attachment shared by several messages is stored once.
+func GetBlobDir() (string, error) {
+	sessionDir, err := GetSessionDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(sessionDir, "blobs"), nil
+}
+
+// blobPath returns the path of a blob, sharded by the first two hex digits
+// of its hash. The hash is validated so it can't escape the blob directory.
+func blobPath(hash string) (string, error) {
+	if len(hash) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid blob hash %q", hash)
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", fmt.Errorf("invalid blob hash %q", hash)
+	}
+	blobDir, err := GetBlobDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(blobDir, hash[:2], hash), nil
+}
+
+// WriteBlob stores data and returns its hash. Data already stored is not
+// written again.
+func WriteBlob(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	path, err := blobPath(hash)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil {
+		// Refresh the time so PruneBlobs sees the blob as newly used
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return hash, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	// Write to a temporary file first so a partial blob is never visible
+	// under its hash
+	tmp, err := os.CreateTemp(filepath.Dir(path), hash+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create blob file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to write blob: %w", err)
+	}
+	return hash, nil
+}
+
+// ReadBlob returns the content of a stored blob.
+func ReadBlob(hash string) ([]byte, error) {
+	path, err := blobPath(hash)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, hash)
+		}
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	return data, nil
+}
+
+// blobGracePeriod is how long a new blob is kept without a reference, as a
+// running chat stores attachments just before saving the message using them.
+const blobGracePeriod = time.Hour
+
+// PruneBlobs removes blobs that no session references and returns how many
+// were removed. Nothing is removed if any session file can't be read, since
+// its references would be unknown.
+func PruneBlobs() (int, error) {
+	referenced, err := referencedBlobs()
+	if err != nil {
+		return 0, err
+	}
+	blobDir, err := GetBlobDir()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	err = filepath.WalkDir(blobDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		hash := entry.Name()
+		// Skip directories and files in progress, which are not named by hash
+		if entry.IsDir() || len(hash) != sha256.Size*2 || referenced[hash] {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if time.Since(info.ModTime()) < blobGracePeriod {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("failed to prune blobs: %w", err)
+	}
+	return removed, nil
+}
+
+// referencedBlobs returns the hashes of the blobs used by any message, on
+// any branch, of any session.
+func referencedBlobs() (map[string]bool, error) {
+	ids, err := DefaultStore.IDs()
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[string]bool)
+	for _, id := range ids {
+		session, err := LoadSession(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check attachments of session %s: %w", id, err)
+		}
+		for _, node := range session.Nodes {
+			for _, part := range slices.Concat(node.Parts, node.Images) {
+				if part.Blob != "" {
+					referenced[part.Blob] = true
+				}
+			}
+		}
+	}
+	return referenced, nil
+}
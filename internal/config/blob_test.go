@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteBlob(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	data := []byte("attachment")
+	hash, err := WriteBlob(data)
+	if err != nil {
+		t.Fatalf("WriteBlob() error = %v", err)
+	}
+
+	// Same content is stored once under the same hash
+	again, err := WriteBlob(data)
+	if err != nil || again != hash {
+		t.Errorf("WriteBlob() again = %q, %v, want %q", again, err, hash)
+	}
+	blobDir, _ := GetBlobDir()
+	entries, _ := os.ReadDir(filepath.Join(blobDir, hash[:2]))
+	if len(entries) != 1 {
+		t.Errorf("blob shard has %d files, want 1", len(entries))
+	}
+
+	got, err := ReadBlob(hash)
+	if err != nil {
+		t.Fatalf("ReadBlob() error = %v", err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("ReadBlob() = %q, want %q", got, data)
+	}
+}
+
+func TestReadBlob_Errors(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	missing := "0000000000000000000000000000000000000000000000000000000000000000"
+	if _, err := ReadBlob(missing); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("ReadBlob(missing) error = %v, want ErrBlobNotFound", err)
+	}
+
+	for _, hash := range []string{"", "../../config.json", "zz" + missing[2:]} {
+		if _, err := ReadBlob(hash); err == nil || errors.Is(err, ErrBlobNotFound) {
+			t.Errorf("ReadBlob(%q) error = %v, want invalid hash error", hash, err)
+		}
+	}
+}
+
+func TestPruneBlobs(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	used, _ := WriteBlob([]byte("used"))
+	unused, _ := WriteBlob([]byte("unused"))
+	recent, _ := WriteBlob([]byte("recent"))
+
+	s := NewSession()
+	// Reference the blob from a branch other than the active one
+	s.SetMessages([]SessionMessage{
+		{Role: "user", Content: "look", Parts: []SessionPart{{Type: SessionPartImage, Blob: used}}},
+	})
+	s.TruncateMessages(0)
+
+	old := time.Now().Add(-2 * blobGracePeriod)
+	for _, hash := range []string{used, unused} {
+		path, _ := blobPath(hash)
+		os.Chtimes(path, old, old)
+	}
+
+	removed, err := PruneBlobs()
+	if err != nil {
+		t.Fatalf("PruneBlobs() error = %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("PruneBlobs() removed %d, want 1", removed)
+	}
+	for hash, wantKept := range map[string]bool{used: true, recent: true, unused: false} {
+		if _, err := ReadBlob(hash); (err == nil) != wantKept {
+			t.Errorf("ReadBlob(%s) error = %v, want kept %v", hash[:8], err, wantKept)
+		}
+	}
+
+	// An unreadable session stops pruning, as its references are unknown
+	sessionDir, _ := GetSessionDir()
+	os.WriteFile(filepath.Join(sessionDir, "broken.json"), []byte("{"), 0600)
+	if _, err := PruneBlobs(); err == nil {
+		t.Error("PruneBlobs() with an unreadable session should fail")
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
 )
 
 // Default configuration values.
@@ -19,13 +21,13 @@ const (
 	// DefaultImageModel is the default model for image generation.
 	DefaultImageModel = "google/gemini-2.5-flash-image"
 
+	// DefaultCompactModel is the default model that summarizes conversations
+	// for /compact.
+	DefaultCompactModel = "openai/gpt-4o-mini"
+
 	// DefaultStreamTimeout is the default timeout for streaming requests.
 	DefaultStreamTimeout = 5 * time.Minute
 
-	// StreamChunkTimeout is the timeout for waiting for a single chunk.
-	// If no data is received within this time, the stream is considered hung.
-	StreamChunkTimeout = 30 * time.Second
-
 	// DefaultTerminalWidth is the default terminal width when auto-detection fails.
 	DefaultTerminalWidth = 80
 
@@ -40,10 +42,14 @@ const (
 )
 
 // Config holds the application configuration that is persisted to disk.
+// Provider holds default provider routing preferences; command-line flags
+// override individual fields.
 type Config struct {
-	APIKey            string `json:"api_key"`
-	DefaultModel      string `json:"default_model,omitempty"`
-	DefaultImageModel string `json:"default_image_model,omitempty"`
+	APIKey            string                   `json:"api_key"`
+	DefaultModel      string                   `json:"default_model,omitempty"`
+	DefaultImageModel string                   `json:"default_image_model,omitempty"`
+	CompactModel      string                   `json:"compact_model,omitempty"`
+	Provider          *api.ProviderPreferences `json:"provider,omitempty"`
 }
 
 // AppConfig holds all runtime configuration.
@@ -118,6 +124,12 @@ func Load() (*Config, error) {
 	if cfg.DefaultImageModel == "" {
 		cfg.DefaultImageModel = DefaultImageModel
 	}
+	if cfg.CompactModel == "" {
+		cfg.CompactModel = DefaultCompactModel
+	}
+	if err := cfg.Provider.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid provider settings in config file: %w", err)
+	}
 
 	return &cfg, nil
 }
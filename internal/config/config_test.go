@@ -178,3 +178,42 @@ func TestConstants(t *testing.T) {
 		t.Errorf("DefaultTerminalWidth = %d, want positive value", DefaultTerminalWidth)
 	}
 }
+
+func TestLoadProvider(t *testing.T) {
+	configDir := t.TempDir()
+	originalGetConfigDir := GetConfigDir
+	GetConfigDir = func() (string, error) {
+		return configDir, nil
+	}
+	defer func() { GetConfigDir = originalGetConfigDir }()
+	configPath := filepath.Join(configDir, "config.json")
+
+	t.Run("loads provider preferences", func(t *testing.T) {
+		data := `{"api_key":"k","provider":{"order":["groq"],"allow_fallbacks":false,"data_collection":"deny"}}`
+		if err := os.WriteFile(configPath, []byte(data), 0600); err != nil {
+			t.Fatalf("failed to write test config: %v", err)
+		}
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.Provider == nil || len(cfg.Provider.Order) != 1 || cfg.Provider.Order[0] != "groq" {
+			t.Fatalf("cfg.Provider = %+v, want order [groq]", cfg.Provider)
+		}
+		if cfg.Provider.AllowFallbacks == nil || *cfg.Provider.AllowFallbacks {
+			t.Error("cfg.Provider.AllowFallbacks should be false")
+		}
+	})
+
+	t.Run("rejects invalid provider preferences", func(t *testing.T) {
+		data := `{"api_key":"k","provider":{"sort":"fastest"}}`
+		if err := os.WriteFile(configPath, []byte(data), 0600); err != nil {
+			t.Fatalf("failed to write test config: %v", err)
+		}
+
+		if _, err := Load(); err == nil {
+			t.Error("Load() error = nil, want invalid provider error")
+		}
+	})
+}
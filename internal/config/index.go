@@ -0,0 +1,372 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// indexVersion is the current index format version. An index with another
+// version is rebuilt.
+const indexVersion = 2
+
+// Index files in the index subdirectory of the sessions directory. Each
+// index has a log next to it, named with indexLogExt, of the updates made by
+// writes since the index file was written. The log is folded into the index
+// file the next time the index is used.
+const (
+	summaryIndexName = "summaries.json"
+	searchIndexName  = "search.json"
+	indexLogExt      = ".log"
+)
+
+// fileStamp identifies the version of a session's files an index entry was
+// built from, or a session was loaded from. It combines the session file and
+// its journal.
+type fileStamp struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+}
+
+func newFileStamp(info os.FileInfo) fileStamp {
+	return fileStamp{ModTime: info.ModTime(), Size: info.Size()}
+}
+
+func (f fileStamp) stamp() fileStamp { return f }
+
+// with adds another file to the stamp.
+func (f fileStamp) with(info os.FileInfo) fileStamp {
+	if info.ModTime().After(f.ModTime) {
+		f.ModTime = info.ModTime()
+	}
+	f.Size += info.Size()
+	return f
+}
+
+func (f fileStamp) equal(g fileStamp) bool {
+	return f.ModTime.Equal(g.ModTime) && f.Size == g.Size
+}
+
+// indexEntry is an index's entry for one session.
+type indexEntry interface {
+	stamp() fileStamp
+}
+
+// sessionIndex is an index file holding an entry per session ID.
+type sessionIndex[E indexEntry] struct {
+	Version  int          `json:"version"`
+	Sessions map[string]E `json:"sessions"`
+	// Failed holds the stamps of session files that couldn't be loaded,
+	// which are skipped until they change
+	Failed map[string]fileStamp `json:"failed,omitempty"`
+
+	logged bool // Records from the log were applied
+}
+
+// indexRecord is a line of an index's log. It holds a session's new entry
+// after its file was written, marks it removed, or updates its entry after
+// a change was appended to its journal.
+type indexRecord[E indexEntry] struct {
+	ID      string       `json:"id"`
+	Entry   E            `json:"entry,omitempty"`
+	Removed bool         `json:"removed,omitempty"`
+	Update  *indexUpdate `json:"update,omitempty"`
+}
+
+// indexUpdate updates the entry of a session whose journal grew. It only
+// applies to an entry built from the files as they were before, From, so
+// an entry that missed an update stays outdated and is rebuilt.
+type indexUpdate struct {
+	From    fileStamp       `json:"from"`
+	To      fileStamp       `json:"to"`
+	Summary SessionSummary  `json:"summary"`
+	Message *indexedMessage `json:"message,omitempty"` // Added to the active branch
+}
+
+// updater is implemented by index entries that can take an indexUpdate.
+type updater interface {
+	update(u indexUpdate)
+}
+
+// indexedSummary is the summary index's entry for a session.
+type indexedSummary struct {
+	fileStamp
+	Summary SessionSummary `json:"summary"`
+}
+
+func newIndexedSummary(s *Session) *indexedSummary {
+	return &indexedSummary{fileStamp: s.stamp, Summary: s.Summary()}
+}
+
+func (e *indexedSummary) update(u indexUpdate) {
+	if e.fileStamp.equal(u.From) {
+		e.fileStamp, e.Summary = u.To, u.Summary
+	}
+}
+
+// indexedSession is the search index's entry for a session, holding the
+// text of its active branch.
+type indexedSession struct {
+	fileStamp
+	Summary  SessionSummary   `json:"summary"`
+	Messages []indexedMessage `json:"messages"`
+}
+
+type indexedMessage struct {
+	ID    int       `json:"id"`
+	Role  string    `json:"role"`
+	Model string    `json:"model,omitempty"`
+	Time  time.Time `json:"time,omitzero"`
+	Text  string    `json:"text"`
+}
+
+func newIndexedSession(s *Session) *indexedSession {
+	entry := &indexedSession{fileStamp: s.stamp, Summary: s.Summary()}
+	for _, msg := range s.Messages {
+		entry.Messages = append(entry.Messages, newIndexedMessage(msg))
+	}
+	return entry
+}
+
+func newIndexedMessage(msg SessionMessage) indexedMessage {
+	return indexedMessage{
+		ID:    msg.ID,
+		Role:  msg.Role,
+		Model: msg.Model,
+		Time:  msg.Time,
+		Text:  msg.Content,
+	}
+}
+
+func (e *indexedSession) update(u indexUpdate) {
+	if !e.fileStamp.equal(u.From) {
+		return
+	}
+	e.fileStamp, e.Summary = u.To, u.Summary
+	if u.Message != nil {
+		e.Messages = append(e.Messages, *u.Message)
+	}
+}
+
+// indexPath returns the path of an index file.
+func (st *JSONStore) indexPath(name string) (string, error) {
+	dir, err := st.dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "index", name), nil
+}
+
+// indexLogPath returns the path of an index's log.
+func (st *JSONStore) indexLogPath(name string) (string, error) {
+	return st.indexPath(strings.TrimSuffix(name, filepath.Ext(name)) + indexLogExt)
+}
+
+// loadIndex reads an index and applies its log. A missing, unreadable or
+// outdated index is started empty, to be rebuilt.
+func loadIndex[E indexEntry](st *JSONStore, name string) (*sessionIndex[E], error) {
+	path, err := st.indexPath(name)
+	if err != nil {
+		return nil, err
+	}
+	idx := &sessionIndex[E]{Version: indexVersion, Sessions: map[string]E{}}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	if err == nil {
+		var saved sessionIndex[E]
+		if json.Unmarshal(data, &saved) == nil && saved.Version == indexVersion && saved.Sessions != nil {
+			idx = &saved
+		}
+	}
+	if err := idx.replay(st, name); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// replay applies the records in the index's log. Lines that can't be
+// parsed, such as one cut short by a crash, are skipped.
+func (idx *sessionIndex[E]) replay(st *JSONStore, name string) error {
+	path, err := st.indexLogPath(name)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read index log: %w", err)
+	}
+	for line := range bytes.Lines(data) {
+		var rec indexRecord[E]
+		if json.Unmarshal(line, &rec) != nil || rec.ID == "" {
+			continue
+		}
+		idx.logged = true
+		switch {
+		case rec.Removed:
+			delete(idx.Sessions, rec.ID)
+			delete(idx.Failed, rec.ID)
+		case rec.Update != nil:
+			if entry, ok := any(idx.Sessions[rec.ID]).(updater); ok {
+				entry.update(*rec.Update)
+			}
+		default:
+			idx.Sessions[rec.ID] = rec.Entry
+			delete(idx.Failed, rec.ID)
+		}
+	}
+	return nil
+}
+
+// logIndex appends a record to an index's log. Errors are ignored: the
+// indexes are caches, and a missed update is caught the next time they are
+// used.
+func logIndex[E indexEntry](st *JSONStore, name string, rec indexRecord[E]) {
+	path, err := st.indexLogPath(name)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(path), 0700) != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
+
+// indexWritten logs a session's new index entries after its file was
+// written.
+func (st *JSONStore) indexWritten(s *Session) {
+	logIndex(st, summaryIndexName, indexRecord[*indexedSummary]{ID: s.ID, Entry: newIndexedSummary(s)})
+	logIndex(st, searchIndexName, indexRecord[*indexedSession]{ID: s.ID, Entry: newIndexedSession(s)})
+}
+
+// indexAppended logs the update to a session's index entries after a
+// change was appended to its journal, from the files stamped from.
+func (st *JSONStore) indexAppended(s *Session, from fileStamp, msg *SessionMessage) {
+	update := indexUpdate{From: from, To: s.stamp, Summary: s.Summary()}
+	logIndex(st, summaryIndexName, indexRecord[*indexedSummary]{ID: s.ID, Update: &update})
+	if msg != nil {
+		m := newIndexedMessage(*msg)
+		update.Message = &m
+	}
+	logIndex(st, searchIndexName, indexRecord[*indexedSession]{ID: s.ID, Update: &update})
+}
+
+// indexRemoved logs the removal of a deleted session from the indexes.
+func (st *JSONStore) indexRemoved(id string) {
+	logIndex(st, summaryIndexName, indexRecord[*indexedSummary]{ID: id, Removed: true})
+	logIndex(st, searchIndexName, indexRecord[*indexedSession]{ID: id, Removed: true})
+}
+
+// save writes the index through a temporary file, so concurrent readers
+// never see a partial index.
+func (idx *sessionIndex[E]) save(st *JSONStore, name string) error {
+	path, err := st.indexPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// refresh brings the index up to date with the session files, rebuilding
+// the entries of files that changed since they were indexed and dropping
+// those of deleted files. Files that fail to load are recorded in Failed. It
+// reports whether anything changed.
+func (idx *sessionIndex[E]) refresh(st *JSONStore, build func(*Session) E) (bool, error) {
+	files, err := st.files()
+	if err != nil {
+		return false, err
+	}
+	changed := false
+	for id, stamp := range files {
+		if entry, ok := idx.Sessions[id]; ok && entry.stamp().equal(stamp) {
+			continue
+		}
+		if failed, ok := idx.Failed[id]; ok && failed.equal(stamp) {
+			continue
+		}
+		changed = true
+		session, err := st.Load(id)
+		if err != nil {
+			// Leave out corrupted files until they change
+			delete(idx.Sessions, id)
+			if idx.Failed == nil {
+				idx.Failed = map[string]fileStamp{}
+			}
+			idx.Failed[id] = stamp
+			continue
+		}
+		idx.Sessions[id] = build(session)
+		delete(idx.Failed, id)
+	}
+	for id := range idx.Sessions {
+		if _, ok := files[id]; !ok {
+			delete(idx.Sessions, id)
+			changed = true
+		}
+	}
+	for id := range idx.Failed {
+		if _, ok := files[id]; !ok {
+			delete(idx.Failed, id)
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// currentIndex loads an index and brings it up to date, saving it and
+// clearing its log if anything changed. Records logged meanwhile are lost
+// with the log, but the entries they'd update are outdated by their stamps
+// and rebuilt.
+func currentIndex[E indexEntry](st *JSONStore, name string, build func(*Session) E) (*sessionIndex[E], error) {
+	idx, err := loadIndex[E](st, name)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := idx.refresh(st, build)
+	if err != nil {
+		return nil, err
+	}
+	// The index is a cache; it is usable without saving it
+	if (changed || idx.logged) && idx.save(st, name) == nil {
+		if path, err := st.indexLogPath(name); err == nil {
+			os.Remove(path)
+		}
+	}
+	return idx, nil
+}
+
+func (st *JSONStore) currentSummaryIndex() (*sessionIndex[*indexedSummary], error) {
+	return currentIndex(st, summaryIndexName, newIndexedSummary)
+}
+
+func (st *JSONStore) currentSearchIndex() (*sessionIndex[*indexedSession], error) {
+	return currentIndex(st, searchIndexName, newIndexedSession)
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// journalRecord is a line of a session's journal, recording one change made
+// after the session file was last written. Seq numbers the changes; the
+// session file's Seq is the last one it includes.
+type journalRecord struct {
+	Seq       int             `json:"seq"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Header    *sessionHeader  `json:"header,omitempty"` // Set when the header changed
+	Message   *SessionMessage `json:"message,omitempty"`
+	History   *string         `json:"history,omitempty"`
+}
+
+// sessionHeader holds the fields of a session that change without a
+// message being added, such as its model after a /model command.
+type sessionHeader struct {
+	Model           string   `json:"model,omitempty"`
+	Models          []string `json:"models,omitempty"`
+	SystemPrompt    string   `json:"system_prompt,omitempty"`
+	Title           string   `json:"title,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	Source          string   `json:"source,omitempty"`
+	ContextStrategy string   `json:"context_strategy,omitempty"`
+}
+
+func (s *Session) header() sessionHeader {
+	return sessionHeader{
+		Model:           s.Model,
+		Models:          s.Models,
+		SystemPrompt:    s.SystemPrompt,
+		Title:           s.Title,
+		Tags:            s.Tags,
+		Source:          s.Source,
+		ContextStrategy: s.ContextStrategy,
+	}
+}
+
+// headerJSON returns the header in the form compared to find changes.
+func (s *Session) headerJSON() string {
+	data, _ := json.Marshal(s.header())
+	return string(data)
+}
+
+// apply makes the change a journal record describes.
+func (s *Session) apply(rec journalRecord) {
+	if h := rec.Header; h != nil {
+		s.Model, s.Models, s.SystemPrompt = h.Model, h.Models, h.SystemPrompt
+		s.Title, s.Tags, s.Source = h.Title, h.Tags, h.Source
+		s.ContextStrategy = h.ContextStrategy
+	}
+	if rec.Message != nil {
+		s.Nodes = append(s.Nodes, *rec.Message)
+		s.Head = rec.Message.ID
+	}
+	if rec.History != nil {
+		s.History = append(s.History, *rec.History)
+	}
+	s.Seq = rec.Seq
+	s.UpdatedAt = rec.UpdatedAt
+}
+
+// appendJournal records a change just made to the end of the session in its
+// journal. The whole session is written instead if it has no file yet, if
+// an earlier change failed to be recorded, or once the journal has grown
+// larger than the file, which keeps the journal short.
+func (st *JSONStore) appendJournal(s *Session, rec journalRecord) error {
+	err := st.withLock(s.ID, func() error {
+		if err := st.checkUnchanged(s); err != nil {
+			return err
+		}
+		sessionPath, err := st.path(s.ID)
+		if err != nil {
+			return err
+		}
+		journalPath, err := st.file(s.ID, journalExt)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(sessionPath)
+		journal, journalErr := os.Stat(journalPath)
+		if err != nil || s.needsSave || (journalErr == nil && journal.Size() > info.Size()) {
+			return st.save(s)
+		}
+
+		header := s.headerJSON()
+		if header != s.savedHeader {
+			h := s.header()
+			rec.Header = &h
+		}
+		rec.Seq = s.Seq + 1
+		rec.UpdatedAt = s.UpdatedAt
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("failed to marshal session change: %w", err)
+		}
+		if err := appendLine(journalPath, line); err != nil {
+			return fmt.Errorf("failed to write session journal: %w", err)
+		}
+		s.Seq = rec.Seq
+		s.savedHeader = header
+		from := s.stamp
+		if st.restamp(s) {
+			st.indexAppended(s, from, rec.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		// Whatever wasn't recorded goes into the next write
+		s.needsSave = true
+	}
+	return err
+}
+
+// replayJournal applies the changes in the session's journal that its file
+// doesn't include yet. Records must follow on from the session's Seq; lines
+// that can't be parsed, such as one cut short by a crash, are skipped.
+func (st *JSONStore) replayJournal(s *Session) error {
+	journalPath, err := st.file(s.ID, journalExt)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(journalPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read session journal: %w", err)
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var rec journalRecord
+		if json.Unmarshal(line, &rec) != nil || rec.Seq != s.Seq+1 {
+			continue
+		}
+		s.apply(rec)
+	}
+	return nil
+}
+
+// appendLine appends a line to the file at path and syncs it. If the file
+// ends in a partial line, left by a crash, the line starts on a new one.
+func appendLine(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			line = append([]byte{'\n'}, line...)
+		}
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSessionJournal(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	s := NewSession()
+	if err := s.AppendMessage("user", "Hello"); err != nil {
+		t.Fatal(err)
+	}
+	sessionPath, _ := (&JSONStore{}).path(s.ID)
+	journalPath, _ := (&JSONStore{}).file(s.ID, journalExt)
+	written, err := os.ReadFile(sessionPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Appends go to the journal, leaving the session file alone
+	if err := s.AppendHistory("Hi"); err != nil {
+		t.Fatal(err)
+	}
+	s.Model = "openai/gpt-4o"
+	if err := s.AppendMessage("assistant", "Hi there"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(sessionPath); string(data) != string(written) {
+		t.Error("append rewrote the session file")
+	}
+	loaded, err := LoadSession(s.ID)
+	if err != nil {
+		t.Fatalf("LoadSession() error = %v", err)
+	}
+	if len(loaded.Messages) != 2 || loaded.Messages[1].Content != "Hi there" || loaded.Model != "openai/gpt-4o" ||
+		len(loaded.History) != 1 || !loaded.UpdatedAt.Equal(s.UpdatedAt) {
+		t.Errorf("loaded session = %+v", loaded)
+	}
+
+	// A line cut short by a crash is skipped, and the next append starts a
+	// new line
+	f, err := os.OpenFile(journalPath, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"seq": 3, "message": {"id": 9, "con`)
+	f.Close()
+	if loaded, err = LoadSession(s.ID); err != nil || len(loaded.Messages) != 2 {
+		t.Fatalf("LoadSession() with a partial line = %v, %v", loaded, err)
+	}
+	if err := loaded.AppendMessage("user", "Still here?"); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err = LoadSession(s.ID); err != nil || len(loaded.Messages) != 3 || loaded.Messages[2].Content != "Still here?" {
+		t.Fatalf("LoadSession() after recovering = %v, %v", loaded, err)
+	}
+
+	// The journal is folded into the file once it outgrows it
+	for range 20 {
+		if err := loaded.AppendMessage("user", strings.Repeat("long ", 20)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sessionInfo, _ := os.Stat(sessionPath)
+	if journal, err := os.Stat(journalPath); err == nil && journal.Size() > sessionInfo.Size() {
+		t.Errorf("journal is %d bytes, longer than the %d byte session file", journal.Size(), sessionInfo.Size())
+	}
+	if reloaded, err := LoadSession(s.ID); err != nil || len(reloaded.Messages) != 23 {
+		t.Errorf("LoadSession() after compaction = %v, %v", reloaded, err)
+	}
+
+	// Saving includes the journal in the file and removes it
+	if err := loaded.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(journalPath); !os.IsNotExist(err) {
+		t.Errorf("journal still exists after save: %v", err)
+	}
+	if reloaded, err := LoadSession(s.ID); err != nil || len(reloaded.Messages) != 23 || reloaded.Seq != loaded.Seq {
+		t.Errorf("LoadSession() after save = %v, %v", reloaded, err)
+	}
+}
+
+func TestSessionJournalStaleRecords(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	s := NewSession()
+	if err := s.AppendMessage("user", "Hello"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AppendMessage("assistant", "Hi"); err != nil {
+		t.Fatal(err)
+	}
+	journalPath, _ := (&JSONStore{}).file(s.ID, journalExt)
+	journal, err := os.ReadFile(journalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash between writing the file and removing the journal leaves
+	// records the file already includes
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(journalPath, journal, 0600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSession(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Messages) != 2 {
+		t.Errorf("got %d messages, want 2 without the journal replayed twice", len(loaded.Messages))
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrSessionLocked is returned when writing a session that another process
+// has open.
+var ErrSessionLocked = errors.New("session is open in another process")
+
+// ErrSessionChanged is returned when writing a session whose file was
+// changed by another process since it was loaded. Saving would lose those
+// changes, so the session must be loaded again.
+var ErrSessionChanged = errors.New("session was changed by another process")
+
+// errLocked is returned by lockFile when another process holds the lock.
+var errLocked = errors.New("locked")
+
+// acquireLock takes the lock file at path without waiting and writes the
+// process ID to it. It fails with errLocked if another process holds it.
+func acquireLock(path string) (*os.File, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open lock file: %w", err)
+		}
+		if err := lockFile(f); err != nil {
+			f.Close()
+			if errors.Is(err, errLocked) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("failed to lock session: %w", err)
+		}
+		// The previous holder removes the file when it is done, so the lock
+		// may be on a file that is gone; then try again with the new one
+		locked, err1 := f.Stat()
+		current, err2 := os.Stat(path)
+		if err1 == nil && err2 == nil && os.SameFile(locked, current) {
+			f.Truncate(0)
+			f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+			return f, nil
+		}
+		unlockFile(f)
+		f.Close()
+	}
+}
+
+// releaseLock removes a lock file taken with acquireLock and releases it.
+func releaseLock(path string, f *os.File) {
+	// Removed while still locked, so nobody locks the file being removed
+	os.Remove(path)
+	unlockFile(f)
+	f.Close()
+}
+
+// lockHolder returns the process ID written to a lock file, or "" if it
+// can't be read.
+func lockHolder(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
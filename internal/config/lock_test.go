@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestSessionLock(t *testing.T) {
+	// Two stores on one directory lock like two processes
+	dir := t.TempDir()
+	chat, other := &JSONStore{Dir: dir}, &JSONStore{Dir: dir}
+
+	s := NewSession()
+	s.appendNode(SessionMessage{Role: "user", Content: "Hello"})
+	if err := chat.Lock(s.ID); err != nil {
+		t.Fatalf("Lock() of an unsaved session error = %v", err)
+	}
+	lockPath, _ := chat.file(s.ID, lockExt)
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("unsaved session has a lock file: %v", err)
+	}
+
+	// The lock is taken when the session is first saved, and kept
+	if err := chat.Save(s); err != nil {
+		t.Fatal(err)
+	}
+	copy, err := other.Load(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Lock(s.ID); !errors.Is(err, ErrSessionLocked) {
+		t.Errorf("Lock() of a session open elsewhere error = %v, want ErrSessionLocked", err)
+	}
+	if err := other.Save(copy); !errors.Is(err, ErrSessionLocked) {
+		t.Errorf("Save() of a session open elsewhere error = %v, want ErrSessionLocked", err)
+	}
+	if err := other.Delete(s.ID); !errors.Is(err, ErrSessionLocked) {
+		t.Errorf("Delete() of a session open elsewhere error = %v, want ErrSessionLocked", err)
+	}
+	if err := chat.AppendMessage(s, SessionMessage{ID: 2, Parent: 1, Role: "assistant"}); err != nil {
+		t.Errorf("AppendMessage() by the lock holder error = %v", err)
+	}
+
+	// Once unlocked, a copy loaded before the last change can't overwrite it
+	if err := chat.Unlock(s.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("lock file left after Unlock: %v", err)
+	}
+	if err := other.Save(copy); !errors.Is(err, ErrSessionChanged) {
+		t.Errorf("Save() of a stale copy error = %v, want ErrSessionChanged", err)
+	}
+	if copy, err = other.Load(s.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Save(copy); err != nil {
+		t.Errorf("Save() of a fresh copy error = %v", err)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("lock file left after Save: %v", err)
+	}
+}
+
+func TestSessionLockSameProcess(t *testing.T) {
+	store := newTestStore(t)
+	open := NewSession()
+	open.appendNode(SessionMessage{Role: "user", Content: "Hello"})
+	if err := store.Lock(open.ID); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Unlock(open.ID)
+	if err := store.Save(open); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second value for the session, such as one a picker loaded, can
+	// write it while it is open
+	copy, err := store.Load(open.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy.Title = "Renamed"
+	if err := store.Save(copy); err != nil {
+		t.Fatalf("Save() of a second value error = %v", err)
+	}
+
+	// The open session keeps writing, and its writes win
+	for _, content := range []string{"Hi", "How are you?"} {
+		open.appendNode(SessionMessage{Role: "assistant", Content: content})
+		if err := store.AppendMessage(open, open.Nodes[len(open.Nodes)-1]); err != nil {
+			t.Fatalf("AppendMessage(%q) after a second value's save error = %v", content, err)
+		}
+	}
+	got, err := store.Load(open.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Messages) != 3 || got.Title != open.Title {
+		t.Errorf("Load() = %d messages, title %q; want 3, %q", len(got.Messages), got.Title, open.Title)
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+// ErrPresetNotFound is returned when a preset cannot be found.
+var ErrPresetNotFound = errors.New("preset not found")
+
+// validPresetName matches preset names, which double as file names.
+var validPresetName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// Preset is a named, reusable chat setup stored as presets/<name>.json in
+// the config directory. Model may be a comma-separated fallback chain.
+// Fields left unset fall back to the command-line flags and config defaults.
+type Preset struct {
+	Name         string                `json:"-"`
+	SystemPrompt string                `json:"system_prompt,omitempty"`
+	Model        string                `json:"model,omitempty"`
+	Params       api.SamplingParams    `json:"params"`
+	Reasoning    *api.ReasoningOptions `json:"reasoning,omitempty"`
+}
+
+// GetPresetDir returns the directory where presets are stored.
+func GetPresetDir() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "presets"), nil
+}
+
+// LoadPreset loads a preset by name.
+func LoadPreset(name string) (*Preset, error) {
+	if !validPresetName.MatchString(name) {
+		return nil, fmt.Errorf("invalid preset name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	presetDir, err := GetPresetDir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(presetDir, name+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrPresetNotFound, name)
+		}
+		return nil, fmt.Errorf("failed to read preset file: %w", err)
+	}
+
+	// Unknown fields are rejected so a misspelled setting is not silently ignored
+	var preset Preset
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&preset); err != nil {
+		return nil, fmt.Errorf("failed to parse preset %s: %w", name, err)
+	}
+	if err := preset.Params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid parameters in preset %s: %w", name, err)
+	}
+	if preset.Reasoning != nil {
+		if err := preset.Reasoning.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid reasoning settings in preset %s: %w", name, err)
+		}
+	}
+	preset.Name = name
+	return &preset, nil
+}
+
+// ListPresets returns the names of all presets, sorted.
+func ListPresets() ([]string, error) {
+	presetDir, err := GetPresetDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(presetDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read presets directory: %w", err)
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok || !validPresetName.MatchString(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
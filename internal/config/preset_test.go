@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadPreset(t *testing.T) {
+	configDir := t.TempDir()
+	originalGetConfigDir := GetConfigDir
+	GetConfigDir = func() (string, error) {
+		return configDir, nil
+	}
+	defer func() { GetConfigDir = originalGetConfigDir }()
+
+	presetDir := filepath.Join(configDir, "presets")
+	if err := os.MkdirAll(presetDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"reviewer.json": `{"system_prompt": "You review code.", "model": "a/one,b/two", "params": {"temperature": 0.2}}`,
+		"typo.json":     `{"system_promt": "oops"}`,
+		"hot.json":      `{"params": {"temperature": 5}}`,
+		"notes.txt":     `ignored`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(presetDir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("loads preset", func(t *testing.T) {
+		p, err := LoadPreset("reviewer")
+		if err != nil {
+			t.Fatalf("LoadPreset() error = %v", err)
+		}
+		if p.Name != "reviewer" || p.SystemPrompt != "You review code." || p.Model != "a/one,b/two" {
+			t.Errorf("preset = %+v", p)
+		}
+		if p.Params.Temperature == nil || *p.Params.Temperature != 0.2 {
+			t.Errorf("Temperature = %v, want 0.2", p.Params.Temperature)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		preset  string
+		wantErr error
+	}{
+		{name: "missing", preset: "nope", wantErr: ErrPresetNotFound},
+		{name: "unknown field", preset: "typo"},
+		{name: "invalid params", preset: "hot"},
+		{name: "path traversal", preset: "../config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadPreset(tt.preset)
+			if err == nil {
+				t.Fatal("LoadPreset() error = nil, want error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("LoadPreset() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("lists presets", func(t *testing.T) {
+		names, err := ListPresets()
+		if err != nil {
+			t.Fatalf("ListPresets() error = %v", err)
+		}
+		if want := []string{"hot", "reviewer", "typo"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("ListPresets() = %v, want %v", names, want)
+		}
+	})
+}
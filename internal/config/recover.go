@@ -0,0 +1,78 @@
+package config
+
+import "encoding/json"
+
+// repairDepth is how deep repairJSON cuts. Elements of the session's
+// top-level arrays, such as its messages, are kept whole or dropped.
+const repairDepth = 2
+
+// repairJSON makes the longest valid JSON document it can from the start of
+// a truncated one, such as a session file cut short by a crash while it was
+// written. It drops the value that was being written and closes the arrays
+// and objects still open. It reports false if nothing could be kept.
+func repairJSON(data []byte) ([]byte, bool) {
+	if json.Valid(data) {
+		return data, true
+	}
+
+	type cut struct {
+		at      int
+		closing []byte
+	}
+	var cuts []cut
+	var stack []byte // Closing brackets of the open arrays and objects
+	closing := func() []byte {
+		out := make([]byte, len(stack))
+		for i, c := range stack {
+			out[len(stack)-1-i] = c
+		}
+		return out
+	}
+
+	inString, escaped := false, false
+	for i, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			if c == '{' {
+				stack = append(stack, '}')
+			} else {
+				stack = append(stack, ']')
+			}
+			if len(stack) <= repairDepth {
+				// Cut here, the array or object is empty
+				cuts = append(cuts, cut{i + 1, closing()})
+			}
+		case '}', ']':
+			if len(stack) == 0 {
+				return nil, false
+			}
+			stack = stack[:len(stack)-1]
+		case ',':
+			if len(stack) <= repairDepth {
+				// Cut before the comma, after a whole element
+				cuts = append(cuts, cut{i, closing()})
+			}
+		}
+	}
+
+	for i := len(cuts) - 1; i >= 0; i-- {
+		repaired := append(append([]byte{}, data[:cuts[i].at]...), cuts[i].closing...)
+		if json.Valid(repaired) {
+			return repaired, true
+		}
+	}
+	return nil, false
+}
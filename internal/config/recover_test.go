@@ -0,0 +1,70 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{data: `{"a": 1, "b": [1, 2`, want: `{"a": 1, "b": [1]}`},
+		{data: `{"a": "x, y", "b": [{"c": 1}, {"c": 2, "d": "unfinished`, want: `{"a": "x, y", "b": [{"c": 1}]}`},
+		{data: `{"a": "quote \", comma", "b": [`, want: `{"a": "quote \", comma", "b": []}`},
+		{data: `{"a": 1}`, want: `{"a": 1}`},
+		{data: `{"a`, want: `{}`},
+	}
+	for _, tt := range tests {
+		got, ok := repairJSON([]byte(tt.data))
+		if !ok || string(got) != tt.want {
+			t.Errorf("repairJSON(%s) = %s, %v; want %s", tt.data, got, ok, tt.want)
+		}
+	}
+	if _, ok := repairJSON([]byte(`nonsense`)); ok {
+		t.Error("repairJSON(nonsense) succeeded")
+	}
+}
+
+func TestLoadSessionRecovery(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	s := NewSession()
+	s.SetMessages([]SessionMessage{
+		{Role: "user", Content: "First"},
+		{Role: "assistant", Content: "Second"},
+		{Role: "user", Content: "Third"},
+	})
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A file cut off in the third message, as a crash during a
+	// non-atomic write in an older version left it
+	sessionPath, _ := (&JSONStore{}).path(s.ID)
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cut := len(data) - 80
+	if err := os.WriteFile(sessionPath, data[:cut], 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadSession(s.ID)
+	if err != nil {
+		t.Fatalf("LoadSession() error = %v", err)
+	}
+	if !loaded.Recovered() || len(loaded.Messages) != 2 || loaded.Messages[1].Content != "Second" {
+		t.Fatalf("recovered %v with messages %+v, want the first two", loaded.Recovered(), loaded.Messages)
+	}
+	if err := loaded.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err = LoadSession(s.ID); err != nil || loaded.Recovered() || len(loaded.Messages) != 2 {
+		t.Errorf("LoadSession() after saving the recovered session = %v, %v", loaded, err)
+	}
+}
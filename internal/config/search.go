@@ -0,0 +1,323 @@
+package config
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// snippetLength is the approximate length of a search result snippet in
+// bytes.
+const snippetLength = 160
+
+// titleWeight is how much more a match in a session's title counts than
+// one in a message.
+const titleWeight = 1.5
+
+// maxSearchMatches is the number of matching messages shown per session.
+const maxSearchMatches = 3
+
+// SearchOptions narrows a search. Zero fields match everything.
+type SearchOptions struct {
+	Model string    // Only sessions whose model or any answering model contains this text
+	Role  string    // Only match messages with this role, and not titles
+	Tag   string    // Only sessions with this tag
+	Since time.Time // Only sessions updated at or after this time
+	Until time.Time // Only sessions updated before this time
+	Limit int       // At most this many results
+}
+
+// SearchResult is a session matching a search, with its best matching
+// messages first.
+type SearchResult struct {
+	Session SessionSummary `json:"session"`
+	Score   float64        `json:"score"`
+	Matches []SearchMatch  `json:"matches"`
+}
+
+// SearchMatch is a message matching a search. Highlights are the byte
+// ranges of the query terms in Snippet.
+type SearchMatch struct {
+	MessageID  int       `json:"message_id"`
+	Role       string    `json:"role"`
+	Time       time.Time `json:"time,omitzero"`
+	Snippet    string    `json:"snippet"`
+	Highlights [][2]int  `json:"highlights"`
+}
+
+// SessionSearchTexts returns the message text of each session's active
+// branch by session ID, for filtering sessions on their content.
+func SessionSearchTexts() (map[string]string, error) {
+	return DefaultStore.Texts()
+}
+
+// SearchSessions finds the sessions whose messages or title contain every
+// term of query, ranked by relevance. Terms are matched ignoring case, and
+// a quoted phrase is matched as a whole. Rarer terms and repeated matches
+// rank higher; a match in the title counts extra.
+func SearchSessions(query string, opts SearchOptions) ([]SearchResult, error) {
+	return DefaultStore.Search(query, opts)
+}
+
+// search ranks indexed sessions against the lowercase query terms.
+func search(sessions map[string]*indexedSession, terms []string, opts SearchOptions) []SearchResult {
+	// Count matches of each term per message of the sessions that pass
+	// the filters
+	type candidate struct {
+		entry  *indexedSession
+		counts [][]int // Per message, matches of each term
+		title  []int   // Matches of each term in the title
+	}
+	var candidates []candidate
+	sessionsWith := make([]int, len(terms))
+	for _, entry := range sessions {
+		if !opts.match(entry) {
+			continue
+		}
+		c := candidate{entry: entry, counts: make([][]int, len(entry.Messages)), title: make([]int, len(terms))}
+		found := make([]bool, len(terms))
+		if opts.Role == "" {
+			for t, term := range terms {
+				c.title[t] = len(findFold(entry.Summary.Title, term))
+				found[t] = c.title[t] > 0
+			}
+		}
+		for m, msg := range entry.Messages {
+			if opts.Role != "" && msg.Role != opts.Role {
+				continue
+			}
+			c.counts[m] = make([]int, len(terms))
+			for t, term := range terms {
+				c.counts[m][t] = len(findFold(msg.Text, term))
+				found[t] = found[t] || c.counts[m][t] > 0
+			}
+		}
+		all := true
+		for t := range terms {
+			if found[t] {
+				sessionsWith[t]++
+			} else {
+				all = false
+			}
+		}
+		if all {
+			candidates = append(candidates, c)
+		}
+	}
+
+	// Weight each term by how rare it is
+	idf := make([]float64, len(terms))
+	for t := range terms {
+		idf[t] = math.Log(1 + float64(len(sessions))/float64(max(sessionsWith[t], 1)))
+	}
+	termScore := func(counts []int) float64 {
+		score := 0.0
+		for t, n := range counts {
+			if n > 0 {
+				score += idf[t] * (1 + math.Log(float64(n)))
+			}
+		}
+		return score
+	}
+
+	results := make([]SearchResult, 0, len(candidates))
+	for _, c := range candidates {
+		type scored struct {
+			index int
+			score float64
+		}
+		var messages []scored
+		for m, counts := range c.counts {
+			if score := termScore(counts); score > 0 {
+				messages = append(messages, scored{m, score})
+			}
+		}
+		sort.SliceStable(messages, func(i, j int) bool { return messages[i].score > messages[j].score })
+
+		// The best message counts fully and the rest a little, so one
+		// focused message outranks many passing mentions
+		result := SearchResult{Session: c.entry.Summary, Score: titleWeight * termScore(c.title)}
+		for i, m := range messages {
+			if i == 0 {
+				result.Score += m.score
+			} else {
+				result.Score += m.score / 4
+			}
+			if i < maxSearchMatches {
+				msg := c.entry.Messages[m.index]
+				snippet, highlights := makeSnippet(msg.Text, terms)
+				result.Matches = append(result.Matches, SearchMatch{
+					MessageID:  msg.ID,
+					Role:       msg.Role,
+					Time:       msg.Time,
+					Snippet:    snippet,
+					Highlights: highlights,
+				})
+			}
+		}
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Session.UpdatedAt.After(results[j].Session.UpdatedAt)
+	})
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return results
+}
+
+// match reports whether an indexed session passes the filters that don't
+// depend on the query.
+func (o SearchOptions) match(entry *indexedSession) bool {
+	s := entry.Summary
+	if !o.Since.IsZero() && s.UpdatedAt.Before(o.Since) {
+		return false
+	}
+	if !o.Until.IsZero() && !s.UpdatedAt.Before(o.Until) {
+		return false
+	}
+	if o.Tag != "" && !slices.ContainsFunc(s.Tags, func(tag string) bool { return strings.EqualFold(tag, o.Tag) }) {
+		return false
+	}
+	if o.Model != "" {
+		model := strings.ToLower(o.Model)
+		if strings.Contains(strings.ToLower(s.Model), model) {
+			return true
+		}
+		for _, msg := range entry.Messages {
+			if strings.Contains(strings.ToLower(msg.Model), model) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// parseQuery splits a query into lowercase terms. Double-quoted phrases are
+// kept as one term.
+func parseQuery(query string) []string {
+	var terms []string
+	for i, part := range strings.Split(query, `"`) {
+		if i%2 == 1 {
+			// Inside quotes
+			if phrase := strings.Join(strings.Fields(part), " "); phrase != "" {
+				terms = append(terms, strings.ToLower(phrase))
+			}
+			continue
+		}
+		for _, word := range strings.Fields(part) {
+			terms = append(terms, strings.ToLower(word))
+		}
+	}
+	return terms
+}
+
+// findFold returns the byte ranges of the non-overlapping occurrences of
+// the lowercase term in s, ignoring case.
+func findFold(s, term string) [][2]int {
+	if term == "" {
+		return nil
+	}
+	first, _ := utf8.DecodeRuneInString(term)
+	var ranges [][2]int
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if unicode.ToLower(r) == first {
+			if end, ok := matchFold(s, i, term); ok {
+				ranges = append(ranges, [2]int{i, end})
+				i = end
+				continue
+			}
+		}
+		i += size
+	}
+	return ranges
+}
+
+// matchFold reports whether the lowercase term occurs in s at start,
+// ignoring case, and where the occurrence ends.
+func matchFold(s string, start int, term string) (int, bool) {
+	i := start
+	for _, want := range term {
+		if i >= len(s) {
+			return 0, false
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if unicode.ToLower(r) != want {
+			return 0, false
+		}
+		i += size
+	}
+	return i, true
+}
+
+// makeSnippet cuts the part of text around its first match for display on
+// one line, and returns it with the ranges of every match in it.
+func makeSnippet(text string, terms []string) (string, [][2]int) {
+	var ranges [][2]int
+	for _, term := range terms {
+		ranges = append(ranges, findFold(text, term)...)
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+
+	start, end := 0, len(text)
+	if len(ranges) > 0 && len(text) > snippetLength {
+		start = max(ranges[0][0]-snippetLength/3, 0)
+		end = min(start+snippetLength, len(text))
+		start = max(end-snippetLength, 0)
+		// Don't cut words or runes in half
+		if start > 0 {
+			if space := strings.IndexAny(text[start:ranges[0][0]], " \n\t"); space >= 0 {
+				start += space + 1
+			}
+			for start < len(text) && !utf8.RuneStart(text[start]) {
+				start++
+			}
+		}
+		if end < len(text) && ranges[0][1] <= end {
+			if space := strings.LastIndexAny(text[ranges[0][1]:end], " \n\t"); space >= 0 {
+				end = ranges[0][1] + space
+			}
+			for end > start && !utf8.RuneStart(text[end]) {
+				end--
+			}
+		}
+	} else if len(text) > snippetLength {
+		end = snippetLength
+		for end > 0 && !utf8.RuneStart(text[end]) {
+			end--
+		}
+	}
+
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = "…"
+	}
+	if end < len(text) {
+		suffix = "…"
+	}
+	// Whitespace is replaced byte for byte, so ranges stay valid
+	body := []byte(text[start:end])
+	for i, c := range body {
+		if c == '\n' || c == '\r' || c == '\t' {
+			body[i] = ' '
+		}
+	}
+
+	var highlights [][2]int
+	for _, r := range ranges {
+		if r[0] >= start && r[1] <= end {
+			highlights = append(highlights, [2]int{r[0] - start + len(prefix), r[1] - start + len(prefix)})
+		}
+	}
+	return prefix + string(body) + suffix, highlights
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSearchSession saves a session with the given title, model and
+// alternating user and assistant messages.
+func newSearchSession(t *testing.T, title, model string, messages ...string) *Session {
+	t.Helper()
+	s := NewSession()
+	s.Title = title
+	s.Model = model
+	for i, content := range messages {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		if err := s.AppendSessionMessage(SessionMessage{Role: role, Content: content, Model: model}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+// resultIDs returns the session IDs of search results in order.
+func resultIDs(results []SearchResult) []string {
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.Session.ID)
+	}
+	return ids
+}
+
+func TestSearchSessions(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	migration := newSearchSession(t, "", "anthropic/claude",
+		"How do I write a migration script?",
+		"A migration script runs schema changes. Keep each migration script small.")
+	mention := newSearchSession(t, "", "openai/gpt-4o",
+		"Plan the release",
+		"Step 3: run the migration, then deploy the script.")
+	titled := newSearchSession(t, "Migration script review", "openai/gpt-4o",
+		"Looks fine to me")
+	newSearchSession(t, "", "openai/gpt-4o", "Unrelated chat", "About lunch")
+
+	tests := []struct {
+		name  string
+		query string
+		opts  SearchOptions
+		want  []string
+	}{
+		{name: "ranked", query: "migration script", want: []string{migration.ID, titled.ID, mention.ID}},
+		{name: "phrase", query: `"migration script"`, want: []string{migration.ID, titled.ID}},
+		{name: "case", query: "MIGRATION Script", want: []string{migration.ID, titled.ID, mention.ID}},
+		{name: "model", query: "migration", opts: SearchOptions{Model: "gpt"}, want: []string{titled.ID, mention.ID}},
+		{name: "role", query: "migration", opts: SearchOptions{Role: "user"}, want: []string{migration.ID}},
+		{name: "limit", query: "migration", opts: SearchOptions{Limit: 1}, want: []string{migration.ID}},
+		{name: "since", query: "migration", opts: SearchOptions{Since: time.Now().Add(time.Hour)}, want: nil},
+		{name: "until", query: "migration", opts: SearchOptions{Until: time.Now().Add(-time.Hour)}, want: nil},
+		{name: "every term", query: "migration lunch", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := SearchSessions(tt.query, tt.opts)
+			if err != nil {
+				t.Fatalf("SearchSessions() error = %v", err)
+			}
+			if got := resultIDs(results); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("SearchSessions(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	results, err := SearchSessions("migration script", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	best := results[0].Matches[0]
+	if best.Role != "assistant" || best.MessageID != 2 || len(best.Highlights) != 4 {
+		t.Errorf("best match = %+v", best)
+	}
+	if h := best.Highlights[0]; best.Snippet[h[0]:h[1]] != "migration" {
+		t.Errorf("first highlight = %q", best.Snippet[h[0]:h[1]])
+	}
+
+	if _, err := SearchSessions(`  "" `, SearchOptions{}); err == nil {
+		t.Error("SearchSessions() with an empty query succeeded")
+	}
+}
+
+func TestSearchIndexUpdates(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	store := &JSONStore{}
+	s := newSearchSession(t, "", "", "First topic")
+	search := func(query string) []string {
+		t.Helper()
+		results, err := SearchSessions(query, SearchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resultIDs(results)
+	}
+
+	// Saving updates the index through its log without rewriting it, and
+	// searching folds the log in
+	if got := search("topic"); len(got) != 1 {
+		t.Fatalf("search before save = %v, want the session", got)
+	}
+	path, err := store.indexPath(searchIndexName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AppendMessage("assistant", "Second topic: walruses"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != string(before) {
+		t.Fatalf("index file rewritten by save: %v", err)
+	}
+	idx, err := loadIndex[*indexedSession](store, searchIndexName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry := idx.Sessions[s.ID]; entry == nil || !entry.stamp().equal(s.stamp) ||
+		entry.Messages[len(entry.Messages)-1].Text != "Second topic: walruses" {
+		t.Fatalf("index entry after save = %+v, want the new message", entry)
+	}
+	if got := search("walruses"); len(got) != 1 {
+		t.Errorf("search after save = %v, want the session", got)
+	}
+	if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), "walruses") {
+		t.Fatalf("index after search = %q, %v; want the new message", data, err)
+	}
+
+	// An entry that missed an update, such as one logged while another
+	// process cleared the log, is rebuilt rather than updated
+	if err := s.AppendMessage("user", "Third topic: otters"); err != nil {
+		t.Fatal(err)
+	}
+	logPath, err := store.indexLogPath(searchIndexName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(logPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AppendMessage("assistant", "Fourth topic: seals"); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("otters seals"); len(got) != 1 {
+		t.Errorf("search after a lost update = %v, want the session", got)
+	}
+
+	// Files changed behind the index's back are reindexed
+	other := NewSession()
+	other.appendNode(SessionMessage{Role: "user", Content: "Narwhals"})
+	sessionFile, _ := store.path(other.ID)
+	if err := other.write(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(logPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(sessionFile, time.Now(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("narwhals"); len(got) != 1 || got[0] != other.ID {
+		t.Errorf("search for a file missing from the index = %v", got)
+	}
+
+	// Deleted sessions drop out, even if deleted behind the index's back
+	if err := DeleteSession(s.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("walruses"); len(got) != 0 {
+		t.Errorf("search after delete = %v, want none", got)
+	}
+	if err := os.Remove(sessionFile); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("narwhals"); len(got) != 0 {
+		t.Errorf("search after removing the file = %v, want none", got)
+	}
+
+	// A corrupt index is rebuilt
+	newSearchSession(t, "", "", "Penguins")
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("penguins"); len(got) != 1 {
+		t.Errorf("search with a corrupt index = %v, want the session", got)
+	}
+}
+
+func TestMakeSnippet(t *testing.T) {
+	long := strings.Repeat("filler words here ", 20)
+	tests := []struct {
+		name       string
+		text       string
+		terms      []string
+		wantPrefix bool
+		wantSuffix bool
+	}{
+		{name: "short", text: "Run the migration\nnow", terms: []string{"migration"}},
+		{name: "middle", text: long + "the Migration step " + long, terms: []string{"migration"}, wantPrefix: true, wantSuffix: true},
+		{name: "start", text: "Migration " + long, terms: []string{"migration"}, wantSuffix: true},
+		{name: "unicode", text: long + "Ünïcode ÜNÏCODE " + long, terms: []string{"ünïcode"}, wantPrefix: true, wantSuffix: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet, highlights := makeSnippet(tt.text, tt.terms)
+			if strings.Contains(snippet, "\n") {
+				t.Errorf("snippet %q has a newline", snippet)
+			}
+			if strings.HasPrefix(snippet, "…") != tt.wantPrefix || strings.HasSuffix(snippet, "…") != tt.wantSuffix {
+				t.Errorf("snippet %q: want prefix %v, suffix %v", snippet, tt.wantPrefix, tt.wantSuffix)
+			}
+			if len(snippet) > snippetLength+2*len("…") {
+				t.Errorf("snippet is %d bytes long", len(snippet))
+			}
+			if len(highlights) == 0 {
+				t.Fatalf("snippet %q has no highlights", snippet)
+			}
+			for _, h := range highlights {
+				if got := strings.ToLower(snippet[h[0]:h[1]]); got != tt.terms[0] {
+					t.Errorf("highlight %v = %q, want %q", h, got, tt.terms[0])
+				}
+			}
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	got := parseQuery(`Migration "Connection  Pool" script "unclosed`)
+	want := []string{"migration", "connection pool", "script", "unclosed"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("parseQuery() = %q, want %q", got, want)
+	}
+}
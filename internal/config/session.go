@@ -4,48 +4,98 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
-	"sort"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/vstratful/openrouter-cli/internal/api"
 )
 
 // ErrSessionNotFound is returned when a session cannot be found.
 var ErrSessionNotFound = errors.New("session not found")
 
+// SessionVersion is the current session file format version.
+// Version 1 files have no version field and hold text-only messages.
+// Version 2 adds content parts and generated images, with their binary data
+// kept in the blob store. Version 3 stores the conversation as a tree of
+// message nodes so that edits and retries keep the replaced continuation.
+// Version 4 adds Seq, as changes made since the file was written may be
+// kept in a journal next to it.
+const SessionVersion = 4
+
 // SessionMessage represents a message in the conversation.
+// Content always holds the message text. Parts is set for multimodal
+// messages and Images for images generated by the model.
+// Usage is recorded on assistant messages when the API reports it.
+// Reasoning holds the model's thinking text, separate from the answer.
+// Model and Provider name the model that answered an assistant message and
+// the upstream provider that served it.
+// ID identifies the message within its session and Parent is the ID of the
+// message it follows, 0 for the first message of a branch from the start.
+// Time is when the message was added; messages saved by older versions have
+// none.
 type SessionMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	ID        int           `json:"id"`
+	Parent    int           `json:"parent,omitempty"`
+	Role      string        `json:"role"`
+	Content   string        `json:"content"`
+	Parts     []SessionPart `json:"parts,omitempty"`
+	Images    []SessionPart `json:"images,omitempty"`
+	Reasoning string        `json:"reasoning,omitempty"`
+	Model     string        `json:"model,omitempty"`
+	Provider  string        `json:"provider,omitempty"`
+	Usage     *api.Usage    `json:"usage,omitempty"`
+	Time      time.Time     `json:"time,omitzero"`
 }
 
 // Session represents a CLI session with its history.
 type Session struct {
-	ID        string           `json:"id"`
-	Model     string           `json:"model,omitempty"` // Model used for this session
-	CreatedAt time.Time        `json:"created_at"`
-	UpdatedAt time.Time        `json:"updated_at"`
-	History   []string         `json:"history"`  // User input history for arrow key navigation
-	Messages  []SessionMessage `json:"messages"` // Full conversation for resume
+	Version         int              `json:"version"`
+	ID              string           `json:"id"`
+	Model           string           `json:"model,omitempty"`            // Model used for this session
+	Models          []string         `json:"models,omitempty"`           // Fallback chain, primary first (when more than one)
+	SystemPrompt    string           `json:"system_prompt,omitempty"`    // System prompt sent before the conversation
+	Title           string           `json:"title,omitempty"`            // Name given with "sessions rename"
+	Tags            []string         `json:"tags,omitempty"`             // Labels for filtering, sorted
+	Source          string           `json:"source,omitempty"`           // Origin of an imported session, such as "chatgpt:<id>"
+	ContextStrategy string           `json:"context_strategy,omitempty"` // How chats fit a conversation outgrowing the context window
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	History         []string         `json:"history"` // User input history for arrow key navigation
+	Nodes           []SessionMessage `json:"nodes"`   // Messages of every branch, each naming its parent
+	Head            int              `json:"head"`    // ID of the last message on the active branch
+	Seq             int              `json:"seq"`     // Number of the last journaled change the file includes
+	Messages        []SessionMessage `json:"-"`       // Active branch, first message first; derived from Nodes and Head
+
+	stamp       fileStamp // State of the files when loaded or last written
+	savedHeader string    // Header as last written, to journal changes to it
+	needsSave   bool      // A change failed to be journaled
+	recovered   bool      // Loaded from a damaged file
+	maxID       int       // Highest message ID among the first scannedIDs nodes
+	scannedIDs  int
 }
 
 // SessionSummary represents a session for list display.
 type SessionSummary struct {
-	ID           string
-	Model        string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	MessageCount int
-	Preview      string // First user message, truncated to ~50 chars
+	ID           string    `json:"id"`
+	Title        string    `json:"title,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	Source       string    `json:"source,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	MessageCount int       `json:"message_count"` // Messages on the active branch
+	Nodes        int       `json:"nodes"`         // Messages on every branch
+	Preview      string    `json:"preview"`       // First user message, truncated to ~50 chars
 }
 
 // NewSession creates a new session with a generated UUID.
 func NewSession() *Session {
 	now := time.Now()
 	return &Session{
+		Version:   SessionVersion,
 		ID:        uuid.New().String(),
 		CreatedAt: now,
 		UpdatedAt: now,
@@ -65,131 +115,246 @@ var GetSessionDir = func() (string, error) {
 
 // Save writes the session to disk.
 func (s *Session) Save() error {
-	sessionDir, err := GetSessionDir()
-	if err != nil {
-		return err
-	}
-
-	// Create sessions directory with user-only permissions
-	if err := os.MkdirAll(sessionDir, 0700); err != nil {
-		return fmt.Errorf("failed to create sessions directory: %w", err)
-	}
-
 	// Update the timestamp on each save
 	s.UpdatedAt = time.Now()
+	return s.write()
+}
 
-	sessionPath := filepath.Join(sessionDir, s.ID+".json")
-
-	data, err := json.MarshalIndent(s, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal session: %w", err)
-	}
-
-	if err := os.WriteFile(sessionPath, data, 0600); err != nil {
-		return fmt.Errorf("failed to write session file: %w", err)
+// SaveImported writes a session built from another tool's history, keeping
+// its UpdatedAt so it sorts by when the conversation actually happened.
+func (s *Session) SaveImported() error {
+	if s.UpdatedAt.IsZero() {
+		s.UpdatedAt = time.Now()
 	}
+	return s.write()
+}
 
-	return nil
+// write writes the session to DefaultStore.
+func (s *Session) write() error {
+	return DefaultStore.Save(s)
 }
 
 // AppendHistory adds an entry to the history and saves.
 func (s *Session) AppendHistory(entry string) error {
 	s.History = append(s.History, entry)
-	return s.Save()
+	s.UpdatedAt = time.Now()
+	return DefaultStore.AppendHistory(s, entry)
 }
 
 // AppendMessage adds a message to the conversation and saves.
 func (s *Session) AppendMessage(role, content string) error {
-	s.Messages = append(s.Messages, SessionMessage{Role: role, Content: content})
-	return s.Save()
+	return s.AppendSessionMessage(SessionMessage{Role: role, Content: content})
 }
 
-// LoadSession loads an existing session by ID.
-func LoadSession(id string) (*Session, error) {
-	sessionDir, err := GetSessionDir()
-	if err != nil {
-		return nil, err
+// AppendSessionMessage adds a fully populated message to the end of the
+// active branch and saves.
+func (s *Session) AppendSessionMessage(msg SessionMessage) error {
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+	s.appendNode(msg)
+	s.UpdatedAt = time.Now()
+	return DefaultStore.AppendMessage(s, s.Nodes[len(s.Nodes)-1])
+}
+
+// Lock keeps other processes from writing the session while it is open,
+// until Unlock. It fails with ErrSessionLocked if another process has the
+// session open.
+func (s *Session) Lock() error {
+	return DefaultStore.Lock(s.ID)
+}
+
+// Unlock releases the lock taken with Lock.
+func (s *Session) Unlock() error {
+	return DefaultStore.Unlock(s.ID)
+}
+
+// Recovered reports whether the session was loaded from a file cut short by
+// a crash. The messages before the damage are kept; the file is repaired
+// when the session is next saved.
+func (s *Session) Recovered() bool {
+	return s.recovered
+}
+
+// TotalUsage returns the token usage and cost summed across all messages.
+func (s *Session) TotalUsage() api.Usage {
+	var total api.Usage
+	for _, msg := range s.Messages {
+		total.Add(msg.Usage)
 	}
+	return total
+}
 
-	sessionPath := filepath.Join(sessionDir, id+".json")
+// LastProvider returns the provider that served the most recent assistant
+// message, or an empty string if none was recorded.
+func (s *Session) LastProvider() string {
+	return s.lastRecorded(func(msg SessionMessage) string { return msg.Provider })
+}
 
-	data, err := os.ReadFile(sessionPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+// LastModel returns the model that answered the most recent assistant
+// message, or an empty string if none was recorded.
+func (s *Session) LastModel() string {
+	return s.lastRecorded(func(msg SessionMessage) string { return msg.Model })
+}
+
+// lastRecorded returns the most recent non-empty value of a message field.
+func (s *Session) lastRecorded(field func(SessionMessage) string) string {
+	for i := len(s.Messages) - 1; i >= 0; i-- {
+		if v := field(s.Messages[i]); v != "" {
+			return v
 		}
-		return nil, fmt.Errorf("failed to read session file: %w", err)
 	}
+	return ""
+}
 
-	var session Session
-	if err := json.Unmarshal(data, &session); err != nil {
-		return nil, fmt.Errorf("failed to parse session file: %w", err)
+// ModelChain returns the session's models in fallback order, primary first.
+func (s *Session) ModelChain() []string {
+	if len(s.Models) > 0 {
+		return s.Models
 	}
+	if s.Model != "" {
+		return []string{s.Model}
+	}
+	return nil
+}
 
-	return &session, nil
+// SetModelChain records the primary model and its fallbacks.
+func (s *Session) SetModelChain(chain []string) {
+	s.Model, s.Models = "", nil
+	if len(chain) > 0 {
+		s.Model = chain[0]
+	}
+	if len(chain) > 1 {
+		s.Models = chain
+	}
+}
+
+// LoadSession loads an existing session by ID.
+func LoadSession(id string) (*Session, error) {
+	return DefaultStore.Load(id)
 }
 
-// ListSessions returns summaries of all sessions sorted by UpdatedAt descending.
+// migrate upgrades a session read from data in an older format version in
+// place. The file is rewritten in the current format on its next save.
+func (s *Session) migrate(data []byte) error {
+	if s.Version > SessionVersion {
+		return fmt.Errorf("session %s uses format version %d, newer than the supported version %d", s.ID, s.Version, SessionVersion)
+	}
+	if s.Version < 2 {
+		// Version 1 text-only messages are stored the same way in version 2
+		s.Version = 2
+	}
+	if s.Version < 3 {
+		// Version 2 holds a flat message list, which becomes a single branch
+		var flat struct {
+			Messages []SessionMessage `json:"messages"`
+		}
+		if err := json.Unmarshal(data, &flat); err != nil {
+			return fmt.Errorf("failed to parse session file: %w", err)
+		}
+		s.SetMessages(flat.Messages)
+		s.Version = 3
+	}
+	// Version 3 files have no journal
+	s.Version = SessionVersion
+	return nil
+}
+
+// ListSessions returns summaries of all sessions with messages sorted by
+// UpdatedAt descending.
 func ListSessions() ([]SessionSummary, error) {
-	sessionDir, err := GetSessionDir()
+	return listSessions(false)
+}
+
+// ListAllSessions is like ListSessions but includes sessions without
+// messages.
+func ListAllSessions() ([]SessionSummary, error) {
+	return listSessions(true)
+}
+
+func listSessions(includeEmpty bool) ([]SessionSummary, error) {
+	page, err := DefaultStore.List(ListOptions{IncludeEmpty: includeEmpty})
 	if err != nil {
 		return nil, err
 	}
+	return page.Sessions, nil
+}
 
-	entries, err := os.ReadDir(sessionDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return []SessionSummary{}, nil
+// Summary returns the session's list entry.
+func (s *Session) Summary() SessionSummary {
+	// Get preview from first user message
+	preview := ""
+	for _, msg := range s.Messages {
+		if msg.Role == "user" {
+			preview = msg.Content
+			break
 		}
-		return nil, fmt.Errorf("failed to read sessions directory: %w", err)
 	}
 
-	var summaries []SessionSummary
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
-			continue
-		}
-
-		id := strings.TrimSuffix(entry.Name(), ".json")
-		session, err := LoadSession(id)
-		if err != nil {
-			// Skip corrupted files
-			continue
-		}
+	return SessionSummary{
+		ID:           s.ID,
+		Title:        s.Title,
+		Model:        s.Model,
+		Tags:         s.Tags,
+		Source:       s.Source,
+		CreatedAt:    s.CreatedAt,
+		UpdatedAt:    s.UpdatedAt,
+		MessageCount: len(s.Messages),
+		Nodes:        len(s.Nodes),
+		Preview:      previewText(preview),
+	}
+}
 
-		// Filter out empty sessions
-		if len(session.Messages) == 0 {
-			continue
-		}
+// FindSession returns the ID of the session whose ID is id or starts with
+// it, so sessions can be named by a short prefix.
+func FindSession(id string) (string, error) {
+	ids, err := DefaultStore.IDs()
+	if err != nil {
+		return "", err
+	}
 
-		// Get preview from first user message
-		preview := ""
-		for _, msg := range session.Messages {
-			if msg.Role == "user" {
-				preview = msg.Content
-				break
-			}
+	var matches []string
+	for _, candidate := range ids {
+		if candidate == id {
+			return id, nil
 		}
-		if len(preview) > PreviewTruncateLength {
-			preview = preview[:PreviewTruncateLength-3] + "..."
+		if strings.HasPrefix(candidate, id) {
+			matches = append(matches, candidate)
 		}
-
-		summaries = append(summaries, SessionSummary{
-			ID:           session.ID,
-			Model:        session.Model,
-			CreatedAt:    session.CreatedAt,
-			UpdatedAt:    session.UpdatedAt,
-			MessageCount: len(session.Messages),
-			Preview:      preview,
-		})
 	}
+	switch {
+	case id == "" || len(matches) == 0:
+		return "", fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	case len(matches) > 1:
+		return "", fmt.Errorf("session ID %q is ambiguous: it matches %d sessions", id, len(matches))
+	}
+	return matches[0], nil
+}
 
-	// Sort by UpdatedAt descending (most recent first)
-	sort.Slice(summaries, func(i, j int) bool {
-		return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt)
-	})
+// DeleteSession removes a session. Attachments it referenced stay in the
+// blob store until PruneBlobs finds them unreferenced.
+func DeleteSession(id string) error {
+	return DefaultStore.Delete(id)
+}
 
-	return summaries, nil
+// SetTags replaces the session's tags, dropping blanks and duplicates.
+func (s *Session) SetTags(tags []string) {
+	s.Tags = nil
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(s.Tags, tag) {
+			s.Tags = append(s.Tags, tag)
+		}
+	}
+	slices.Sort(s.Tags)
+}
+
+// previewText truncates text for a one-line preview.
+func previewText(text string) string {
+	if len(text) > PreviewTruncateLength {
+		return text[:PreviewTruncateLength-3] + "..."
+	}
+	return text
 }
 
 // GetLatestSession returns the most recently updated session.
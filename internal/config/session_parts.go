@@ -0,0 +1,192 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+// Session content part types.
+const (
+	SessionPartText  = "text"
+	SessionPartImage = "image"
+	SessionPartFile  = "file"
+)
+
+// SessionPart is a stored content part or generated image. Inline data is
+// kept in the blob store and referenced by hash; remote content keeps its URL.
+type SessionPart struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+	MIMEType string `json:"mime_type,omitempty"`
+	Filename string `json:"filename,omitempty"`
+}
+
+// NewSessionMessage converts an API message for storage, moving inline
+// images and files into the blob store.
+func NewSessionMessage(msg api.Message) (SessionMessage, error) {
+	stored := SessionMessage{
+		Role:      msg.Role,
+		Content:   msg.Content,
+		Reasoning: msg.Reasoning,
+	}
+
+	var text []string
+	for _, part := range msg.ContentParts {
+		sp, err := storeContentPart(part)
+		if err != nil {
+			return SessionMessage{}, err
+		}
+		stored.Parts = append(stored.Parts, sp)
+		if sp.Type == SessionPartText {
+			text = append(text, sp.Text)
+		}
+	}
+	if stored.Content == "" {
+		stored.Content = strings.Join(text, "\n")
+	}
+
+	for _, image := range msg.Images {
+		sp, err := storeURL(SessionPartImage, image.ImageURL.URL, "")
+		if err != nil {
+			return SessionMessage{}, err
+		}
+		stored.Images = append(stored.Images, sp)
+	}
+
+	return stored, nil
+}
+
+// storeContentPart converts an API content part for storage.
+func storeContentPart(part api.ContentPart) (SessionPart, error) {
+	switch part.Type {
+	case "text":
+		return SessionPart{Type: SessionPartText, Text: part.Text}, nil
+	case "image_url":
+		if part.ImageURL == nil {
+			return SessionPart{}, fmt.Errorf("image content part has no URL")
+		}
+		return storeURL(SessionPartImage, part.ImageURL.URL, "")
+	case "file":
+		if part.File == nil {
+			return SessionPart{}, fmt.Errorf("file content part has no file")
+		}
+		return storeURL(SessionPartFile, part.File.FileData, part.File.Filename)
+	default:
+		return SessionPart{}, fmt.Errorf("unsupported content part type %q", part.Type)
+	}
+}
+
+// storeURL stores the content of a data URL as a blob. Other URLs are kept
+// as references.
+func storeURL(partType, url, filename string) (SessionPart, error) {
+	part := SessionPart{Type: partType, Filename: filename}
+	if !api.IsDataURL(url) {
+		part.URL = url
+		return part, nil
+	}
+
+	mimeType, data, err := api.DecodeDataURL(url)
+	if err != nil {
+		return SessionPart{}, err
+	}
+	hash, err := WriteBlob(data)
+	if err != nil {
+		return SessionPart{}, err
+	}
+	part.Blob = hash
+	part.MIMEType = mimeType
+	return part, nil
+}
+
+// APIMessage rebuilds the API message, loading attachments from the blob
+// store. Attachments that can't be loaded are replaced by a text note, and
+// the returned error describes them; the message is usable either way.
+func (m SessionMessage) APIMessage() (api.Message, error) {
+	msg := api.Message{
+		Role:      m.Role,
+		Content:   m.Content,
+		Reasoning: m.Reasoning,
+	}
+
+	var errs []error
+	for _, part := range m.Parts {
+		cp, err := part.contentPart()
+		if err != nil {
+			errs = append(errs, err)
+			cp = api.ContentPart{Type: "text", Text: fmt.Sprintf("[%s unavailable]", part.label())}
+		}
+		msg.ContentParts = append(msg.ContentParts, cp)
+	}
+	for _, image := range m.Images {
+		url, err := image.url()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		msg.Images = append(msg.Images, api.ImageContent{Type: "image_url", ImageURL: api.ImageURL{URL: url}})
+	}
+
+	return msg, errors.Join(errs...)
+}
+
+// APIMessages rebuilds the conversation for the API. See
+// SessionMessage.APIMessage for how missing attachments are handled.
+func (s *Session) APIMessages() ([]api.Message, error) {
+	messages := make([]api.Message, 0, len(s.Messages))
+	var errs []error
+	for _, stored := range s.Messages {
+		msg, err := stored.APIMessage()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, errors.Join(errs...)
+}
+
+// contentPart converts a stored part back to an API content part.
+func (p SessionPart) contentPart() (api.ContentPart, error) {
+	switch p.Type {
+	case SessionPartText:
+		return api.ContentPart{Type: "text", Text: p.Text}, nil
+	case SessionPartImage:
+		url, err := p.url()
+		if err != nil {
+			return api.ContentPart{}, err
+		}
+		return api.ContentPart{Type: "image_url", ImageURL: &api.ImageURL{URL: url}}, nil
+	case SessionPartFile:
+		url, err := p.url()
+		if err != nil {
+			return api.ContentPart{}, err
+		}
+		return api.ContentPart{Type: "file", File: &api.FileContent{Filename: p.Filename, FileData: url}}, nil
+	default:
+		return api.ContentPart{}, fmt.Errorf("unsupported session part type %q", p.Type)
+	}
+}
+
+// url returns the part's remote URL, or its blob as a data URL.
+func (p SessionPart) url() (string, error) {
+	if p.Blob == "" {
+		return p.URL, nil
+	}
+	data, err := ReadBlob(p.Blob)
+	if err != nil {
+		return "", fmt.Errorf("failed to load %s: %w", p.label(), err)
+	}
+	return api.EncodeDataURL(p.MIMEType, data), nil
+}
+
+// label describes the part for notes and errors.
+func (p SessionPart) label() string {
+	if p.Filename != "" {
+		return fmt.Sprintf("%s %s", p.Type, p.Filename)
+	}
+	return p.Type
+}
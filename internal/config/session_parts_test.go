@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+func TestSessionMultimodalRoundTrip(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	imageURL := api.EncodeDataURL("image/png", []byte("png bytes"))
+	pdfURL := api.EncodeDataURL("application/pdf", []byte("%PDF-1.7"))
+	generatedURL := api.EncodeDataURL("image/webp", []byte("webp bytes"))
+
+	user := api.Message{
+		Role: "user",
+		ContentParts: []api.ContentPart{
+			{Type: "text", Text: "Compare these"},
+			{Type: "image_url", ImageURL: &api.ImageURL{URL: imageURL}},
+			{Type: "image_url", ImageURL: &api.ImageURL{URL: "https://example.com/cat.jpg"}},
+			{Type: "file", File: &api.FileContent{Filename: "report.pdf", FileData: pdfURL}},
+		},
+	}
+	assistant := api.Message{
+		Role:    "assistant",
+		Content: "Here is a chart",
+		Images:  []api.ImageContent{{Type: "image_url", ImageURL: api.ImageURL{URL: generatedURL}}},
+	}
+
+	session := NewSession()
+	for _, msg := range []api.Message{user, assistant} {
+		stored, err := NewSessionMessage(msg)
+		if err != nil {
+			t.Fatalf("NewSessionMessage() error = %v", err)
+		}
+		if err := session.AppendSessionMessage(stored); err != nil {
+			t.Fatalf("AppendSessionMessage() error = %v", err)
+		}
+	}
+
+	// Inline data is kept out of the session file
+	sessionDir, _ := GetSessionDir()
+	data, err := os.ReadFile(filepath.Join(sessionDir, session.ID+".json"))
+	if err != nil {
+		t.Fatalf("failed to read session file: %v", err)
+	}
+	if strings.Contains(string(data), "base64") {
+		t.Error("session file should not contain inline base64 data")
+	}
+
+	loaded, err := LoadSession(session.ID)
+	if err != nil {
+		t.Fatalf("LoadSession() error = %v", err)
+	}
+	if loaded.Messages[0].Content != "Compare these" {
+		t.Errorf("user Content = %q, want text of parts", loaded.Messages[0].Content)
+	}
+
+	messages, err := loaded.APIMessages()
+	if err != nil {
+		t.Fatalf("APIMessages() error = %v", err)
+	}
+	parts := messages[0].ContentParts
+	if len(parts) != 4 {
+		t.Fatalf("got %d content parts, want 4", len(parts))
+	}
+	if parts[1].ImageURL.URL != imageURL {
+		t.Errorf("inline image URL = %q, want %q", parts[1].ImageURL.URL, imageURL)
+	}
+	if parts[2].ImageURL.URL != "https://example.com/cat.jpg" {
+		t.Errorf("remote image URL = %q", parts[2].ImageURL.URL)
+	}
+	if parts[3].File.Filename != "report.pdf" || parts[3].File.FileData != pdfURL {
+		t.Errorf("file part = %+v", parts[3].File)
+	}
+	if len(messages[1].Images) != 1 || messages[1].Images[0].ImageURL.URL != generatedURL {
+		t.Errorf("generated images = %+v", messages[1].Images)
+	}
+}
+
+func TestSessionMissingBlob(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	stored := SessionMessage{
+		Role:    "user",
+		Content: "see attached",
+		Parts: []SessionPart{
+			{Type: SessionPartText, Text: "see attached"},
+			{Type: SessionPartFile, Filename: "gone.pdf", MIMEType: "application/pdf",
+				Blob: "1111111111111111111111111111111111111111111111111111111111111111"},
+		},
+	}
+
+	msg, err := stored.APIMessage()
+	if !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("APIMessage() error = %v, want ErrBlobNotFound", err)
+	}
+	if len(msg.ContentParts) != 2 || msg.ContentParts[1].Text != "[file gone.pdf unavailable]" {
+		t.Errorf("ContentParts = %+v, want a note in place of the missing file", msg.ContentParts)
+	}
+}
@@ -1,10 +1,14 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
 )
 
 // testSessionDir is used to override the session directory for testing
@@ -65,11 +69,12 @@ func TestSessionSaveAndLoad(t *testing.T) {
 	// Create and save a session
 	s := NewSession()
 	s.Model = "test-model"
+	s.SystemPrompt = "Be brief."
 	s.History = []string{"hello", "world"}
-	s.Messages = []SessionMessage{
+	s.SetMessages([]SessionMessage{
 		{Role: "user", Content: "Hello"},
 		{Role: "assistant", Content: "Hi there!"},
-	}
+	})
 
 	if err := s.Save(); err != nil {
 		t.Fatalf("Save() error = %v", err)
@@ -88,6 +93,9 @@ func TestSessionSaveAndLoad(t *testing.T) {
 	if loaded.Model != s.Model {
 		t.Errorf("Model = %q, want %q", loaded.Model, s.Model)
 	}
+	if loaded.SystemPrompt != s.SystemPrompt {
+		t.Errorf("SystemPrompt = %q, want %q", loaded.SystemPrompt, s.SystemPrompt)
+	}
 	if len(loaded.History) != len(s.History) {
 		t.Errorf("History length = %d, want %d", len(loaded.History), len(s.History))
 	}
@@ -196,6 +204,84 @@ func TestListSessions(t *testing.T) {
 	}
 }
 
+func TestListAllSessions(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	s1 := NewSession()
+	s1.AppendMessage("user", "hello")
+	s2 := NewSession()
+	s2.Save()
+
+	summaries, err := ListAllSessions()
+	if err != nil {
+		t.Fatalf("ListAllSessions() error = %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Errorf("ListAllSessions() returned %d sessions, want 2 including the empty one", len(summaries))
+	}
+}
+
+func TestFindSession(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	for _, id := range []string{"abc123", "abd456", "abc"} {
+		s := NewSession()
+		s.ID = id
+		s.Save()
+	}
+
+	tests := []struct {
+		id      string
+		want    string
+		wantErr bool
+	}{
+		{id: "abc123", want: "abc123"},
+		{id: "abd", want: "abd456"},
+		{id: "abc", want: "abc"}, // Exact match wins over longer IDs
+		{id: "ab", wantErr: true},
+		{id: "xyz", wantErr: true},
+		{id: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := FindSession(tt.id)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("FindSession(%q) = %q, %v, want %q (error %v)", tt.id, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDeleteSession(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	s := NewSession()
+	s.Save()
+	if err := DeleteSession(s.ID); err != nil {
+		t.Fatalf("DeleteSession() error = %v", err)
+	}
+	if _, err := LoadSession(s.ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("LoadSession() after delete error = %v, want ErrSessionNotFound", err)
+	}
+	if err := DeleteSession(s.ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("DeleteSession() again error = %v, want ErrSessionNotFound", err)
+	}
+	for _, id := range []string{"../config", "..", ""} {
+		if err := DeleteSession(id); err == nil || errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("DeleteSession(%q) error = %v, want invalid ID", id, err)
+		}
+	}
+}
+
+func TestSessionSetTags(t *testing.T) {
+	s := NewSession()
+	s.SetTags([]string{"work", " go ", "", "work"})
+	if want := []string{"go", "work"}; !reflect.DeepEqual(s.Tags, want) {
+		t.Errorf("Tags = %v, want %v", s.Tags, want)
+	}
+}
+
 func TestSessionSummaryPreview(t *testing.T) {
 	_, cleanup := setupTestDir(t)
 	defer cleanup()
@@ -251,3 +337,143 @@ func TestGetLatestSession(t *testing.T) {
 		t.Errorf("GetLatestSession().ID = %q, want %q", latest.ID, s2.ID)
 	}
 }
+
+func TestSessionUsage(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	s := NewSession()
+	s.AppendMessage("user", "Hello")
+	if err := s.AppendSessionMessage(SessionMessage{
+		Role:    "assistant",
+		Content: "Hi!",
+		Usage:   &api.Usage{PromptTokens: 10, CompletionTokens: 3, TotalTokens: 13, Cost: 0.0001},
+	}); err != nil {
+		t.Fatalf("AppendSessionMessage() error = %v", err)
+	}
+	s.AppendMessage("user", "Again")
+	s.AppendSessionMessage(SessionMessage{
+		Role:    "assistant",
+		Content: "Hi again!",
+		Usage:   &api.Usage{PromptTokens: 20, CompletionTokens: 4, TotalTokens: 24, Cost: 0.0002},
+	})
+
+	loaded, err := LoadSession(s.ID)
+	if err != nil {
+		t.Fatalf("LoadSession() error = %v", err)
+	}
+	if loaded.Messages[0].Usage != nil {
+		t.Error("user message should not have usage")
+	}
+	if loaded.Messages[1].Usage == nil || loaded.Messages[1].Usage.TotalTokens != 13 {
+		t.Errorf("Messages[1].Usage = %+v, want 13 total tokens", loaded.Messages[1].Usage)
+	}
+
+	total := loaded.TotalUsage()
+	if total.PromptTokens != 30 || total.CompletionTokens != 7 || total.TotalTokens != 37 {
+		t.Errorf("TotalUsage() = %+v", total)
+	}
+}
+
+func TestSessionReasoning(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	s := NewSession()
+	s.AppendMessage("user", "What is 6*7?")
+	if err := s.AppendSessionMessage(SessionMessage{
+		Role:      "assistant",
+		Content:   "42",
+		Reasoning: "Multiply 6 by 7.",
+	}); err != nil {
+		t.Fatalf("AppendSessionMessage() error = %v", err)
+	}
+
+	loaded, err := LoadSession(s.ID)
+	if err != nil {
+		t.Fatalf("LoadSession() error = %v", err)
+	}
+	if loaded.Messages[0].Reasoning != "" {
+		t.Errorf("Messages[0].Reasoning = %q, want empty", loaded.Messages[0].Reasoning)
+	}
+	if loaded.Messages[1].Reasoning != "Multiply 6 by 7." {
+		t.Errorf("Messages[1].Reasoning = %q, want %q", loaded.Messages[1].Reasoning, "Multiply 6 by 7.")
+	}
+}
+
+func TestSessionModelChain(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	s := NewSession()
+	if s.ModelChain() != nil {
+		t.Errorf("ModelChain() = %v, want nil", s.ModelChain())
+	}
+
+	s.SetModelChain([]string{"a/primary", "b/fallback"})
+	s.AppendMessage("user", "Hi")
+	s.AppendSessionMessage(SessionMessage{Role: "assistant", Content: "Hello", Model: "b/fallback", Provider: "Groq"})
+
+	loaded, err := LoadSession(s.ID)
+	if err != nil {
+		t.Fatalf("LoadSession() error = %v", err)
+	}
+	if loaded.Model != "a/primary" {
+		t.Errorf("Model = %q, want a/primary", loaded.Model)
+	}
+	if got := loaded.ModelChain(); len(got) != 2 || got[1] != "b/fallback" {
+		t.Errorf("ModelChain() = %v", got)
+	}
+	if loaded.LastModel() != "b/fallback" || loaded.LastProvider() != "Groq" {
+		t.Errorf("LastModel() = %q, LastProvider() = %q", loaded.LastModel(), loaded.LastProvider())
+	}
+
+	loaded.SetModelChain([]string{"c/only"})
+	if loaded.Models != nil || loaded.Model != "c/only" {
+		t.Errorf("single chain: Model = %q, Models = %v", loaded.Model, loaded.Models)
+	}
+}
+
+func TestLoadSessionMigration(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	if err := os.MkdirAll(testSessionDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// Version 1 files have no version field
+	v1 := `{"id":"old","model":"m","created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z",` +
+		`"history":["hi"],"messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"hello"}]}`
+	if err := os.WriteFile(filepath.Join(testSessionDir, "old.json"), []byte(v1), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := LoadSession("old")
+	if err != nil {
+		t.Fatalf("LoadSession() error = %v", err)
+	}
+	if session.Version != SessionVersion {
+		t.Errorf("Version = %d, want %d", session.Version, SessionVersion)
+	}
+	messages, err := session.APIMessages()
+	if err != nil {
+		t.Fatalf("APIMessages() error = %v", err)
+	}
+	if len(messages) != 2 || messages[1].Content != "hello" || messages[1].ContentParts != nil {
+		t.Errorf("messages = %+v", messages)
+	}
+	// The flat message list becomes a single branch
+	if len(session.Nodes) != 2 || session.Nodes[1].Parent != session.Nodes[0].ID || session.Head != session.Nodes[1].ID {
+		t.Errorf("Nodes = %+v, Head = %d, want a single branch", session.Nodes, session.Head)
+	}
+
+	// Files from a newer version are rejected rather than silently mangled
+	future := `{"version":99,"id":"future","messages":[]}`
+	if err := os.WriteFile(filepath.Join(testSessionDir, "future.json"), []byte(future), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSession("future"); err == nil {
+		t.Error("LoadSession() should fail for a newer format version")
+	}
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SessionBranch summarizes one branch of a session's conversation tree.
+// Head is the ID of the branch's last message and Length its number of
+// messages. Preview shows the first message where the branch leaves the
+// active branch, or the last user message for the active branch itself.
+type SessionBranch struct {
+	Head    int
+	Length  int
+	Active  bool
+	Preview string
+}
+
+// nextID returns an unused message ID for a node being added. Only nodes
+// added since the last call are scanned, so building a long conversation
+// stays linear; the IDs of removed messages aren't reused.
+func (s *Session) nextID() int {
+	for _, node := range s.Nodes[min(s.scannedIDs, len(s.Nodes)):] {
+		s.maxID = max(s.maxID, node.ID)
+	}
+	s.scannedIDs = len(s.Nodes)
+	s.maxID++
+	return s.maxID
+}
+
+// appendNode adds msg as a child of the active branch's last message and
+// makes it the new head.
+func (s *Session) appendNode(msg SessionMessage) {
+	msg.ID = s.nextID()
+	msg.Parent = s.Head
+	s.Nodes = append(s.Nodes, msg)
+	s.Messages = append(s.Messages, msg)
+	s.Head = msg.ID
+}
+
+// AddNode adds msg as a continuation of the message parent, or as a first
+// message when parent is 0, and returns its ID. The active branch doesn't
+// change; see SetHead. It does not save.
+func (s *Session) AddNode(msg SessionMessage, parent int) int {
+	msg.ID = s.nextID()
+	msg.Parent = parent
+	s.Nodes = append(s.Nodes, msg)
+	return msg.ID
+}
+
+// SetMessages replaces the conversation, including every branch, with a
+// single branch holding messages. It does not save.
+func (s *Session) SetMessages(messages []SessionMessage) {
+	s.Nodes, s.Messages, s.Head = nil, nil, 0
+	s.maxID, s.scannedIDs = 0, 0
+	for _, msg := range messages {
+		s.appendNode(msg)
+	}
+}
+
+// node returns the message with the given ID, or nil.
+func (s *Session) node(id int) *SessionMessage {
+	for i := range s.Nodes {
+		if s.Nodes[i].ID == id {
+			return &s.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// pathTo returns the messages from the start of the conversation to the
+// message with the given ID.
+func (s *Session) pathTo(id int) []SessionMessage {
+	var path []SessionMessage
+	// Bounded by the node count in case a hand-edited file has a cycle
+	for id != 0 && len(path) <= len(s.Nodes) {
+		node := s.node(id)
+		if node == nil {
+			break
+		}
+		path = append(path, *node)
+		id = node.Parent
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// activePath returns the active branch. A head that no longer exists falls
+// back to the most recent message.
+func (s *Session) activePath() []SessionMessage {
+	if s.Head != 0 && s.node(s.Head) == nil && len(s.Nodes) > 0 {
+		s.Head = s.Nodes[len(s.Nodes)-1].ID
+	}
+	return s.pathTo(s.Head)
+}
+
+// TruncateMessages moves the head back so the active branch keeps only its
+// first n messages, and saves. The dropped messages remain in the tree as a
+// separate branch.
+func (s *Session) TruncateMessages(n int) error {
+	s.truncate(n)
+	return s.Save()
+}
+
+// RemoveMessages drops the active branch's messages from index n on and
+// saves. Dropped messages that other branches continue from are kept.
+func (s *Session) RemoveMessages(n int) error {
+	dropped := s.truncate(n)
+
+	// Remove from the end while the message has no remaining children
+	removed := make(map[int]bool)
+	for i := len(dropped) - 1; i >= 0; i-- {
+		if s.hasChild(dropped[i].ID, removed) {
+			break
+		}
+		removed[dropped[i].ID] = true
+	}
+	s.Nodes = slices.DeleteFunc(s.Nodes, func(node SessionMessage) bool {
+		return removed[node.ID]
+	})
+	return s.Save()
+}
+
+// truncate moves the head back to the active branch's n-th message and
+// returns the messages that were after it.
+func (s *Session) truncate(n int) []SessionMessage {
+	if n >= len(s.Messages) {
+		return nil
+	}
+	dropped := s.Messages[n:]
+	s.Head = 0
+	if n > 0 {
+		s.Head = s.Messages[n-1].ID
+	}
+	s.Messages = s.Messages[:n:n]
+	return dropped
+}
+
+// hasChild reports whether any message not in skip follows the message id.
+func (s *Session) hasChild(id int, skip map[int]bool) bool {
+	for _, node := range s.Nodes {
+		if node.Parent == id && !skip[node.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+// Branches returns the branches of the conversation in the order they were
+// started. Each message without a continuation ends a branch, as does the
+// active head. An empty active branch, started before the first message,
+// comes last with a Head of 0.
+func (s *Session) Branches() []SessionBranch {
+	active := make(map[int]bool, len(s.Messages))
+	for _, msg := range s.Messages {
+		active[msg.ID] = true
+	}
+
+	var branches []SessionBranch
+	for _, node := range s.Nodes {
+		isHead := node.ID == s.Head
+		if !isHead && s.hasChild(node.ID, nil) {
+			continue
+		}
+		path := s.pathTo(node.ID)
+		branch := SessionBranch{Head: node.ID, Length: len(path), Active: isHead}
+		if isHead {
+			for i := len(path) - 1; i >= 0; i-- {
+				if path[i].Role == "user" {
+					branch.Preview = previewText(path[i].Content)
+					break
+				}
+			}
+		} else {
+			for _, msg := range path {
+				if !active[msg.ID] {
+					branch.Preview = previewText(msg.Content)
+					break
+				}
+			}
+		}
+		branches = append(branches, branch)
+	}
+	if s.Head == 0 && len(s.Nodes) > 0 {
+		branches = append(branches, SessionBranch{Active: true})
+	}
+	return branches
+}
+
+// SwitchBranch makes the branch ending in the message head active and saves.
+func (s *Session) SwitchBranch(head int) error {
+	if err := s.SetHead(head); err != nil {
+		return err
+	}
+	return s.Save()
+}
+
+// Compact starts a new branch holding summary followed by copies of the
+// last keep messages of the active branch, and makes it active. The full
+// conversation stays on its own branch. It does not save.
+func (s *Session) Compact(summary SessionMessage, keep int) {
+	kept := s.Messages[max(len(s.Messages)-keep, 0):]
+	head := s.AddNode(summary, 0)
+	for _, msg := range kept {
+		head = s.AddNode(msg, head)
+	}
+	s.Head = head
+	s.Messages = s.activePath()
+}
+
+// SetHead makes the branch ending in the message head active without
+// saving. A head of 0 makes an empty branch active.
+func (s *Session) SetHead(head int) error {
+	if head != 0 && s.node(head) == nil {
+		return fmt.Errorf("no message %d in session %s", head, s.ID)
+	}
+	s.Head = head
+	s.Messages = s.activePath()
+	return nil
+}
+
+// Fork returns a new, unsaved session holding a copy of the conversation,
+// including every branch, the models, the system prompt and the context
+// strategy.
+func (s *Session) Fork() *Session {
+	fork := NewSession()
+	fork.Model = s.Model
+	fork.Models = slices.Clone(s.Models)
+	fork.SystemPrompt = s.SystemPrompt
+	fork.ContextStrategy = s.ContextStrategy
+	fork.History = append(fork.History, s.History...)
+	fork.Nodes = slices.Clone(s.Nodes)
+	fork.Head = s.Head
+	fork.Messages = fork.activePath()
+	return fork
+}
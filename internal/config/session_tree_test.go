@@ -0,0 +1,223 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// contents returns the content of each message.
+func contents(messages []SessionMessage) []string {
+	var out []string
+	for _, msg := range messages {
+		out = append(out, msg.Content)
+	}
+	return out
+}
+
+func TestSessionBranches(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	s := NewSession()
+	for _, content := range []string{"q1", "a1", "q2", "a2"} {
+		if err := s.AppendMessage("user", content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Editing q2 keeps the old continuation as a branch
+	if err := s.TruncateMessages(2); err != nil {
+		t.Fatal(err)
+	}
+	s.AppendMessage("user", "q2 edited")
+	s.AppendMessage("assistant", "a2 edited")
+
+	if got, want := contents(s.Messages), []string{"q1", "a1", "q2 edited", "a2 edited"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Messages = %v, want %v", got, want)
+	}
+	branches := s.Branches()
+	if len(branches) != 2 {
+		t.Fatalf("Branches() = %+v, want 2", branches)
+	}
+	if branches[0].Active || branches[0].Preview != "q2" || branches[0].Length != 4 {
+		t.Errorf("old branch = %+v, want inactive, forked at q2", branches[0])
+	}
+	if !branches[1].Active || branches[1].Preview != "q2 edited" {
+		t.Errorf("new branch = %+v, want active", branches[1])
+	}
+
+	// Switching is saved and survives a reload
+	if err := s.SwitchBranch(branches[0].Head); err != nil {
+		t.Fatalf("SwitchBranch() error = %v", err)
+	}
+	loaded, err := LoadSession(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := contents(loaded.Messages), []string{"q1", "a1", "q2", "a2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded Messages = %v, want %v", got, want)
+	}
+	if len(loaded.Nodes) != 6 {
+		t.Errorf("len(Nodes) = %d, want 6", len(loaded.Nodes))
+	}
+	if err := s.SwitchBranch(99); err == nil {
+		t.Error("SwitchBranch() to a missing message should fail")
+	}
+}
+
+func TestSessionBranchesEmptyActive(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	s := NewSession()
+	s.SetMessages([]SessionMessage{{Role: "user", Content: "q1"}, {Role: "assistant", Content: "a1"}})
+
+	// A branch started before the first message is listed as active
+	if err := s.TruncateMessages(0); err != nil {
+		t.Fatal(err)
+	}
+	branches := s.Branches()
+	if len(branches) != 2 || branches[0].Active || !branches[1].Active || branches[1].Length != 0 {
+		t.Fatalf("Branches() = %+v, want the old branch and an empty active one", branches)
+	}
+
+	// Switching back and forth works
+	if err := s.SwitchBranch(branches[0].Head); err != nil || len(s.Messages) != 2 {
+		t.Fatalf("SwitchBranch(%d) = %v, %d messages; want 2", branches[0].Head, err, len(s.Messages))
+	}
+	if err := s.SwitchBranch(0); err != nil || len(s.Messages) != 0 {
+		t.Fatalf("SwitchBranch(0) = %v, %d messages; want none", err, len(s.Messages))
+	}
+}
+
+func TestSessionNextID(t *testing.T) {
+	s := NewSession()
+	s.SetMessages([]SessionMessage{{Role: "user", Content: "q1"}, {Role: "assistant", Content: "a1"}})
+	s.Nodes = append(s.Nodes, SessionMessage{ID: 10, Parent: 2, Role: "user", Content: "journaled"})
+	if got := s.AddNode(SessionMessage{Role: "assistant"}, 10); got != 11 {
+		t.Errorf("AddNode() after a node added directly = %d, want 11", got)
+	}
+
+	// IDs of removed messages aren't reused
+	s.Nodes = s.Nodes[:2]
+	if got := s.AddNode(SessionMessage{Role: "user"}, 2); got != 12 {
+		t.Errorf("AddNode() after removing nodes = %d, want 12", got)
+	}
+}
+
+func TestSessionRemoveMessages(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	s := NewSession()
+	s.SetMessages([]SessionMessage{
+		{Role: "user", Content: "q1"},
+		{Role: "assistant", Content: "a1"},
+		{Role: "user", Content: "q2"},
+		{Role: "assistant", Content: "a2"},
+	})
+	// A retry of q2 leaves two answers to it
+	s.TruncateMessages(3)
+	s.AppendMessage("assistant", "a2 retried")
+
+	// Undoing the exchange keeps q2, which the other answer follows
+	if err := s.RemoveMessages(2); err != nil {
+		t.Fatalf("RemoveMessages() error = %v", err)
+	}
+	if got, want := contents(s.Messages), []string{"q1", "a1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Messages = %v, want %v", got, want)
+	}
+	if got, want := contents(s.Nodes), []string{"q1", "a1", "q2", "a2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Nodes = %v, want %v", got, want)
+	}
+
+	// With no other branches, everything after the head goes
+	s.SwitchBranch(4)
+	s.RemoveMessages(2)
+	if got, want := contents(s.Nodes), []string{"q1", "a1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Nodes = %v, want %v", got, want)
+	}
+}
+
+func TestSessionFork(t *testing.T) {
+	s := NewSession()
+	s.SetModelChain([]string{"a/model", "b/model"})
+	s.SystemPrompt = "Be brief."
+	s.History = []string{"q1"}
+	s.SetMessages([]SessionMessage{{Role: "user", Content: "q1"}, {Role: "assistant", Content: "a1"}})
+
+	fork := s.Fork()
+	if fork.ID == s.ID {
+		t.Error("fork should have a new ID")
+	}
+	if !reflect.DeepEqual(fork.ModelChain(), s.ModelChain()) || fork.SystemPrompt != s.SystemPrompt {
+		t.Errorf("fork = %+v, want the same models and system prompt", fork)
+	}
+	if got, want := contents(fork.Messages), []string{"q1", "a1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fork Messages = %v, want %v", got, want)
+	}
+
+	// Changes to the fork leave the original alone
+	fork.appendNode(SessionMessage{Role: "user", Content: "q2"})
+	fork.History[0] = "changed"
+	if len(s.Nodes) != 2 || s.History[0] != "q1" {
+		t.Error("changing the fork modified the original")
+	}
+}
+
+func TestSessionCompact(t *testing.T) {
+	s := NewSession()
+	s.SetMessages([]SessionMessage{
+		{Role: "user", Content: "q1"}, {Role: "assistant", Content: "a1"},
+		{Role: "user", Content: "q2"}, {Role: "assistant", Content: "a2"},
+	})
+	full := s.Head
+
+	s.Compact(SessionMessage{Role: "system", Content: "summary"}, 2)
+	if got, want := contents(s.Messages), []string{"summary", "q2", "a2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Messages = %v, want %v", got, want)
+	}
+	if err := s.SetHead(full); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(s.Messages); got != 4 {
+		t.Errorf("full conversation has %d messages, want 4", got)
+	}
+}
+
+func TestSessionAddNode(t *testing.T) {
+	_, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	s := NewSession()
+	q := s.AddNode(SessionMessage{Role: "user", Content: "q"}, 0)
+	a1 := s.AddNode(SessionMessage{Role: "assistant", Content: "a1"}, q)
+	s.AddNode(SessionMessage{Role: "assistant", Content: "a2"}, q)
+	if len(s.Messages) != 0 {
+		t.Errorf("AddNode() changed the active branch: %v", contents(s.Messages))
+	}
+	if err := s.SetHead(a1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := contents(s.Messages), []string{"q", "a1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Messages = %v, want %v", got, want)
+	}
+	if err := s.SetHead(99); err == nil {
+		t.Error("SetHead(99) succeeded")
+	}
+
+	// Imported sessions keep their timestamps
+	updated := s.UpdatedAt.Add(-24 * time.Hour).Truncate(time.Second)
+	s.UpdatedAt = updated
+	if err := s.SaveImported(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSession(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.UpdatedAt.Equal(updated) || len(loaded.Branches()) != 2 {
+		t.Errorf("UpdatedAt = %v, want %v; branches = %d", loaded.UpdatedAt, updated, len(loaded.Branches()))
+	}
+}
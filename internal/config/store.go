@@ -0,0 +1,582 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Files kept per session in the sessions directory.
+const (
+	sessionExt = ".json"
+	journalExt = ".journal"
+	lockExt    = ".lock"
+)
+
+// SessionStore persists sessions. The package-level session functions and
+// Session methods use DefaultStore.
+type SessionStore interface {
+	// Load reads a session. The error wraps ErrSessionNotFound if there is
+	// no session with that ID.
+	Load(id string) (*Session, error)
+	// Save writes a session as is; Session.Save also updates UpdatedAt.
+	// Writes fail with ErrSessionLocked if another process has the session
+	// open, and with ErrSessionChanged if another process changed it since
+	// it was loaded. While this process has the session locked, the last
+	// write wins.
+	Save(s *Session) error
+	// AppendMessage records the message just added to the end of the
+	// session's active branch, along with any other changes since the
+	// session was last written.
+	AppendMessage(s *Session, msg SessionMessage) error
+	// AppendHistory records the entry just added to the session's input
+	// history, like AppendMessage.
+	AppendHistory(s *Session, entry string) error
+	// Delete removes a session. The error wraps ErrSessionNotFound if
+	// there is no session with that ID.
+	Delete(id string) error
+	// IDs returns the IDs of all sessions, in no particular order.
+	IDs() ([]string, error)
+	// List returns a page of session summaries, most recently updated
+	// first. Sessions that can't be read are left out.
+	List(opts ListOptions) (SessionPage, error)
+	// Search finds sessions by their content; see SearchSessions.
+	Search(query string, opts SearchOptions) ([]SearchResult, error)
+	// Texts returns the message text of each session's active branch by
+	// session ID.
+	Texts() (map[string]string, error)
+	// Lock keeps other processes from writing a session until Unlock. It
+	// fails with ErrSessionLocked if another process has it locked.
+	Lock(id string) error
+	// Unlock releases a lock taken with Lock.
+	Unlock(id string) error
+}
+
+// ListOptions selects a page of sessions. The zero value lists every
+// session with messages.
+type ListOptions struct {
+	IncludeEmpty bool                      // Include sessions without messages
+	Filter       func(SessionSummary) bool // If set, only sessions it returns true for
+	Offset       int                       // Skip this many sessions
+	Limit        int                       // Return at most this many sessions, 0 for all
+}
+
+// SessionPage is a page of session summaries. Total counts the sessions
+// matching the options on every page.
+type SessionPage struct {
+	Sessions []SessionSummary
+	Total    int
+}
+
+// DefaultStore is where sessions are kept.
+// This is a variable to allow mocking in tests.
+var DefaultStore SessionStore = &JSONStore{}
+
+// JSONStore keeps each session in a JSON file named by its ID. Summaries and
+// message text are also kept in indexes under the index subdirectory, so
+// listing and searching don't parse every session file. Writes update the
+// indexes by appending to a log, so their cost doesn't grow with the number
+// of sessions; listing and searching fold the log into the index files.
+// Index entries record the size and modification time of the files they
+// were built from, and files changed behind the store's back, for example
+// by a process racing on an index, are reindexed the next time they are
+// needed.
+//
+// Session files are replaced whole through a temporary file, so a crash
+// never leaves one partly written. Messages and history entries are
+// appended to a journal file next to the session file instead of rewriting
+// it, and the journal is folded into the file once it outgrows it. Each
+// write takes the session's lock file, which a chat holds for as long as the
+// session is open.
+type JSONStore struct {
+	// Dir is the sessions directory; GetSessionDir is used when empty
+	Dir string
+
+	mu    sync.Mutex
+	locks map[string]*os.File // Sessions locked with Lock; nil until first saved
+}
+
+// dir returns the sessions directory.
+func (st *JSONStore) dir() (string, error) {
+	if st.Dir != "" {
+		return st.Dir, nil
+	}
+	return GetSessionDir()
+}
+
+// path returns the path of a session file.
+func (st *JSONStore) path(id string) (string, error) {
+	return st.file(id, sessionExt)
+}
+
+// file returns the path of one of a session's files. The ID is validated so
+// it can't escape the sessions directory.
+func (st *JSONStore) file(id, ext string) (string, error) {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return "", fmt.Errorf("invalid session ID %q", id)
+	}
+	dir, err := st.dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, id+ext), nil
+}
+
+// Load reads a session file and applies its journal, upgrading older
+// formats. A file cut short by a crash in an older version is recovered up
+// to the damage; see Session.Recovered.
+func (st *JSONStore) Load(id string) (*Session, error) {
+	sessionPath, err := st.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stamped before reading, so a change made meanwhile is seen as one
+	stamp, ok, err := st.stamp(id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	data, err := os.ReadFile(sessionPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		repaired, ok := repairJSON(data)
+		if !ok || json.Unmarshal(repaired, &session) != nil {
+			return nil, fmt.Errorf("failed to parse session file: %w", err)
+		}
+		data = repaired
+		session.recovered = true
+	}
+	if err := session.migrate(data); err != nil {
+		return nil, err
+	}
+	if err := st.replayJournal(&session); err != nil {
+		return nil, err
+	}
+	if session.recovered {
+		// The end of the file, naming the active branch, may be lost
+		session.ID = id
+		if session.node(session.Head) == nil && len(session.Nodes) > 0 {
+			session.Head = session.Nodes[len(session.Nodes)-1].ID
+		}
+	}
+	session.Messages = session.activePath()
+	session.stamp = stamp
+	session.savedHeader = session.headerJSON()
+
+	return &session, nil
+}
+
+// Save writes the session file in the current format and updates the
+// indexes.
+func (st *JSONStore) Save(s *Session) error {
+	return st.withLock(s.ID, func() error {
+		if err := st.checkUnchanged(s); err != nil {
+			return err
+		}
+		return st.save(s)
+	})
+}
+
+// save writes the session file, which then includes every journaled
+// change, and removes the journal. The lock must be held.
+func (st *JSONStore) save(s *Session) error {
+	sessionPath, err := st.path(s.ID)
+	if err != nil {
+		return err
+	}
+	journalPath, err := st.file(s.ID, journalExt)
+	if err != nil {
+		return err
+	}
+
+	s.Version = SessionVersion
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	if err := writeFileAtomic(sessionPath, data); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	// Records left behind by a crash here are skipped by their Seq
+	if err := os.Remove(journalPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove session journal: %w", err)
+	}
+
+	s.needsSave = false
+	s.recovered = false
+	s.savedHeader = s.headerJSON()
+	st.written(s)
+	return nil
+}
+
+// written records the state of a session's files after writing the session
+// file, and indexes it.
+func (st *JSONStore) written(s *Session) {
+	if st.restamp(s) {
+		st.indexWritten(s)
+	}
+}
+
+// restamp records the state of a session's files after writing them. It
+// reports false if they can't be read.
+func (st *JSONStore) restamp(s *Session) bool {
+	stamp, ok, err := st.stamp(s.ID)
+	if err != nil || !ok {
+		return false
+	}
+	s.stamp = stamp
+	return true
+}
+
+// checkUnchanged fails with ErrSessionChanged if the session's files were
+// changed since it was loaded or last written. A session locked with Lock
+// can only have been changed through another Session value in this
+// process; the whole session is written then, so the last write wins. The
+// lock must be held.
+func (st *JSONStore) checkUnchanged(s *Session) error {
+	stamp, ok, err := st.stamp(s.ID)
+	if err != nil {
+		return err
+	}
+	if !ok || stamp.equal(s.stamp) {
+		return nil
+	}
+	if st.locks[s.ID] != nil {
+		s.needsSave = true
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrSessionChanged, s.ID)
+}
+
+// AppendMessage records a message in the session's journal.
+func (st *JSONStore) AppendMessage(s *Session, msg SessionMessage) error {
+	return st.appendJournal(s, journalRecord{Message: &msg})
+}
+
+// AppendHistory records an input history entry in the session's journal.
+func (st *JSONStore) AppendHistory(s *Session, entry string) error {
+	return st.appendJournal(s, journalRecord{History: &entry})
+}
+
+// Delete removes a session's files and its index entries. Attachments it
+// referenced stay in the blob store until PruneBlobs finds them
+// unreferenced.
+func (st *JSONStore) Delete(id string) error {
+	sessionPath, err := st.path(id)
+	if err != nil {
+		return err
+	}
+	journalPath, err := st.file(id, journalExt)
+	if err != nil {
+		return err
+	}
+	return st.withLock(id, func() error {
+		if err := os.Remove(sessionPath); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+			}
+			return fmt.Errorf("failed to delete session file: %w", err)
+		}
+		os.Remove(journalPath)
+		st.indexRemoved(id)
+		if f := st.locks[id]; f != nil {
+			lockPath, _ := st.file(id, lockExt)
+			releaseLock(lockPath, f)
+		}
+		delete(st.locks, id)
+		return nil
+	})
+}
+
+// Lock takes the session's lock file and holds it until Unlock. A session
+// that hasn't been saved yet is locked when it first is.
+func (st *JSONStore) Lock(id string) error {
+	sessionPath, err := st.path(id)
+	if err != nil {
+		return err
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, ok := st.locks[id]; ok {
+		return nil
+	}
+	if st.locks == nil {
+		st.locks = map[string]*os.File{}
+	}
+	if _, err := os.Stat(sessionPath); os.IsNotExist(err) {
+		st.locks[id] = nil
+		return nil
+	}
+	f, err := st.acquire(id)
+	if err != nil {
+		return err
+	}
+	st.locks[id] = f
+	return nil
+}
+
+// Unlock releases the session's lock file.
+func (st *JSONStore) Unlock(id string) error {
+	lockPath, err := st.file(id, lockExt)
+	if err != nil {
+		return err
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if f := st.locks[id]; f != nil {
+		releaseLock(lockPath, f)
+	}
+	delete(st.locks, id)
+	return nil
+}
+
+// withLock runs write with the session's lock file held, taking it just for
+// the write unless the session is locked with Lock. write runs with st.mu
+// held.
+func (st *JSONStore) withLock(id string, write func() error) error {
+	lockPath, err := st.file(id, lockExt)
+	if err != nil {
+		return err
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.locks[id] != nil {
+		return write()
+	}
+
+	f, err := st.acquire(id)
+	if err != nil {
+		return err
+	}
+	if _, ok := st.locks[id]; ok {
+		// Locked before the session was first saved; keep it locked
+		st.locks[id] = f
+	} else {
+		defer releaseLock(lockPath, f)
+	}
+	return write()
+}
+
+// acquire takes the session's lock file, creating the sessions directory if
+// needed.
+func (st *JSONStore) acquire(id string) (*os.File, error) {
+	lockPath, err := st.file(id, lockExt)
+	if err != nil {
+		return nil, err
+	}
+	// Create sessions directory with user-only permissions
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create sessions directory: %w", err)
+	}
+	f, err := acquireLock(lockPath)
+	if errors.Is(err, errLocked) {
+		if pid := lockHolder(lockPath); pid != "" {
+			return nil, fmt.Errorf("%w: %s (process %s)", ErrSessionLocked, id, pid)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrSessionLocked, id)
+	}
+	return f, err
+}
+
+// IDs returns the IDs of the session files in the sessions directory.
+func (st *JSONStore) IDs() ([]string, error) {
+	entries, err := st.entries()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), sessionExt); ok {
+			ids = append(ids, name)
+		}
+	}
+	return ids, nil
+}
+
+// entries returns the directory entries of the session files and their
+// journals.
+func (st *JSONStore) entries() ([]os.DirEntry, error) {
+	dir, err := st.dir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read sessions directory: %w", err)
+	}
+
+	var files []os.DirEntry
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, sessionExt) && !strings.HasSuffix(name, journalExt) {
+			continue
+		}
+		files = append(files, entry)
+	}
+	return files, nil
+}
+
+// files returns the stamp of each session's files by session ID. Reading
+// it costs a directory listing, not a read of each file.
+func (st *JSONStore) files() (map[string]fileStamp, error) {
+	entries, err := st.entries()
+	if err != nil {
+		return nil, err
+	}
+	stamps := make(map[string]fileStamp, len(entries))
+	journals := map[string]os.FileInfo{}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			// Deleted since the directory was read
+			continue
+		}
+		if id, ok := strings.CutSuffix(entry.Name(), journalExt); ok {
+			journals[id] = info
+		} else {
+			stamps[strings.TrimSuffix(entry.Name(), sessionExt)] = newFileStamp(info)
+		}
+	}
+	for id, info := range journals {
+		if stamp, ok := stamps[id]; ok {
+			stamps[id] = stamp.with(info)
+		}
+	}
+	return stamps, nil
+}
+
+// stamp returns the stamp of a session's files, or false if it has none.
+func (st *JSONStore) stamp(id string) (fileStamp, bool, error) {
+	sessionPath, err := st.path(id)
+	if err != nil {
+		return fileStamp{}, false, err
+	}
+	journalPath, err := st.file(id, journalExt)
+	if err != nil {
+		return fileStamp{}, false, err
+	}
+	info, err := os.Stat(sessionPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fileStamp{}, false, nil
+		}
+		return fileStamp{}, false, fmt.Errorf("failed to read session file: %w", err)
+	}
+	stamp := newFileStamp(info)
+	if journal, err := os.Stat(journalPath); err == nil {
+		stamp = stamp.with(journal)
+	}
+	return stamp, true, nil
+}
+
+// List returns a page of summaries from the summary index, which is
+// brought up to date first. Its cost depends on the number of sessions, not
+// their length.
+func (st *JSONStore) List(opts ListOptions) (SessionPage, error) {
+	idx, err := st.currentSummaryIndex()
+	if err != nil {
+		return SessionPage{}, err
+	}
+
+	var summaries []SessionSummary
+	for _, entry := range idx.Sessions {
+		if entry.Summary.Nodes == 0 && !opts.IncludeEmpty {
+			continue
+		}
+		if opts.Filter != nil && !opts.Filter(entry.Summary) {
+			continue
+		}
+		summaries = append(summaries, entry.Summary)
+	}
+
+	// Sort by UpdatedAt descending (most recent first)
+	sort.Slice(summaries, func(i, j int) bool {
+		if !summaries[i].UpdatedAt.Equal(summaries[j].UpdatedAt) {
+			return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt)
+		}
+		return summaries[i].ID < summaries[j].ID
+	})
+
+	page := SessionPage{Total: len(summaries)}
+	start := min(max(opts.Offset, 0), len(summaries))
+	end := len(summaries)
+	if opts.Limit > 0 {
+		end = min(start+opts.Limit, end)
+	}
+	page.Sessions = summaries[start:end]
+	return page, nil
+}
+
+// Search searches the message text in the search index, which is brought
+// up to date first.
+func (st *JSONStore) Search(query string, opts SearchOptions) ([]SearchResult, error) {
+	terms := parseQuery(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("empty search query")
+	}
+	idx, err := st.currentSearchIndex()
+	if err != nil {
+		return nil, err
+	}
+	return search(idx.Sessions, terms, opts), nil
+}
+
+// Texts returns the message text of each session from the search index.
+func (st *JSONStore) Texts() (map[string]string, error) {
+	idx, err := st.currentSearchIndex()
+	if err != nil {
+		return nil, err
+	}
+	texts := make(map[string]string, len(idx.Sessions))
+	for id, entry := range idx.Sessions {
+		var text []string
+		for _, msg := range entry.Messages {
+			text = append(text, msg.Text)
+		}
+		texts[id] = strings.Join(text, "\n")
+	}
+	return texts, nil
+}
+
+// writeFileAtomic replaces the file at path with data through a temporary
+// file, so readers and a crash see either the old or the new content.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
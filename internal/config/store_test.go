@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestStore returns a store in a temporary directory.
+func newTestStore(t testing.TB) *JSONStore {
+	t.Helper()
+	return &JSONStore{Dir: filepath.Join(t.TempDir(), "sessions")}
+}
+
+// saveTestSessions saves n sessions with the given number of messages each
+// to store, updated a minute apart with the first most recent.
+func saveTestSessions(t testing.TB, store *JSONStore, n, messages int) []*Session {
+	t.Helper()
+	now := time.Now()
+	content := strings.Repeat("A longer message to make the session files bigger. ", 10)
+	sessions := make([]*Session, n)
+	for i := range sessions {
+		s := NewSession()
+		for j := range messages {
+			s.appendNode(SessionMessage{Role: "user", Content: fmt.Sprintf("Session %d message %d. %s", i, j, content)})
+		}
+		s.UpdatedAt = now.Add(-time.Duration(i) * time.Minute)
+		if err := store.Save(s); err != nil {
+			t.Fatal(err)
+		}
+		sessions[i] = s
+	}
+	return sessions
+}
+
+func TestJSONStoreList(t *testing.T) {
+	store := newTestStore(t)
+	sessions := saveTestSessions(t, store, 5, 1)
+	empty := NewSession()
+	if err := store.Save(empty); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(page SessionPage) []string {
+		var ids []string
+		for _, s := range page.Sessions {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+	tests := []struct {
+		name      string
+		opts      ListOptions
+		want      []*Session
+		wantTotal int
+	}{
+		{name: "all", opts: ListOptions{}, want: sessions, wantTotal: 5},
+		{name: "first page", opts: ListOptions{Limit: 2}, want: sessions[:2], wantTotal: 5},
+		{name: "last page", opts: ListOptions{Offset: 4, Limit: 2}, want: sessions[4:], wantTotal: 5},
+		{name: "past the end", opts: ListOptions{Offset: 10, Limit: 2}, want: nil, wantTotal: 5},
+		{name: "empty included", opts: ListOptions{IncludeEmpty: true, Limit: 1}, want: []*Session{empty}, wantTotal: 6},
+		{
+			name:      "filter",
+			opts:      ListOptions{Filter: func(s SessionSummary) bool { return strings.HasPrefix(s.Preview, "Session 3") }},
+			want:      sessions[3:4],
+			wantTotal: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := store.List(tt.opts)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			var want []string
+			for _, s := range tt.want {
+				want = append(want, s.ID)
+			}
+			if got := ids(page); strings.Join(got, ",") != strings.Join(want, ",") || page.Total != tt.wantTotal {
+				t.Errorf("List() = %v of %d, want %v of %d", got, page.Total, want, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestJSONStoreListUsesIndex(t *testing.T) {
+	store := newTestStore(t)
+	sessions := saveTestSessions(t, store, 2, 3)
+
+	// Unchanged files are listed from the index without being read: a file
+	// overwritten with junk of the same size and time still lists
+	path, err := store.path(sessions[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", int(info.Size()))), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	page, err := store.List(ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 {
+		t.Errorf("List() found %d sessions, want 2 from the index", page.Total)
+	}
+
+	// Files changed behind the index's back are reindexed, and unreadable
+	// ones left out
+	if err := os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	other := sessions[1]
+	other.appendNode(SessionMessage{Role: "user", Content: "Changed elsewhere"})
+	elsewhere := &JSONStore{Dir: t.TempDir()}
+	if err := elsewhere.Save(other); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(elsewhere.Dir, other.ID+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(store.Dir, other.ID+".json"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	page, err = store.List(ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || page.Sessions[0].MessageCount != 4 {
+		t.Errorf("List() = %+v, want only the changed session with 4 messages", page.Sessions)
+	}
+
+	// An unreadable file isn't read again until it changes
+	idx, err := loadIndex[*indexedSummary](store, summaryIndexName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := idx.refresh(store, newIndexedSummary); err != nil || changed {
+		t.Errorf("refresh() = %v, %v; want the unreadable file skipped", changed, err)
+	}
+	if err := elsewhere.Save(sessions[0]); err != nil {
+		t.Fatal(err)
+	}
+	if data, err = os.ReadFile(filepath.Join(elsewhere.Dir, sessions[0].ID+".json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if page, err = store.List(ListOptions{}); err != nil || page.Total != 2 {
+		t.Errorf("List() after repairing the file = %d sessions, %v; want 2", page.Total, err)
+	}
+}
+
+// BenchmarkJSONStoreList lists 100 sessions of increasing length. With the
+// summary index, the time per listing stays about the same however long
+// the sessions are.
+func BenchmarkJSONStoreList(b *testing.B) {
+	for _, messages := range []int{10, 1000} {
+		b.Run(fmt.Sprintf("messages=%d", messages), func(b *testing.B) {
+			store := newTestStore(b)
+			saveTestSessions(b, store, 100, messages)
+			if _, err := store.List(ListOptions{}); err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for b.Loop() {
+				if _, err := store.List(ListOptions{Limit: 50}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkJSONStoreAppend appends to a session among an increasing number
+// of others. Writes only append to the indexes' logs, so the time per
+// append stays about the same however many sessions there are.
+func BenchmarkJSONStoreAppend(b *testing.B) {
+	for _, sessions := range []int{0, 200} {
+		b.Run(fmt.Sprintf("sessions=%d", sessions), func(b *testing.B) {
+			store := newTestStore(b)
+			saveTestSessions(b, store, sessions, 50)
+			if _, err := store.Search("message", SearchOptions{}); err != nil {
+				b.Fatal(err)
+			}
+			s := saveTestSessions(b, store, 1, 1)[0]
+			for b.Loop() {
+				s.appendNode(SessionMessage{Role: "user", Content: "Another message"})
+				if err := store.AppendMessage(s, s.Nodes[len(s.Nodes)-1]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
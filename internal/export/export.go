@@ -0,0 +1,259 @@
+// Package export renders saved chat sessions as shareable transcripts.
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/config"
+	"github.com/vstratful/openrouter-cli/internal/tui"
+)
+
+// Export formats.
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+	FormatText     = "txt"
+)
+
+// Formats lists the export formats, default first.
+var Formats = []string{FormatMarkdown, FormatHTML, FormatJSON, FormatText}
+
+// TranscriptFormat identifies the JSON export format, so it can be told apart
+// from other JSON chat exports when importing.
+const TranscriptFormat = "openrouter-cli-transcript"
+
+// TranscriptVersion is the current JSON export format version.
+const TranscriptVersion = 1
+
+// timeLayout is how timestamps are shown in transcripts.
+const timeLayout = "2006-01-02 15:04 MST"
+
+// Transcript is the active branch of a session prepared for export. Its JSON
+// form is the json export format.
+type Transcript struct {
+	Format       string     `json:"format"`
+	Version      int        `json:"version"`
+	ID           string     `json:"id"`
+	Title        string     `json:"title,omitempty"`
+	Models       []string   `json:"models,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	SystemPrompt string     `json:"system_prompt,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Usage        *api.Usage `json:"usage,omitempty"`
+	Messages     []Message  `json:"messages"`
+}
+
+// Message is one message of a transcript. Attachments are the files and
+// images sent with a user message and Images those generated by the model.
+type Message struct {
+	Role        string       `json:"role"`
+	Content     string       `json:"content"`
+	Reasoning   string       `json:"reasoning,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Images      []Attachment `json:"images,omitempty"`
+	Model       string       `json:"model,omitempty"`
+	Provider    string       `json:"provider,omitempty"`
+	Time        time.Time    `json:"time,omitzero"`
+	Usage       *api.Usage   `json:"usage,omitempty"`
+}
+
+// Attachment is an image or file carried in a transcript. URL is a data URL
+// for content stored with the session, so transcripts are self-contained.
+type Attachment struct {
+	Type     string `json:"type"` // "image" or "file"
+	Filename string `json:"filename,omitempty"`
+	URL      string `json:"url"`
+}
+
+// Name describes the attachment for text formats.
+func (a Attachment) Name() string {
+	if a.Filename != "" {
+		return a.Filename
+	}
+	if !api.IsDataURL(a.URL) {
+		return a.URL
+	}
+	return a.Type
+}
+
+// New builds the transcript of a session's active branch. Attachments that
+// can't be loaded from the blob store are left out and described by the
+// returned error; the transcript is usable either way.
+func New(session *config.Session) (*Transcript, error) {
+	t := &Transcript{
+		Format:       TranscriptFormat,
+		Version:      TranscriptVersion,
+		ID:           session.ID,
+		Title:        session.Title,
+		Models:       session.ModelChain(),
+		Tags:         session.Tags,
+		SystemPrompt: session.SystemPrompt,
+		CreatedAt:    session.CreatedAt,
+		UpdatedAt:    session.UpdatedAt,
+		Messages:     []Message{},
+	}
+	if usage := session.TotalUsage(); usage.TotalTokens > 0 {
+		t.Usage = &usage
+	}
+
+	// Rebuild API messages to load attachments as data URLs
+	messages, err := session.APIMessages()
+	for i, stored := range session.Messages {
+		msg := Message{
+			Role:      stored.Role,
+			Content:   stored.Content,
+			Reasoning: stored.Reasoning,
+			Model:     stored.Model,
+			Provider:  stored.Provider,
+			Time:      stored.Time,
+			Usage:     stored.Usage,
+		}
+		for _, part := range messages[i].ContentParts {
+			switch {
+			case part.ImageURL != nil:
+				msg.Attachments = append(msg.Attachments, Attachment{Type: config.SessionPartImage, URL: part.ImageURL.URL})
+			case part.File != nil:
+				msg.Attachments = append(msg.Attachments, Attachment{Type: config.SessionPartFile, Filename: part.File.Filename, URL: part.File.FileData})
+			}
+		}
+		for _, image := range messages[i].Images {
+			msg.Images = append(msg.Images, Attachment{Type: config.SessionPartImage, URL: image.ImageURL.URL})
+		}
+		t.Messages = append(t.Messages, msg)
+	}
+	return t, err
+}
+
+// ParseFormat validates an export format name. "markdown" and "text" are
+// accepted for md and txt.
+func ParseFormat(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case FormatMarkdown, "markdown":
+		return FormatMarkdown, nil
+	case FormatHTML, "htm":
+		return FormatHTML, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatText, "text":
+		return FormatText, nil
+	}
+	return "", fmt.Errorf("invalid export format %q: must be one of %s", value, strings.Join(Formats, ", "))
+}
+
+// FormatForPath picks the export format from a file extension, defaulting
+// to Markdown.
+func FormatForPath(path string) string {
+	format, err := ParseFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return FormatMarkdown
+	}
+	return format
+}
+
+// Write renders the transcript in the given format.
+func Write(w io.Writer, t *Transcript, format string) error {
+	switch format {
+	case FormatMarkdown:
+		return writeMarkdown(w, t)
+	case FormatHTML:
+		return writeHTML(w, t)
+	case FormatJSON:
+		data, err := json.MarshalIndent(t, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal transcript: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case FormatText:
+		return writeText(w, t)
+	}
+	return fmt.Errorf("invalid export format %q", format)
+}
+
+// DisplayTitle returns the transcript's title, or a name built from its
+// first user message.
+func (t *Transcript) DisplayTitle() string {
+	if t.Title != "" {
+		return t.Title
+	}
+	for _, msg := range t.Messages {
+		if msg.Role == "user" && msg.Content != "" {
+			title, _, _ := strings.Cut(msg.Content, "\n")
+			if len(title) > config.PreviewTruncateLength {
+				title = title[:config.PreviewTruncateLength-3] + "..."
+			}
+			return title
+		}
+	}
+	return "Chat session"
+}
+
+// speaker names who wrote a message, with the model and provider for
+// assistant messages.
+func (m Message) speaker() string {
+	switch m.Role {
+	case "user":
+		return "User"
+	case "system":
+		return "System"
+	case "assistant":
+		name := "Assistant"
+		if m.Model != "" {
+			name += " · " + m.Model
+			if m.Provider != "" {
+				name += " via " + m.Provider
+			}
+		}
+		return name
+	}
+	return m.Role
+}
+
+// formatUsage summarizes token usage and cost on one line.
+func formatUsage(usage *api.Usage) string {
+	return fmt.Sprintf("%d tokens (%d prompt, %d completion), %s",
+		usage.TotalTokens, usage.PromptTokens, usage.CompletionTokens, tui.FormatCost(usage.Cost))
+}
+
+// details returns the transcript's metadata as label and value pairs.
+func (t *Transcript) details() [][2]string {
+	details := [][2]string{{"Session", t.ID}}
+	if len(t.Models) > 0 {
+		details = append(details, [2]string{"Models", strings.Join(t.Models, ", ")})
+	}
+	if len(t.Tags) > 0 {
+		details = append(details, [2]string{"Tags", strings.Join(t.Tags, ", ")})
+	}
+	details = append(details,
+		[2]string{"Created", t.CreatedAt.Format(timeLayout)},
+		[2]string{"Updated", t.UpdatedAt.Format(timeLayout)},
+	)
+	if t.Usage != nil {
+		details = append(details, [2]string{"Usage", formatUsage(t.Usage)})
+	}
+	return details
+}
+
+// WriteFile exports a session to path, in the format its extension names.
+// Attachment errors from New are returned after the file is written.
+func WriteFile(path string, session *config.Session) error {
+	transcript, loadErr := New(session)
+	var buf bytes.Buffer
+	if err := Write(&buf, transcript, FormatForPath(path)); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return loadErr
+}
@@ -0,0 +1,192 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/config"
+)
+
+// newTestSession returns a saved session with a code block, an attached
+// image and usage.
+func newTestSession(t *testing.T) *config.Session {
+	t.Helper()
+	originalGetSessionDir := config.GetSessionDir
+	dir := t.TempDir()
+	config.GetSessionDir = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { config.GetSessionDir = originalGetSessionDir })
+
+	image, err := config.NewSessionMessage(api.Message{
+		Role:    "user",
+		Content: "What is in this <script>alert(1)</script> picture?",
+		ContentParts: []api.ContentPart{
+			{Type: "text", Text: "What is in this <script>alert(1)</script> picture?"},
+			{Type: "image_url", ImageURL: &api.ImageURL{URL: api.EncodeDataURL("image/png", []byte("png"))}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := config.NewSession()
+	session.Title = "Review"
+	session.SetModelChain([]string{"a/model"})
+	session.SystemPrompt = "Be brief."
+	for _, msg := range []config.SessionMessage{
+		image,
+		{
+			Role:      "assistant",
+			Content:   "A gopher.\n\n```go\nfunc main() {}\n```",
+			Reasoning: "Looks like Go.",
+			Model:     "a/model",
+			Provider:  "Acme",
+			Usage:     &api.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, Cost: 0.002},
+			Time:      time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC),
+		},
+	} {
+		if err := session.AppendSessionMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return session
+}
+
+func TestNew(t *testing.T) {
+	transcript, err := New(newTestSession(t))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if len(transcript.Messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(transcript.Messages))
+	}
+	user := transcript.Messages[0]
+	if len(user.Attachments) != 1 || !strings.HasPrefix(user.Attachments[0].URL, "data:image/png;base64,") {
+		t.Errorf("attachments = %+v, want the image as a data URL", user.Attachments)
+	}
+	if user.Time.IsZero() {
+		t.Error("user message has no time")
+	}
+	if transcript.Usage == nil || transcript.Usage.TotalTokens != 15 {
+		t.Errorf("Usage = %+v, want the session total", transcript.Usage)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	transcript, err := New(newTestSession(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		format  string
+		want    []string
+		notWant []string
+	}{
+		{
+			format: FormatMarkdown,
+			want: []string{
+				"# Review",
+				"- **Models:** a/model",
+				"## System\n\nBe brief.",
+				"## Assistant · a/model via Acme — 2025-03-01 12:30 UTC",
+				"```go\nfunc main() {}\n```",
+				"<summary>Reasoning</summary>",
+				"*Attached image: `image`*",
+				"*15 tokens (10 prompt, 5 completion), $0.0020*",
+			},
+		},
+		{
+			format: FormatText,
+			want: []string{
+				"Review\nSession: ",
+				"Assistant · a/model via Acme (2025-03-01 12:30 UTC):\n[Reasoning]\nLooks like Go.\n[Answer]\nA gopher.",
+				"[Attached image: image]",
+			},
+		},
+		{
+			format: FormatHTML,
+			want: []string{
+				"<title>Review</title>",
+				`<img class="attachment" src="data:image/png;base64,`,
+				`<pre style="`, // Highlighted with inline styles
+				"main",
+				"<footer>15 tokens (10 prompt, 5 completion), $0.0020</footer>",
+			},
+			notWant: []string{"<script>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, transcript, tt.format); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(buf.String(), notWant) {
+					t.Errorf("output contains %q", notWant)
+				}
+			}
+		})
+	}
+
+	t.Run(FormatJSON, func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := Write(&buf, transcript, FormatJSON); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		var decoded Transcript
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("output is not JSON: %v", err)
+		}
+		if decoded.Format != TranscriptFormat || len(decoded.Messages) != 2 || decoded.Messages[1].Model != "a/model" {
+			t.Errorf("decoded = %+v", decoded)
+		}
+	})
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "md", want: FormatMarkdown},
+		{value: "Markdown", want: FormatMarkdown},
+		{value: "html", want: FormatHTML},
+		{value: "json", want: FormatJSON},
+		{value: "text", want: FormatText},
+		{value: "pdf", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+
+	for path, want := range map[string]string{"chat.html": FormatHTML, "chat.TXT": FormatText, "chat": FormatMarkdown, "chat.log": FormatMarkdown} {
+		if got := FormatForPath(path); got != want {
+			t.Errorf("FormatForPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestAttachmentURL(t *testing.T) {
+	for url, want := range map[string]string{
+		"data:image/png;base64,AA": "data:image/png;base64,AA",
+		"https://example.com/a":    "https://example.com/a",
+		"javascript:alert(1)":      "#",
+	} {
+		if got := string(attachmentURL(url)); got != want {
+			t.Errorf("attachmentURL(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// codeStyle is the chroma style used for code blocks in HTML transcripts.
+const codeStyle = "github"
+
+// markdown converts message content to HTML. Raw HTML in the content is
+// left out, so a transcript can't carry scripts.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(
+		renderer.WithNodeRenderers(util.Prioritized(codeRenderer{}, 100)),
+	),
+)
+
+// codeRenderer renders fenced code blocks with chroma syntax highlighting,
+// using inline styles so the page needs no stylesheet.
+type codeRenderer struct{}
+
+func (r codeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderFencedCode)
+}
+
+func (r codeRenderer) renderFencedCode(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	block := node.(*ast.FencedCodeBlock)
+	var code strings.Builder
+	lines := block.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		code.Write(line.Value(source))
+	}
+
+	lexer := lexers.Get(string(block.Language(source)))
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code.String())
+	if err != nil {
+		return ast.WalkStop, err
+	}
+	formatter := chromahtml.New(chromahtml.TabWidth(4))
+	if err := formatter.Format(w, styles.Get(codeStyle), iterator); err != nil {
+		return ast.WalkStop, err
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// renderMarkdown converts Markdown to HTML for the page template.
+func renderMarkdown(text string) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(text), &buf); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// htmlMessage is a transcript message prepared for the page template.
+type htmlMessage struct {
+	Message
+	Speaker   string
+	Time      string
+	Content   template.HTML
+	Reasoning template.HTML
+	Usage     string
+}
+
+// writeHTML renders the transcript as a single self-contained HTML page,
+// with attachments embedded as data URLs.
+func writeHTML(w io.Writer, t *Transcript) error {
+	data := struct {
+		Title        string
+		Details      [][2]string
+		SystemPrompt template.HTML
+		Messages     []htmlMessage
+	}{Title: t.DisplayTitle(), Details: t.details()}
+
+	var err error
+	if t.SystemPrompt != "" {
+		if data.SystemPrompt, err = renderMarkdown(t.SystemPrompt); err != nil {
+			return err
+		}
+	}
+	for _, msg := range t.Messages {
+		m := htmlMessage{Message: msg, Speaker: msg.speaker()}
+		if !msg.Time.IsZero() {
+			m.Time = msg.Time.Format(timeLayout)
+		}
+		if m.Content, err = renderMarkdown(msg.Content); err != nil {
+			return err
+		}
+		if m.Reasoning, err = renderMarkdown(msg.Reasoning); err != nil {
+			return err
+		}
+		if msg.Usage != nil {
+			m.Usage = formatUsage(msg.Usage)
+		}
+		data.Messages = append(data.Messages, m)
+	}
+	return pageTemplate.Execute(w, data)
+}
+
+// attachmentURL allows data and web URLs as attachment links and sources.
+// html/template would otherwise reject the data URLs attachments are
+// embedded as.
+func attachmentURL(url string) template.URL {
+	for _, scheme := range []string{"data:", "https://", "http://"} {
+		if strings.HasPrefix(url, scheme) {
+			return template.URL(url)
+		}
+	}
+	return "#"
+}
+
+// pageTemplate is the layout of HTML transcripts.
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"url": attachmentURL,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.5; color: #1f2328; max-width: 860px; margin: 2rem auto; padding: 0 1rem; }
+h1 { font-size: 1.6rem; margin-bottom: 0.5rem; }
+dl.details { display: grid; grid-template-columns: max-content auto; gap: 0.1rem 1rem; color: #59636e; font-size: 0.9rem; }
+dl.details dt { font-weight: 600; }
+dl.details dd { margin: 0; }
+.message { border: 1px solid #d1d9e0; border-radius: 6px; margin: 1.25rem 0; padding: 0 1rem; }
+.message > header { display: flex; justify-content: space-between; gap: 1rem; border-bottom: 1px solid #d1d9e0; margin: 0 -1rem; padding: 0.4rem 1rem; font-weight: 600; background: #f6f8fa; border-radius: 6px 6px 0 0; }
+.message > header time { font-weight: normal; color: #59636e; font-size: 0.85rem; }
+.user > header { background: #ddf4ff; }
+.system > header { background: #fff8c5; }
+.message footer { color: #59636e; font-size: 0.85rem; margin: 0.5rem 0; }
+details.reasoning { color: #59636e; margin: 0.75rem 0; }
+details.reasoning summary { cursor: pointer; }
+pre { padding: 0.75rem; overflow-x: auto; border-radius: 6px; background: #f6f8fa; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 0.875rem; }
+:not(pre) > code { background: #eff1f3; padding: 0.1em 0.3em; border-radius: 4px; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d1d9e0; padding: 0.25rem 0.75rem; }
+img.attachment { display: block; max-width: 100%; margin: 0.75rem 0; border-radius: 4px; }
+a.attachment { display: inline-block; margin: 0.5rem 0; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<dl class="details">
+{{- range .Details}}
+<dt>{{index . 0}}</dt><dd>{{index . 1}}</dd>
+{{- end}}
+</dl>
+{{- if .SystemPrompt}}
+<section class="message system">
+<header><span>System</span></header>
+{{.SystemPrompt}}
+</section>
+{{- end}}
+{{- range .Messages}}
+<section class="message {{.Role}}">
+<header><span>{{.Speaker}}</span>{{if .Time}}<time>{{.Time}}</time>{{end}}</header>
+{{- if .Reasoning}}
+<details class="reasoning"><summary>Reasoning</summary>
+{{.Reasoning}}
+</details>
+{{- end}}
+{{.Content}}
+{{- range .Attachments}}
+{{- if eq .Type "image"}}
+<img class="attachment" src="{{url .URL}}" alt="{{.Name}}">
+{{- else}}
+<a class="attachment" href="{{url .URL}}" download="{{.Name}}">📎 {{.Name}}</a>
+{{- end}}
+{{- end}}
+{{- range .Images}}
+<img class="attachment" src="{{url .URL}}" alt="Generated image">
+{{- end}}
+{{- if .Usage}}
+<footer>{{.Usage}}</footer>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,94 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vstratful/openrouter-cli/internal/config"
+)
+
+// writeMarkdown renders the transcript as Markdown. Message content is
+// already Markdown, so it is written as is; attachments stored with the
+// session are listed by name, as data URLs don't render in most viewers.
+func writeMarkdown(w io.Writer, t *Transcript) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s\n\n", t.DisplayTitle())
+	for _, detail := range t.details() {
+		fmt.Fprintf(bw, "- **%s:** %s\n", detail[0], detail[1])
+	}
+	if t.SystemPrompt != "" {
+		fmt.Fprintf(bw, "\n## System\n\n%s\n", t.SystemPrompt)
+	}
+
+	for _, msg := range t.Messages {
+		fmt.Fprintf(bw, "\n## %s", msg.speaker())
+		if !msg.Time.IsZero() {
+			fmt.Fprintf(bw, " — %s", msg.Time.Format(timeLayout))
+		}
+		bw.WriteString("\n\n")
+		if msg.Reasoning != "" {
+			fmt.Fprintf(bw, "<details>\n<summary>Reasoning</summary>\n\n%s\n\n</details>\n\n", strings.TrimSpace(msg.Reasoning))
+		}
+		if msg.Content != "" {
+			fmt.Fprintf(bw, "%s\n", strings.TrimSpace(msg.Content))
+		}
+		for _, a := range msg.Attachments {
+			writeMarkdownAttachment(bw, "Attached", a)
+		}
+		for _, a := range msg.Images {
+			writeMarkdownAttachment(bw, "Generated", a)
+		}
+		if msg.Usage != nil {
+			fmt.Fprintf(bw, "\n*%s*\n", formatUsage(msg.Usage))
+		}
+	}
+	return bw.Flush()
+}
+
+// writeMarkdownAttachment shows a remote image inline and names anything
+// else.
+func writeMarkdownAttachment(w io.Writer, verb string, a Attachment) {
+	if a.Type == config.SessionPartImage && a.Name() == a.URL {
+		fmt.Fprintf(w, "\n![%s image](%s)\n", verb, a.URL)
+		return
+	}
+	fmt.Fprintf(w, "\n*%s %s: `%s`*\n", verb, a.Type, a.Name())
+}
+
+// writeText renders the transcript as plain text.
+func writeText(w io.Writer, t *Transcript) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s\n", t.DisplayTitle())
+	for _, detail := range t.details() {
+		fmt.Fprintf(bw, "%s: %s\n", detail[0], detail[1])
+	}
+	if t.SystemPrompt != "" {
+		fmt.Fprintf(bw, "\nSystem:\n%s\n", t.SystemPrompt)
+	}
+
+	for _, msg := range t.Messages {
+		fmt.Fprintf(bw, "\n%s", msg.speaker())
+		if !msg.Time.IsZero() {
+			fmt.Fprintf(bw, " (%s)", msg.Time.Format(timeLayout))
+		}
+		bw.WriteString(":\n")
+		if msg.Reasoning != "" {
+			fmt.Fprintf(bw, "[Reasoning]\n%s\n[Answer]\n", strings.TrimSpace(msg.Reasoning))
+		}
+		if msg.Content != "" {
+			fmt.Fprintf(bw, "%s\n", strings.TrimSpace(msg.Content))
+		}
+		for _, a := range msg.Attachments {
+			fmt.Fprintf(bw, "[Attached %s: %s]\n", a.Type, a.Name())
+		}
+		for _, a := range msg.Images {
+			fmt.Fprintf(bw, "[Generated %s: %s]\n", a.Type, a.Name())
+		}
+		if msg.Usage != nil {
+			fmt.Fprintf(bw, "[%s]\n", formatUsage(msg.Usage))
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,202 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vstratful/openrouter-cli/internal/config"
+)
+
+// chatGPTConversation is one conversation of a ChatGPT data export's
+// conversations.json. Messages form a tree in Mapping, keyed by node ID;
+// CurrentNode is the last message of the branch shown in ChatGPT.
+type chatGPTConversation struct {
+	ID             string                 `json:"id"`
+	ConversationID string                 `json:"conversation_id"`
+	Title          string                 `json:"title"`
+	CreateTime     float64                `json:"create_time"`
+	UpdateTime     float64                `json:"update_time"`
+	Mapping        map[string]chatGPTNode `json:"mapping"`
+	CurrentNode    string                 `json:"current_node"`
+}
+
+type chatGPTNode struct {
+	ID       string          `json:"id"`
+	Message  *chatGPTMessage `json:"message"`
+	Parent   string          `json:"parent"`
+	Children []string        `json:"children"`
+}
+
+type chatGPTMessage struct {
+	Author struct {
+		Role string `json:"role"`
+	} `json:"author"`
+	CreateTime float64 `json:"create_time"`
+	Content    struct {
+		ContentType string            `json:"content_type"`
+		Parts       []json.RawMessage `json:"parts"`
+	} `json:"content"`
+	Recipient string `json:"recipient"`
+	Metadata  struct {
+		ModelSlug      string `json:"model_slug"`
+		VisuallyHidden bool   `json:"is_visually_hidden_from_conversation"`
+	} `json:"metadata"`
+}
+
+// parseChatGPT converts a ChatGPT conversations.json export, or a single
+// conversation from one.
+func parseChatGPT(data []byte) ([]*config.Session, error) {
+	var conversations []chatGPTConversation
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		conversations = make([]chatGPTConversation, 1)
+		if err := json.Unmarshal(data, &conversations[0]); err != nil {
+			return nil, fmt.Errorf("failed to parse ChatGPT conversation: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &conversations); err != nil {
+		return nil, fmt.Errorf("failed to parse ChatGPT export: %w", err)
+	}
+
+	var sessions []*config.Session
+	for _, c := range conversations {
+		session, err := c.session()
+		if err != nil {
+			return nil, err
+		}
+		if len(session.Nodes) > 0 {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+// session converts the conversation, keeping every branch. Only the text
+// of user and assistant messages is kept: tool calls and their output,
+// hidden messages and generated files are left out, and uploaded images and
+// files, which the export doesn't contain, are replaced by a placeholder.
+// A mapping in which a message is reached twice, such as through a cycle,
+// is an error.
+func (c chatGPTConversation) session() (*config.Session, error) {
+	id := c.ConversationID
+	if id == "" {
+		id = c.ID
+	}
+	session := newSession(sourceChatGPT+id, unixTime(c.CreateTime), unixTime(c.UpdateTime))
+	session.Title = c.Title
+
+	// Walk the tree from its roots so parents are added before their
+	// children. Each kept message continues the nearest kept ancestor.
+	kept := make(map[string]int)
+	visited := make(map[string]bool)
+	var walk func(nodeID string, parent int) error
+	walk = func(nodeID string, parent int) error {
+		node, ok := c.Mapping[nodeID]
+		if !ok {
+			return nil
+		}
+		if visited[nodeID] {
+			return fmt.Errorf("invalid ChatGPT conversation %q: message %q is reached twice", id, nodeID)
+		}
+		visited[nodeID] = true
+		if node.Message != nil {
+			switch role, text := node.Message.role(), node.Message.text(); {
+			case role == "system" && text != "" && session.SystemPrompt == "":
+				session.SystemPrompt = text
+			case (role == "user" || role == "assistant") && text != "":
+				msg := config.SessionMessage{
+					Role:    role,
+					Content: text,
+					Time:    unixTime(node.Message.CreateTime),
+				}
+				if role == "assistant" && node.Message.Metadata.ModelSlug != "" {
+					msg.Model = "openai/" + node.Message.Metadata.ModelSlug
+				}
+				parent = session.AddNode(msg, parent)
+				kept[nodeID] = parent
+			}
+		}
+		for _, child := range node.Children {
+			if err := walk(child, parent); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, nodeID := range slices.Sorted(maps.Keys(c.Mapping)) {
+		if _, ok := c.Mapping[c.Mapping[nodeID].Parent]; !ok {
+			if err := walk(nodeID, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The active branch ends at the current node's nearest kept ancestor
+	head := 0
+	for nodeID, seen := c.CurrentNode, 0; nodeID != "" && seen <= len(c.Mapping); seen++ {
+		if id, ok := kept[nodeID]; ok {
+			head = id
+			break
+		}
+		nodeID = c.Mapping[nodeID].Parent
+	}
+	if head == 0 && len(session.Nodes) > 0 {
+		head = session.Nodes[len(session.Nodes)-1].ID
+	}
+	if head != 0 {
+		// head is always a node of the session, so this can't fail
+		_ = session.SetHead(head)
+	}
+	return session, nil
+}
+
+// role returns the message's role, or an empty string for messages that
+// aren't part of the visible conversation.
+func (m *chatGPTMessage) role() string {
+	if m.Metadata.VisuallyHidden || (m.Recipient != "" && m.Recipient != "all") {
+		return ""
+	}
+	switch m.Content.ContentType {
+	case "text", "multimodal_text":
+		return m.Author.Role
+	}
+	return ""
+}
+
+// text joins the message's text parts. Other parts, such as uploaded
+// images, are shown as placeholders.
+func (m *chatGPTMessage) text() string {
+	var text []string
+	for _, raw := range m.Content.Parts {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			if s = strings.TrimSpace(s); s != "" {
+				text = append(text, s)
+			}
+			continue
+		}
+		var part struct {
+			ContentType string `json:"content_type"`
+		}
+		if err := json.Unmarshal(raw, &part); err == nil && strings.HasPrefix(part.ContentType, "image") {
+			text = append(text, "[image]")
+		} else {
+			text = append(text, "[attachment]")
+		}
+	}
+	return strings.Join(text, "\n\n")
+}
+
+// unixTime converts ChatGPT's fractional Unix seconds, 0 when unknown.
+func unixTime(seconds float64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*1e9))
+}
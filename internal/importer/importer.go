@@ -0,0 +1,221 @@
+// Package importer converts chat histories exported by other tools, and
+// transcripts exported by this one, into sessions.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/config"
+	"github.com/vstratful/openrouter-cli/internal/export"
+)
+
+// Import formats.
+const (
+	FormatAuto       = "auto"
+	FormatChatGPT    = "chatgpt"
+	FormatOpenAI     = "openai"
+	FormatTranscript = "transcript"
+)
+
+// Formats lists the import formats, default first.
+var Formats = []string{FormatAuto, FormatChatGPT, FormatOpenAI, FormatTranscript}
+
+// Session sources, prefixed to an ID identifying the original conversation.
+const (
+	sourceChatGPT    = "chatgpt:"
+	sourceOpenAI     = "openai:"
+	sourceTranscript = "transcript:"
+)
+
+// ParseFormat validates an import format name.
+func ParseFormat(value string) (string, error) {
+	format := strings.ToLower(value)
+	for _, f := range Formats {
+		if format == f {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("invalid import format %q: must be one of %s", value, strings.Join(Formats, ", "))
+}
+
+// Parse converts the conversations in data to sessions. With FormatAuto the
+// format is detected from the content. Attachments are written to the blob
+// store, but the sessions are not saved; each has its Source set so repeated
+// imports of the same conversation can be recognized.
+func Parse(data []byte, format string) ([]*config.Session, error) {
+	if format == FormatAuto {
+		format = Detect(data)
+		if format == "" {
+			return nil, fmt.Errorf("unrecognized import format: expected a ChatGPT export, OpenAI messages or a JSON transcript")
+		}
+	}
+
+	var sessions []*config.Session
+	var err error
+	switch format {
+	case FormatChatGPT:
+		sessions, err = parseChatGPT(data)
+	case FormatOpenAI:
+		sessions, err = parseOpenAI(data)
+	case FormatTranscript:
+		sessions, err = parseTranscript(data)
+	default:
+		return nil, fmt.Errorf("invalid import format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("no conversations found")
+	}
+	return sessions, nil
+}
+
+// Detect returns the format of data, or an empty string if it isn't one of
+// the import formats. JSON that is neither a ChatGPT export nor a transcript
+// and isn't valid as a whole is treated as OpenAI JSONL.
+func Detect(data []byte) string {
+	data = bytes.TrimSpace(data)
+	if !json.Valid(data) {
+		if firstLine, _, _ := bytes.Cut(data, []byte("\n")); json.Valid(firstLine) && bytes.HasPrefix(firstLine, []byte("{")) {
+			return FormatOpenAI
+		}
+		return ""
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []byte("[")):
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil || len(items) == 0 {
+			return ""
+		}
+		if _, ok := items[0]["mapping"]; ok {
+			return FormatChatGPT
+		}
+		if _, ok := items[0]["role"]; ok {
+			return FormatOpenAI
+		}
+	case bytes.HasPrefix(data, []byte("{")):
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			return ""
+		}
+		var name string
+		if json.Unmarshal(object["format"], &name) == nil && name == export.TranscriptFormat {
+			return FormatTranscript
+		}
+		if _, ok := object["mapping"]; ok {
+			return FormatChatGPT
+		}
+		if _, ok := object["messages"]; ok {
+			return FormatOpenAI
+		}
+		if _, ok := object["role"]; ok {
+			return FormatOpenAI
+		}
+	}
+	return ""
+}
+
+// parseTranscript converts a JSON transcript written by "sessions export".
+// The session keeps its original ID unless that is already taken.
+func parseTranscript(data []byte) ([]*config.Session, error) {
+	var t export.Transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse transcript: %w", err)
+	}
+	if t.Format != export.TranscriptFormat {
+		return nil, fmt.Errorf("not a transcript: format is %q, want %q", t.Format, export.TranscriptFormat)
+	}
+	if t.Version > export.TranscriptVersion {
+		return nil, fmt.Errorf("transcript uses format version %d, newer than the supported version %d", t.Version, export.TranscriptVersion)
+	}
+
+	session := newSession(sourceTranscript+t.ID, t.CreatedAt, t.UpdatedAt)
+	if t.ID != "" {
+		if _, err := config.LoadSession(t.ID); errors.Is(err, config.ErrSessionNotFound) {
+			session.ID = t.ID
+		}
+	}
+	session.Title = t.Title
+	session.SystemPrompt = t.SystemPrompt
+	session.SetModelChain(t.Models)
+	session.SetTags(t.Tags)
+
+	var messages []config.SessionMessage
+	for i, m := range t.Messages {
+		msg := api.Message{Role: m.Role, Content: m.Content}
+		if len(m.Attachments) > 0 {
+			msg.ContentParts = []api.ContentPart{{Type: "text", Text: m.Content}}
+		}
+		for _, a := range m.Attachments {
+			switch a.Type {
+			case config.SessionPartImage:
+				msg.ContentParts = append(msg.ContentParts, api.ContentPart{Type: "image_url", ImageURL: &api.ImageURL{URL: a.URL}})
+			case config.SessionPartFile:
+				msg.ContentParts = append(msg.ContentParts, api.ContentPart{Type: "file", File: &api.FileContent{Filename: a.Filename, FileData: a.URL}})
+			}
+		}
+		for _, image := range m.Images {
+			msg.Images = append(msg.Images, api.ImageContent{Type: "image_url", ImageURL: api.ImageURL{URL: image.URL}})
+		}
+
+		stored, err := config.NewSessionMessage(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import message %d: %w", i+1, err)
+		}
+		stored.Reasoning = m.Reasoning
+		stored.Model = m.Model
+		stored.Provider = m.Provider
+		stored.Usage = m.Usage
+		stored.Time = m.Time
+		messages = append(messages, stored)
+	}
+	session.SetMessages(messages)
+	return []*config.Session{session}, nil
+}
+
+// newSession returns a session for an imported conversation. Missing
+// timestamps default to now.
+func newSession(source string, created, updated time.Time) *config.Session {
+	session := config.NewSession()
+	session.Source = source
+	if !created.IsZero() {
+		session.CreatedAt = created
+	}
+	if !updated.IsZero() {
+		session.UpdatedAt = updated
+	} else if !created.IsZero() {
+		session.UpdatedAt = created
+	}
+	return session
+}
+
+// contentID identifies a conversation without an ID of its own by a hash
+// of its content.
+func contentID(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// jsonLines returns the non-blank lines of data.
+func jsonLines(data []byte) [][]byte {
+	var lines [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
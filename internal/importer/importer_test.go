@@ -0,0 +1,306 @@
+package importer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/config"
+	"github.com/vstratful/openrouter-cli/internal/export"
+)
+
+// useTempSessionDir stores sessions and blobs in a temporary directory.
+func useTempSessionDir(t *testing.T) {
+	t.Helper()
+	originalGetSessionDir := config.GetSessionDir
+	dir := t.TempDir()
+	config.GetSessionDir = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { config.GetSessionDir = originalGetSessionDir })
+}
+
+// contents returns the role and content of each message on the active
+// branch.
+func contents(s *config.Session) []string {
+	var out []string
+	for _, msg := range s.Messages {
+		out = append(out, msg.Role+": "+msg.Content)
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	return strings.Join(a, "\n") == strings.Join(b, "\n")
+}
+
+const chatGPTExport = `[{
+  "title": "Go channels",
+  "create_time": 1700000000.5,
+  "update_time": 1700000600,
+  "conversation_id": "conv-1",
+  "current_node": "a2",
+  "mapping": {
+    "root": {"id": "root", "message": null, "parent": null, "children": ["sys"]},
+    "sys": {"id": "sys", "parent": "root", "children": ["u1"],
+      "message": {"author": {"role": "system"}, "content": {"content_type": "text", "parts": [""]}, "metadata": {"is_visually_hidden_from_conversation": true}}},
+    "u1": {"id": "u1", "parent": "sys", "children": ["a1", "a2"],
+      "message": {"author": {"role": "user"}, "create_time": 1700000001, "content": {"content_type": "multimodal_text", "parts": [{"content_type": "image_asset_pointer"}, "What are channels?"]}}},
+    "a1": {"id": "a1", "parent": "u1", "children": [],
+      "message": {"author": {"role": "assistant"}, "content": {"content_type": "text", "parts": ["First answer"]}, "metadata": {"model_slug": "gpt-4o"}}},
+    "a2": {"id": "a2", "parent": "u1", "children": ["tool"],
+      "message": {"author": {"role": "assistant"}, "recipient": "all", "content": {"content_type": "text", "parts": ["Second answer"]}, "metadata": {"model_slug": "gpt-4o"}}},
+    "tool": {"id": "tool", "parent": "a2", "children": [],
+      "message": {"author": {"role": "assistant"}, "recipient": "python", "content": {"content_type": "code", "text": "print(1)"}}}
+  }
+}]`
+
+func TestParseChatGPT(t *testing.T) {
+	useTempSessionDir(t)
+	sessions, err := Parse([]byte(chatGPTExport), FormatAuto)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want 1", len(sessions))
+	}
+	s := sessions[0]
+	if s.Title != "Go channels" || s.Source != "chatgpt:conv-1" {
+		t.Errorf("Title, Source = %q, %q", s.Title, s.Source)
+	}
+	if want := time.Unix(1700000600, 0); !s.UpdatedAt.Equal(want) {
+		t.Errorf("UpdatedAt = %v, want %v", s.UpdatedAt, want)
+	}
+	want := []string{"user: [image]\n\nWhat are channels?", "assistant: Second answer"}
+	if got := contents(s); !equal(got, want) {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+	if len(s.Branches()) != 2 {
+		t.Errorf("got %d branches, want 2", len(s.Branches()))
+	}
+	if s.Messages[1].Model != "openai/gpt-4o" {
+		t.Errorf("Model = %q, want openai/gpt-4o", s.Messages[1].Model)
+	}
+	if s.Messages[0].Time.IsZero() {
+		t.Error("user message has no time")
+	}
+}
+
+func TestParseChatGPTCycle(t *testing.T) {
+	message := func(role, text string) string {
+		return `{"author": {"role": "` + role + `"}, "content": {"content_type": "text", "parts": ["` + text + `"]}}`
+	}
+	tests := []struct {
+		name    string
+		mapping string
+	}{
+		{
+			name: "child is an ancestor",
+			mapping: `"root": {"id": "root", "parent": null, "children": ["u1"]},
+				"u1": {"id": "u1", "parent": "root", "children": ["a1"], "message": ` + message("user", "Hi") + `},
+				"a1": {"id": "a1", "parent": "u1", "children": ["u1"], "message": ` + message("assistant", "Hello") + `}`,
+		},
+		{
+			name:    "child of itself",
+			mapping: `"u1": {"id": "u1", "parent": null, "children": ["u1"], "message": ` + message("user", "Hi") + `}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := `[{"conversation_id": "conv-1", "mapping": {` + tt.mapping + `}}]`
+			if _, err := Parse([]byte(data), FormatChatGPT); err == nil || !strings.Contains(err.Error(), "reached twice") {
+				t.Errorf("Parse() error = %v, want the cycle reported", err)
+			}
+		})
+	}
+}
+
+func TestParseOpenAI(t *testing.T) {
+	useTempSessionDir(t)
+	image := api.EncodeDataURL("image/png", []byte("png"))
+
+	tests := []struct {
+		name       string
+		data       string
+		want       [][]string
+		wantSystem string
+		wantModel  string
+	}{
+		{
+			name: "message array",
+			data: `[
+				{"role": "system", "content": "Be brief."},
+				{"role": "user", "content": [{"type": "text", "text": "Look"}, {"type": "text", "text": "here"}, {"type": "image_url", "image_url": {"url": "` + image + `"}}]},
+				{"role": "assistant", "content": null, "tool_calls": [{"id": "1", "type": "function", "function": {"name": "f", "arguments": "{}"}}]},
+				{"role": "tool", "tool_call_id": "1", "content": "42"},
+				{"role": "assistant", "content": "A cat."}
+			]`,
+			want:       [][]string{{"user: Look\nhere", "assistant: A cat."}},
+			wantSystem: "Be brief.",
+		},
+		{
+			name:      "request body",
+			data:      `{"model": "openai/gpt-4o", "messages": [{"role": "user", "content": "Hi"}, {"role": "assistant", "content": "Hello"}]}`,
+			want:      [][]string{{"user: Hi", "assistant: Hello"}},
+			wantModel: "openai/gpt-4o",
+		},
+		{
+			name: "jsonl requests",
+			data: `{"messages": [{"role": "user", "content": "One"}]}
+{"messages": [{"role": "user", "content": "Two"}]}`,
+			want: [][]string{{"user: One"}, {"user: Two"}},
+		},
+		{
+			name: "jsonl messages",
+			data: `{"role": "user", "content": "Hi"}
+{"role": "assistant", "content": "Hello"}
+`,
+			want: [][]string{{"user: Hi", "assistant: Hello"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions, err := Parse([]byte(tt.data), FormatAuto)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(sessions) != len(tt.want) {
+				t.Fatalf("got %d sessions, want %d", len(sessions), len(tt.want))
+			}
+			for i, s := range sessions {
+				if got := contents(s); !equal(got, tt.want[i]) {
+					t.Errorf("session %d messages = %q, want %q", i, got, tt.want[i])
+				}
+				if !strings.HasPrefix(s.Source, "openai:") {
+					t.Errorf("Source = %q", s.Source)
+				}
+			}
+			if sessions[0].SystemPrompt != tt.wantSystem {
+				t.Errorf("SystemPrompt = %q, want %q", sessions[0].SystemPrompt, tt.wantSystem)
+			}
+			if sessions[0].Model != tt.wantModel {
+				t.Errorf("Model = %q, want %q", sessions[0].Model, tt.wantModel)
+			}
+		})
+	}
+
+	t.Run("image stored as blob", func(t *testing.T) {
+		sessions, err := Parse([]byte(tests[0].data), FormatOpenAI)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts := sessions[0].Messages[0].Parts
+		if len(parts) != 3 || parts[2].Blob == "" {
+			t.Errorf("parts = %+v, want the image in the blob store", parts)
+		}
+	})
+
+	t.Run("same content, same source", func(t *testing.T) {
+		a, _ := Parse([]byte(tests[1].data), FormatAuto)
+		b, _ := Parse([]byte(tests[1].data), FormatAuto)
+		if a[0].Source != b[0].Source || a[0].ID == b[0].ID {
+			t.Errorf("sources %q, %q and IDs %q, %q", a[0].Source, b[0].Source, a[0].ID, b[0].ID)
+		}
+	})
+}
+
+func TestParseTranscript(t *testing.T) {
+	useTempSessionDir(t)
+	original := config.NewSession()
+	original.Title = "Review"
+	original.SystemPrompt = "Be brief."
+	original.SetModelChain([]string{"a/model", "b/model"})
+	original.SetTags([]string{"work"})
+	user, err := config.NewSessionMessage(api.Message{
+		Role:    "user",
+		Content: "Read this",
+		ContentParts: []api.ContentPart{
+			{Type: "text", Text: "Read this"},
+			{Type: "file", File: &api.FileContent{Filename: "notes.txt", FileData: api.EncodeDataURL("text/plain", []byte("notes"))}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []config.SessionMessage{
+		user,
+		{Role: "assistant", Content: "Done.", Reasoning: "Easy.", Model: "a/model", Provider: "Acme", Usage: &api.Usage{TotalTokens: 5}},
+	} {
+		if err := original.AppendSessionMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	transcript, err := export.New(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := export.Write(&buf, transcript, export.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	sessions, err := Parse(data, FormatAuto)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	s := sessions[0]
+	if s.ID == original.ID {
+		t.Error("imported session reuses the ID of an existing session")
+	}
+	if s.Title != "Review" || s.SystemPrompt != "Be brief." || len(s.Models) != 2 || !equal(s.Tags, []string{"work"}) {
+		t.Errorf("session = %+v", s)
+	}
+	if want := []string{"user: Read this", "assistant: Done."}; !equal(contents(s), want) {
+		t.Errorf("messages = %q, want %q", contents(s), want)
+	}
+	if parts := s.Messages[0].Parts; len(parts) != 2 || parts[1].Filename != "notes.txt" || parts[1].Blob != user.Parts[1].Blob {
+		t.Errorf("parts = %+v, want the file in the blob store", parts)
+	}
+	reply := s.Messages[1]
+	if reply.Reasoning != "Easy." || reply.Provider != "Acme" || reply.Usage == nil || !reply.Time.Equal(original.Messages[1].Time) {
+		t.Errorf("reply = %+v", reply)
+	}
+
+	// A transcript of a session that no longer exists keeps its ID
+	if err := config.DeleteSession(original.ID); err != nil {
+		t.Fatal(err)
+	}
+	sessions, err = Parse(data, FormatTranscript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessions[0].ID != original.ID || sessions[0].Source != "transcript:"+original.ID {
+		t.Errorf("ID, Source = %q, %q", sessions[0].ID, sessions[0].Source)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{data: chatGPTExport, want: FormatChatGPT},
+		{data: `{"mapping": {}}`, want: FormatChatGPT},
+		{data: `[{"role": "user", "content": "Hi"}]`, want: FormatOpenAI},
+		{data: `{"messages": []}`, want: FormatOpenAI},
+		{data: "{\"role\": \"user\"}\n{\"role\": \"assistant\"}", want: FormatOpenAI},
+		{data: `{"format": "openrouter-cli-transcript", "messages": []}`, want: FormatTranscript},
+		{data: `{"name": "x"}`, want: ""},
+		{data: `[]`, want: ""},
+		{data: `not json`, want: ""},
+	}
+	for _, tt := range tests {
+		if got := Detect([]byte(tt.data)); got != tt.want {
+			t.Errorf("Detect(%.30q) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+
+	if _, err := Parse([]byte(`{"name": "x"}`), FormatAuto); err == nil {
+		t.Error("Parse() of unknown JSON succeeded")
+	}
+	if _, err := ParseFormat("csv"); err == nil {
+		t.Error("ParseFormat(csv) succeeded")
+	}
+}
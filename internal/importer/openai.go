@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/config"
+)
+
+// openAIRequest is a chat completions request body, or any object holding
+// a conversation's messages.
+type openAIRequest struct {
+	Model    string            `json:"model"`
+	Messages []json.RawMessage `json:"messages"`
+}
+
+// parseOpenAI converts OpenAI chat completions messages. data is a message
+// array, a request body with a messages array, or JSONL where each line is
+// either a request body, imported as its own session, or a single message.
+// Bare message lines together form one session.
+func parseOpenAI(data []byte) ([]*config.Session, error) {
+	data = bytes.TrimSpace(data)
+	if json.Valid(data) {
+		if bytes.HasPrefix(data, []byte("[")) {
+			var messages []json.RawMessage
+			if err := json.Unmarshal(data, &messages); err != nil {
+				return nil, fmt.Errorf("failed to parse messages: %w", err)
+			}
+			session, err := openAISession(data, "", messages)
+			if err != nil {
+				return nil, err
+			}
+			return []*config.Session{session}, nil
+		}
+		if !isBareMessage(data) {
+			var request openAIRequest
+			if err := json.Unmarshal(data, &request); err != nil {
+				return nil, fmt.Errorf("failed to parse messages: %w", err)
+			}
+			session, err := openAISession(data, request.Model, request.Messages)
+			if err != nil {
+				return nil, err
+			}
+			return []*config.Session{session}, nil
+		}
+	}
+
+	var sessions []*config.Session
+	var bare []json.RawMessage
+	for i, line := range jsonLines(data) {
+		if isBareMessage(line) {
+			bare = append(bare, line)
+			continue
+		}
+		var request openAIRequest
+		if err := json.Unmarshal(line, &request); err != nil {
+			return nil, fmt.Errorf("failed to parse line %d: %w", i+1, err)
+		}
+		session, err := openAISession(line, request.Model, request.Messages)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		sessions = append(sessions, session)
+	}
+	if len(bare) > 0 {
+		session, err := openAISession(data, "", bare)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// isBareMessage reports whether a JSON object is a single message rather
+// than a request holding messages.
+func isBareMessage(data []byte) bool {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return false
+	}
+	_, hasRole := object["role"]
+	_, hasMessages := object["messages"]
+	return hasRole && !hasMessages
+}
+
+// openAISession converts one conversation. System messages become the
+// system prompt; tool calls and their results are left out, as sessions
+// don't record them. raw identifies the conversation for repeated imports.
+func openAISession(raw []byte, model string, rawMessages []json.RawMessage) (*config.Session, error) {
+	session := newSession(sourceOpenAI+contentID(raw), time.Time{}, time.Time{})
+	if model != "" {
+		session.SetModelChain([]string{model})
+	}
+
+	var system []string
+	var messages []config.SessionMessage
+	for i, rawMessage := range rawMessages {
+		var msg api.Message
+		if err := json.Unmarshal(rawMessage, &msg); err != nil {
+			return nil, fmt.Errorf("failed to parse message %d: %w", i+1, err)
+		}
+		switch msg.Role {
+		case "system", "developer":
+			if text := messageText(msg); text != "" {
+				system = append(system, text)
+			}
+			continue
+		case "user", "assistant":
+		default:
+			continue
+		}
+
+		msg.ContentParts = supportedParts(msg.ContentParts)
+		if len(msg.ContentParts) > 0 {
+			// Content only holds the first text part; let it be rebuilt from all of them
+			msg.Content = ""
+		}
+		if msg.Content == "" && len(msg.ContentParts) == 0 {
+			// Tool calls only, or nothing this tool can show
+			continue
+		}
+		msg.ToolCalls = nil
+
+		stored, err := config.NewSessionMessage(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import message %d: %w", i+1, err)
+		}
+		if msg.Role == "assistant" {
+			stored.Model = model
+		}
+		messages = append(messages, stored)
+	}
+
+	session.SystemPrompt = strings.Join(system, "\n\n")
+	session.SetMessages(messages)
+	return session, nil
+}
+
+// supportedParts returns the text, image and file parts of a message,
+// dropping kinds sessions can't store, such as audio.
+func supportedParts(parts []api.ContentPart) []api.ContentPart {
+	var supported []api.ContentPart
+	for _, part := range parts {
+		switch {
+		case part.Type == "text",
+			part.Type == "image_url" && part.ImageURL != nil,
+			part.Type == "file" && part.File != nil:
+			supported = append(supported, part)
+		}
+	}
+	return supported
+}
+
+// messageText joins the text of a message's content parts.
+func messageText(msg api.Message) string {
+	if len(msg.ContentParts) == 0 {
+		return msg.Content
+	}
+	var text []string
+	for _, part := range msg.ContentParts {
+		if part.Type == "text" {
+			text = append(text, part.Text)
+		}
+	}
+	return strings.Join(text, "\n")
+}
@@ -0,0 +1,405 @@
+// Package schema validates JSON documents against a JSON Schema.
+// It supports the keywords commonly used in structured output schemas:
+// type, enum, const, properties, required, additionalProperties, items,
+// length, size and range constraints, pattern, allOf/anyOf/oneOf/not and
+// local $ref pointers. Unknown keywords such as format are ignored.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a parsed JSON Schema.
+type Schema struct {
+	root any
+	raw  json.RawMessage
+}
+
+// ValidationError lists every location where a document violates the schema.
+type ValidationError struct {
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Errors, "; ")
+}
+
+// Parse parses a JSON Schema document.
+func Parse(data []byte) (*Schema, error) {
+	var root any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid schema JSON: %w", err)
+	}
+	switch root.(type) {
+	case map[string]any, bool:
+	default:
+		return nil, fmt.Errorf("schema must be a JSON object")
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("invalid schema JSON: %w", err)
+	}
+	return &Schema{root: root, raw: compact.Bytes()}, nil
+}
+
+// Raw returns the compacted schema document.
+func (s *Schema) Raw() json.RawMessage {
+	return s.raw
+}
+
+// ValidateJSON decodes data and validates it against the schema.
+func (s *Schema) ValidateJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return s.Validate(v)
+}
+
+// Validate checks a decoded JSON value against the schema.
+// Returns a *ValidationError describing all violations.
+func (s *Schema) Validate(v any) error {
+	vc := &validator{root: s.root}
+	vc.validate(s.root, v, "$")
+	if len(vc.errors) > 0 {
+		return &ValidationError{Errors: vc.errors}
+	}
+	return nil
+}
+
+// validator accumulates errors while walking a document.
+type validator struct {
+	root   any
+	errors []string
+	depth  int
+}
+
+// maxRefDepth bounds $ref resolution to guard against cyclic references.
+const maxRefDepth = 64
+
+func (vc *validator) errorf(path, format string, args ...any) {
+	vc.errors = append(vc.errors, path+": "+fmt.Sprintf(format, args...))
+}
+
+// check validates v against schema and returns whether it matched, without
+// recording errors. Used by the combinators.
+func (vc *validator) check(schema, v any, path string) bool {
+	sub := &validator{root: vc.root, depth: vc.depth}
+	sub.validate(schema, v, path)
+	return len(sub.errors) == 0
+}
+
+func (vc *validator) validate(schema, v any, path string) {
+	switch sch := schema.(type) {
+	case bool:
+		if !sch {
+			vc.errorf(path, "no value is allowed here")
+		}
+		return
+	case map[string]any:
+		vc.validateObjectSchema(sch, v, path)
+	}
+}
+
+func (vc *validator) validateObjectSchema(sch map[string]any, v any, path string) {
+	if ref, ok := sch["$ref"].(string); ok {
+		target, err := vc.resolve(ref)
+		if err != nil {
+			vc.errorf(path, "%v", err)
+			return
+		}
+		if vc.depth >= maxRefDepth {
+			vc.errorf(path, "$ref %q nested too deeply", ref)
+			return
+		}
+		vc.depth++
+		vc.validate(target, v, path)
+		vc.depth--
+	}
+
+	if t, ok := sch["type"]; ok && !matchesType(t, v) {
+		vc.errorf(path, "expected %s, got %s", describeType(t), typeName(v))
+		return
+	}
+
+	if enum, ok := sch["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vc.errorf(path, "value must be one of %s", compactJSON(enum))
+		}
+	}
+	if c, ok := sch["const"]; ok && !reflect.DeepEqual(c, v) {
+		vc.errorf(path, "value must be %s", compactJSON(c))
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		vc.validateObject(sch, val, path)
+	case []any:
+		vc.validateArray(sch, val, path)
+	case string:
+		vc.validateString(sch, val, path)
+	case float64:
+		vc.validateNumber(sch, val, path)
+	}
+
+	if all, ok := sch["allOf"].([]any); ok {
+		for _, sub := range all {
+			vc.validate(sub, v, path)
+		}
+	}
+	if anyOf, ok := sch["anyOf"].([]any); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if vc.check(sub, v, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			vc.errorf(path, "value does not match any of the allowed schemas")
+		}
+	}
+	if oneOf, ok := sch["oneOf"].([]any); ok {
+		matches := 0
+		for _, sub := range oneOf {
+			if vc.check(sub, v, path) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			vc.errorf(path, "value must match exactly one schema, matched %d", matches)
+		}
+	}
+	if not, ok := sch["not"]; ok && vc.check(not, v, path) {
+		vc.errorf(path, "value must not match the excluded schema")
+	}
+}
+
+func (vc *validator) validateObject(sch map[string]any, obj map[string]any, path string) {
+	if required, ok := sch["required"].([]any); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, present := obj[name]; !present {
+				vc.errorf(path, "missing required property %q", name)
+			}
+		}
+	}
+
+	props, _ := sch["properties"].(map[string]any)
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		childPath := path + "." + k
+		if propSchema, ok := props[k]; ok {
+			vc.validate(propSchema, obj[k], childPath)
+			continue
+		}
+		if additional, ok := sch["additionalProperties"]; ok {
+			if allowed, isBool := additional.(bool); isBool && !allowed {
+				vc.errorf(path, "unexpected property %q", k)
+				continue
+			}
+			vc.validate(additional, obj[k], childPath)
+		}
+	}
+
+	if n, ok := intKeyword(sch, "minProperties"); ok && len(obj) < n {
+		vc.errorf(path, "must have at least %d properties", n)
+	}
+	if n, ok := intKeyword(sch, "maxProperties"); ok && len(obj) > n {
+		vc.errorf(path, "must have at most %d properties", n)
+	}
+}
+
+func (vc *validator) validateArray(sch map[string]any, arr []any, path string) {
+	if items, ok := sch["items"]; ok {
+		for i, item := range arr {
+			vc.validate(items, item, path+"["+strconv.Itoa(i)+"]")
+		}
+	}
+	if n, ok := intKeyword(sch, "minItems"); ok && len(arr) < n {
+		vc.errorf(path, "must have at least %d items", n)
+	}
+	if n, ok := intKeyword(sch, "maxItems"); ok && len(arr) > n {
+		vc.errorf(path, "must have at most %d items", n)
+	}
+	if unique, _ := sch["uniqueItems"].(bool); unique {
+		for i := range arr {
+			for j := i + 1; j < len(arr); j++ {
+				if reflect.DeepEqual(arr[i], arr[j]) {
+					vc.errorf(path, "items %d and %d are duplicates", i, j)
+				}
+			}
+		}
+	}
+}
+
+func (vc *validator) validateString(sch map[string]any, s, path string) {
+	length := utf8.RuneCountInString(s)
+	if n, ok := intKeyword(sch, "minLength"); ok && length < n {
+		vc.errorf(path, "must be at least %d characters", n)
+	}
+	if n, ok := intKeyword(sch, "maxLength"); ok && length > n {
+		vc.errorf(path, "must be at most %d characters", n)
+	}
+	if pattern, ok := sch["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			vc.errorf(path, "unsupported pattern %q: %v", pattern, err)
+		} else if !re.MatchString(s) {
+			vc.errorf(path, "must match pattern %q", pattern)
+		}
+	}
+}
+
+func (vc *validator) validateNumber(sch map[string]any, n float64, path string) {
+	if min, ok := sch["minimum"].(float64); ok && n < min {
+		vc.errorf(path, "must be >= %g", min)
+	}
+	if max, ok := sch["maximum"].(float64); ok && n > max {
+		vc.errorf(path, "must be <= %g", max)
+	}
+	if min, ok := sch["exclusiveMinimum"].(float64); ok && n <= min {
+		vc.errorf(path, "must be > %g", min)
+	}
+	if max, ok := sch["exclusiveMaximum"].(float64); ok && n >= max {
+		vc.errorf(path, "must be < %g", max)
+	}
+	if m, ok := sch["multipleOf"].(float64); ok && m > 0 {
+		if q := n / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			vc.errorf(path, "must be a multiple of %g", m)
+		}
+	}
+}
+
+// resolve looks up a local reference such as "#/$defs/item".
+func (vc *validator) resolve(ref string) (any, error) {
+	if ref == "#" {
+		return vc.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q: only local references are supported", ref)
+	}
+
+	node := vc.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if node, ok = obj[token]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return node, nil
+}
+
+// matchesType reports whether v matches a "type" keyword, which may be a
+// single type name or a list of names.
+func matchesType(t, v any) bool {
+	switch tt := t.(type) {
+	case string:
+		return isType(tt, v)
+	case []any:
+		for _, name := range tt {
+			if s, ok := name.(string); ok && isType(s, v) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isType(name string, v any) bool {
+	switch name {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	}
+	return false
+}
+
+func typeName(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func describeType(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, 0, len(list))
+		for _, n := range list {
+			names = append(names, fmt.Sprint(n))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func intKeyword(sch map[string]any, key string) (int, bool) {
+	n, ok := sch[key].(float64)
+	return int(n), ok
+}
+
+func compactJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
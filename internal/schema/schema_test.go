@@ -0,0 +1,128 @@
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const personSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"age": {"type": "integer", "minimum": 0},
+		"role": {"enum": ["admin", "user"]},
+		"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+		"address": {"$ref": "#/$defs/address"}
+	},
+	"required": ["name", "age"],
+	"additionalProperties": false,
+	"$defs": {
+		"address": {
+			"type": "object",
+			"properties": {"zip": {"type": "string", "pattern": "^[0-9]{5}$"}},
+			"required": ["zip"]
+		}
+	}
+}`
+
+func TestValidateJSON(t *testing.T) {
+	s, err := Parse([]byte(personSchema))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{name: "valid", doc: `{"name":"Ada","age":36,"role":"admin","tags":["x"],"address":{"zip":"12345"}}`},
+		{name: "missing required", doc: `{"name":"Ada"}`, wantErr: `$: missing required property "age"`},
+		{name: "wrong type", doc: `{"name":"Ada","age":"36"}`, wantErr: "$.age: expected integer, got string"},
+		{name: "not an integer", doc: `{"name":"Ada","age":3.5}`, wantErr: "$.age: expected integer, got number"},
+		{name: "below minimum", doc: `{"name":"Ada","age":-1}`, wantErr: "$.age: must be >= 0"},
+		{name: "empty string", doc: `{"name":"","age":1}`, wantErr: "$.name: must be at least 1 characters"},
+		{name: "enum", doc: `{"name":"Ada","age":1,"role":"root"}`, wantErr: `$.role: value must be one of ["admin","user"]`},
+		{name: "item type", doc: `{"name":"Ada","age":1,"tags":[1]}`, wantErr: "$.tags[0]: expected string, got integer"},
+		{name: "too many items", doc: `{"name":"Ada","age":1,"tags":["a","b","c"]}`, wantErr: "$.tags: must have at most 2 items"},
+		{name: "additional property", doc: `{"name":"Ada","age":1,"extra":true}`, wantErr: `$: unexpected property "extra"`},
+		{name: "ref pattern", doc: `{"name":"Ada","age":1,"address":{"zip":"abc"}}`, wantErr: `$.address.zip: must match pattern`},
+		{name: "not an object", doc: `[1,2]`, wantErr: "$: expected object, got array"},
+		{name: "invalid JSON", doc: `{"name":`, wantErr: "invalid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.ValidateJSON([]byte(tt.doc))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateJSON() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateJSON() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Combinators(t *testing.T) {
+	s, err := Parse([]byte(`{
+		"anyOf": [{"type": "string"}, {"type": "null"}],
+		"not": {"const": "forbidden"}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	for _, doc := range []string{`"ok"`, `null`} {
+		if err := s.ValidateJSON([]byte(doc)); err != nil {
+			t.Errorf("ValidateJSON(%s) error = %v", doc, err)
+		}
+	}
+	for _, doc := range []string{`1`, `"forbidden"`} {
+		if err := s.ValidateJSON([]byte(doc)); err == nil {
+			t.Errorf("ValidateJSON(%s) should fail", doc)
+		}
+	}
+
+	oneOf, _ := Parse([]byte(`{"oneOf": [{"type": "number"}, {"type": "integer"}]}`))
+	if err := oneOf.ValidateJSON([]byte(`1`)); err == nil {
+		t.Error("oneOf should fail when more than one schema matches")
+	}
+	if err := oneOf.ValidateJSON([]byte(`1.5`)); err != nil {
+		t.Errorf("oneOf error = %v", err)
+	}
+}
+
+func TestValidate_CollectsAllErrors(t *testing.T) {
+	s, _ := Parse([]byte(personSchema))
+	err := s.ValidateJSON([]byte(`{"age":"x","extra":1}`))
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error = %v, want *ValidationError", err)
+	}
+	if len(verr.Errors) != 3 {
+		t.Errorf("got %d errors, want 3: %v", len(verr.Errors), verr.Errors)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if _, err := Parse([]byte(`{not json}`)); err == nil {
+		t.Error("Parse() should fail for invalid JSON")
+	}
+	if _, err := Parse([]byte(`"string"`)); err == nil {
+		t.Error("Parse() should fail for a non-object schema")
+	}
+
+	s, err := Parse([]byte("{\n  \"type\": \"object\"\n}"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if string(s.Raw()) != `{"type":"object"}` {
+		t.Errorf("Raw() = %s, want compacted schema", s.Raw())
+	}
+}
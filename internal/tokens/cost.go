@@ -0,0 +1,48 @@
+package tokens
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+// ErrVariablePricing is returned for models priced by the model they route
+// to, such as openrouter/auto.
+var ErrVariablePricing = errors.New("pricing depends on the model the request is routed to")
+
+// Cost returns the cost in credits (USD) of a request with the given prompt
+// and completion tokens at the model's pricing, including any per-request
+// price.
+func Cost(pricing api.ModelPricing, promptTokens, completionTokens int) (float64, error) {
+	prompt, err := parsePrice(pricing.Prompt)
+	if err != nil {
+		return 0, err
+	}
+	completion, err := parsePrice(pricing.Completion)
+	if err != nil {
+		return 0, err
+	}
+	request, err := parsePrice(pricing.Request)
+	if err != nil {
+		return 0, err
+	}
+	return prompt*float64(promptTokens) + completion*float64(completionTokens) + request, nil
+}
+
+// parsePrice parses a price from the models list. Missing prices are free;
+// negative ones mark variable pricing.
+func parsePrice(price string) (float64, error) {
+	if price == "" {
+		return 0, nil
+	}
+	p, err := strconv.ParseFloat(price, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid price %q: %w", price, err)
+	}
+	if p < 0 {
+		return 0, ErrVariablePricing
+	}
+	return p, nil
+}
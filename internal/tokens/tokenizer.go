@@ -0,0 +1,54 @@
+package tokens
+
+import (
+	"strings"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+// Tokenizer is an estimation profile for a family of tokenizers. Name is the
+// tokenizer as given in a model's architecture, such as "Claude".
+type Tokenizer struct {
+	Name string
+	// CharsPerToken is the number of ASCII characters, such as English text
+	// and code, per token
+	CharsPerToken float64
+	// RuneTokens is the number of tokens per other character, such as CJK
+	RuneTokens float64
+}
+
+// Default is the profile for tokenizers without one of their own.
+var Default = Tokenizer{Name: "Other", CharsPerToken: charsPerToken, RuneTokens: 1}
+
+// tokenizers holds the profiles of the tokenizers in the models list, keyed
+// by lowercase name. Larger vocabularies pack more text into each token;
+// Llama 2's and Mistral's split CJK text into bytes.
+var tokenizers = map[string]Tokenizer{
+	"gpt":      {Name: "GPT", CharsPerToken: 4, RuneTokens: 0.8},
+	"claude":   {Name: "Claude", CharsPerToken: 3.5, RuneTokens: 1.2},
+	"gemini":   {Name: "Gemini", CharsPerToken: 4, RuneTokens: 0.8},
+	"llama2":   {Name: "Llama2", CharsPerToken: 3.5, RuneTokens: 1.8},
+	"llama3":   {Name: "Llama3", CharsPerToken: 4, RuneTokens: 1},
+	"llama4":   {Name: "Llama4", CharsPerToken: 4, RuneTokens: 0.9},
+	"mistral":  {Name: "Mistral", CharsPerToken: 3.7, RuneTokens: 1.5},
+	"qwen":     {Name: "Qwen", CharsPerToken: 4, RuneTokens: 0.7},
+	"qwen3":    {Name: "Qwen3", CharsPerToken: 4, RuneTokens: 0.7},
+	"deepseek": {Name: "DeepSeek", CharsPerToken: 3.8, RuneTokens: 0.7},
+	"cohere":   {Name: "Cohere", CharsPerToken: 4, RuneTokens: 1},
+	"grok":     {Name: "Grok", CharsPerToken: 4, RuneTokens: 0.9},
+	"yi":       {Name: "Yi", CharsPerToken: 3.7, RuneTokens: 0.7},
+}
+
+// For returns the profile of the named tokenizer, or Default for tokenizers
+// without one.
+func For(name string) Tokenizer {
+	if t, ok := tokenizers[strings.ToLower(name)]; ok {
+		return t
+	}
+	return Default
+}
+
+// ForModel returns the profile of the model's tokenizer.
+func ForModel(model *api.Model) Tokenizer {
+	return For(model.Architecture.Tokenizer)
+}
@@ -0,0 +1,106 @@
+// Package tokens estimates how many tokens text and chat messages take up,
+// without calling the API. Estimates are approximate: they are meant for
+// warnings and budgeting, and the usage reported by the API is exact.
+package tokens
+
+import (
+	"math"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+const (
+	// charsPerToken is the typical number of ASCII characters per token of
+	// English text and code.
+	charsPerToken = 4
+
+	// messageOverhead covers the role and separators each message adds.
+	messageOverhead = 4
+
+	// replyOverhead covers the priming of the model's reply.
+	replyOverhead = 3
+
+	// ImageTokens is the estimate for an attached image. Providers count
+	// images differently; this is about a high-detail tile set.
+	ImageTokens = 1000
+
+	// fileBytesPerToken estimates attached files such as PDFs, whose
+	// extracted text is about a tenth of their size.
+	fileBytesPerToken = 10 * charsPerToken
+)
+
+// Estimate returns the approximate number of tokens in text with the
+// Default tokenizer.
+func Estimate(text string) int {
+	return Default.Estimate(text)
+}
+
+// Message returns the approximate number of tokens a message takes up in a
+// request with the Default tokenizer.
+func Message(msg api.Message) int {
+	return Default.Message(msg)
+}
+
+// Messages returns the approximate number of prompt tokens a request with
+// the messages takes up with the Default tokenizer.
+func Messages(messages []api.Message) int {
+	return Default.Messages(messages)
+}
+
+// Estimate returns the approximate number of tokens in text. ASCII text and
+// whitespace are counted at CharsPerToken characters per token and other
+// characters, such as CJK, at RuneTokens tokens each.
+func (t Tokenizer) Estimate(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf:
+			ascii++
+		case unicode.IsSpace(r):
+			ascii++
+		default:
+			other++
+		}
+	}
+	return int(math.Ceil(float64(ascii)/t.CharsPerToken + float64(other)*t.RuneTokens))
+}
+
+// Message returns the approximate number of tokens a message takes up in a
+// request, including its attachments.
+func (t Tokenizer) Message(msg api.Message) int {
+	n := messageOverhead
+	if len(msg.ContentParts) == 0 {
+		return n + t.Estimate(msg.Content)
+	}
+	for _, part := range msg.ContentParts {
+		n += t.Part(part)
+	}
+	return n
+}
+
+// Part returns the approximate number of tokens a part of a message takes
+// up, such as an attached image.
+func (t Tokenizer) Part(part api.ContentPart) int {
+	switch {
+	case part.Type == "text":
+		return t.Estimate(part.Text)
+	case part.Type == "image_url":
+		return ImageTokens
+	case part.Type == "file" && part.File != nil:
+		// Base64 data URLs hold three bytes per four characters
+		return len(part.File.FileData) * 3 / 4 / fileBytesPerToken
+	}
+	return 0
+}
+
+// Messages returns the approximate number of prompt tokens a request with
+// the messages takes up.
+func (t Tokenizer) Messages(messages []api.Message) int {
+	n := replyOverhead
+	for _, msg := range messages {
+		n += t.Message(msg)
+	}
+	return n
+}
@@ -0,0 +1,110 @@
+package tokens
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "", want: 0},
+		{text: "abc", want: 1},
+		{text: "Hello, world!", want: 4},
+		{text: strings.Repeat("a", 400), want: 100},
+		{text: "日本語", want: 3},
+		{text: "hi 日本", want: 3},
+	}
+	for _, tt := range tests {
+		if got := Estimate(tt.text); got != tt.want {
+			t.Errorf("Estimate(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMessages(t *testing.T) {
+	text := api.Message{Role: "user", Content: strings.Repeat("a", 40)}
+	if got, want := Message(text), messageOverhead+10; got != want {
+		t.Errorf("Message(text) = %d, want %d", got, want)
+	}
+
+	image := api.Message{Role: "user", ContentParts: []api.ContentPart{
+		{Type: "text", Text: "Look"},
+		{Type: "image_url", ImageURL: &api.ImageURL{URL: "data:image/png;base64,AAAA"}},
+	}}
+	if got, want := Message(image), messageOverhead+1+ImageTokens; got != want {
+		t.Errorf("Message(image) = %d, want %d", got, want)
+	}
+
+	file := api.Message{Role: "user", ContentParts: []api.ContentPart{
+		{Type: "file", File: &api.FileContent{Filename: "a.pdf", FileData: strings.Repeat("A", 4000)}},
+	}}
+	if got, want := Message(file), messageOverhead+3000/fileBytesPerToken; got != want {
+		t.Errorf("Message(file) = %d, want %d", got, want)
+	}
+
+	if got, want := Messages([]api.Message{text, text}), replyOverhead+2*Message(text); got != want {
+		t.Errorf("Messages() = %d, want %d", got, want)
+	}
+}
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Claude", want: "Claude"},
+		{name: "gpt", want: "GPT"},
+		{name: "Router", want: "Other"},
+		{name: "", want: "Other"},
+	}
+	for _, tt := range tests {
+		if got := For(tt.name).Name; got != tt.want {
+			t.Errorf("For(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	// Profiles differ in how densely they pack text
+	text := strings.Repeat("a", 700)
+	if claude, gpt := For("Claude").Estimate(text), For("GPT").Estimate(text); claude != 200 || gpt != 175 {
+		t.Errorf("Estimate() = %d (Claude), %d (GPT), want 200 and 175", claude, gpt)
+	}
+	model := &api.Model{Architecture: api.ModelArchitecture{Tokenizer: "Qwen"}}
+	if got := ForModel(model).Estimate("日本語日本語日本語日"); got != 7 {
+		t.Errorf("ForModel(Qwen).Estimate() = %d, want 7", got)
+	}
+}
+
+func TestCost(t *testing.T) {
+	tests := []struct {
+		name    string
+		pricing api.ModelPricing
+		want    float64
+		wantErr error
+	}{
+		{name: "free", pricing: api.ModelPricing{Prompt: "0", Completion: "0"}, want: 0},
+		{name: "per token", pricing: api.ModelPricing{Prompt: "0.000003", Completion: "0.000015"}, want: 0.003 + 0.0015},
+		{name: "per request", pricing: api.ModelPricing{Prompt: "0.000003", Completion: "0.000015", Request: "0.01"}, want: 0.0145},
+		{name: "variable", pricing: api.ModelPricing{Prompt: "-1", Completion: "-1"}, wantErr: ErrVariablePricing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Cost(tt.pricing, 1000, 100)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Cost() error = %v, want %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("Cost() = %g, want %g", got, tt.want)
+			}
+		})
+	}
+	if _, err := Cost(api.ModelPricing{Prompt: "free"}, 1, 0); err == nil {
+		t.Error("Cost() should reject an invalid price")
+	}
+}
@@ -0,0 +1,172 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/attachment"
+	"github.com/vstratful/openrouter-cli/internal/tui"
+)
+
+// modelDetailsMsg carries the metadata of a model, used to check that it
+// accepts the pending attachments.
+type modelDetailsMsg struct {
+	name  string
+	model *api.Model
+}
+
+// commandArg reports whether input invokes cmd and returns its argument.
+func commandArg(input, cmd string) (string, bool) {
+	if input == cmd {
+		return "", true
+	}
+	arg, ok := strings.CutPrefix(input, cmd+" ")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(arg), true
+}
+
+// Attachments returns the attachments pending for the next message.
+func (m *Model) Attachments() []*attachment.Attachment {
+	return m.attachments
+}
+
+// SetModelDetails records the current model's metadata, drops pending
+// attachments it can't accept and re-estimates the conversation with its
+// tokenizer.
+func (m *Model) SetModelDetails(model *api.Model) {
+	m.modelDetails = model
+	var kept []*attachment.Attachment
+	for _, a := range m.attachments {
+		if err := attachment.Check(model, a); err != nil {
+			m.err = err
+			continue
+		}
+		kept = append(kept, a)
+	}
+	m.attachments = kept
+	m.updateTextareaState()
+	m.countTokens()
+}
+
+// attach loads a file as a pending attachment. If the model's input
+// modalities aren't known yet, it returns a command to look them up.
+func (m *Model) attach(path string) tea.Cmd {
+	a, err := attachment.Load(path)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	if m.modelDetails != nil {
+		if err := attachment.Check(m.modelDetails, a); err != nil {
+			m.err = err
+			return nil
+		}
+	}
+	m.attachments = append(m.attachments, a)
+	m.err = nil
+
+	if m.modelDetails == nil {
+		return m.loadModelDetails()
+	}
+	return nil
+}
+
+// messageAttachments returns the pending attachments plus files referenced
+// inline as @path, checked against the model when its metadata is known.
+// handleSubmit waits for the metadata before sending attachments.
+func (m *Model) messageAttachments(input string) ([]*attachment.Attachment, error) {
+	attachments := append([]*attachment.Attachment(nil), m.attachments...)
+	for _, path := range inlineReferences(input) {
+		a, err := attachment.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	if m.modelDetails != nil {
+		for _, a := range attachments {
+			if err := attachment.Check(m.modelDetails, a); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return attachments, nil
+}
+
+// inlineReferences returns the paths of existing files referenced in input
+// as @path. Words starting with @ that don't name a file, such as handles,
+// are left alone. Trailing punctuation is ignored.
+func inlineReferences(input string) []string {
+	var paths []string
+	for _, word := range strings.Fields(input) {
+		path, ok := strings.CutPrefix(word, "@")
+		if !ok || path == "" {
+			continue
+		}
+		for _, candidate := range []string{path, strings.TrimRight(path, ".,;:!?)\"'")} {
+			if isFile(candidate) {
+				paths = append(paths, candidate)
+				break
+			}
+		}
+	}
+	return paths
+}
+
+// isFile reports whether path names an existing regular file.
+func isFile(path string) bool {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		path = home + string(os.PathSeparator) + rest
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// loadModelDetails looks up the current model's metadata.
+func (m *Model) loadModelDetails() tea.Cmd {
+	client, name := m.client, m.modelName
+	if client == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		models, err := client.ListModels(context.Background(), nil)
+		if err != nil {
+			// Unchecked attachments are left for the API to reject
+			return modelDetailsMsg{name: name}
+		}
+		for i := range models {
+			if models[i].ID == name {
+				return modelDetailsMsg{name: name, model: &models[i]}
+			}
+		}
+		return modelDetailsMsg{name: name}
+	}
+}
+
+// attachmentsHeight returns the rows taken by the pending attachments line.
+func (m *Model) attachmentsHeight() int {
+	if len(m.attachments) == 0 {
+		return 0
+	}
+	return 1
+}
+
+// renderAttachments renders the pending attachments shown above the input.
+func (m *Model) renderAttachments() string {
+	labels := make([]string, len(m.attachments))
+	for i, a := range m.attachments {
+		labels[i] = a.Label()
+	}
+	return tui.HelpStyle.Render(fmt.Sprintf("Attached: %s", strings.Join(labels, " "))) +
+		tui.DimHelpStyle.Render("  (⎋⎋ to clear)")
+}
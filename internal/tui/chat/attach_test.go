@@ -0,0 +1,126 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+func TestCommandArg(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantArg string
+		wantOK  bool
+	}{
+		{"/attach", "", true},
+		{"/attach  notes.txt ", "notes.txt", true},
+		{"/attachment", "", false},
+		{"hello /attach x", "", false},
+	}
+	for _, tt := range tests {
+		arg, ok := commandArg(tt.input, CmdAttach)
+		if arg != tt.wantArg || ok != tt.wantOK {
+			t.Errorf("commandArg(%q) = %q, %v, want %q, %v", tt.input, arg, ok, tt.wantArg, tt.wantOK)
+		}
+	}
+}
+
+func TestInlineReferences(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "cat.png")
+	notes := filepath.Join(dir, "notes.txt")
+	for _, path := range []string{image, notes} {
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	input := "Compare @" + image + " with @" + notes + ". Ping @someone or @" + dir
+	got := inlineReferences(input)
+	want := []string{image, notes}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("inlineReferences() = %q, want %q", got, want)
+	}
+}
+
+func TestMessageAttachments_ChecksModel(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "cat.png")
+	if err := os.WriteFile(image, []byte("png"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(Config{ModelName: "text/model"})
+	if cmd := m.attach(image); cmd != nil {
+		t.Error("attach() without a client should not look up the model")
+	}
+	if len(m.Attachments()) != 1 {
+		t.Fatalf("pending attachments = %d, want 1", len(m.Attachments()))
+	}
+
+	// Learning that the model is text-only drops the pending image
+	m.SetModelDetails(&api.Model{ID: "text/model", Architecture: api.ModelArchitecture{InputModalities: []string{"text"}}})
+	if len(m.Attachments()) != 0 || m.Err() == nil {
+		t.Errorf("pending = %d, err = %v, want image dropped with an error", len(m.Attachments()), m.Err())
+	}
+
+	// Inline references are checked too
+	if _, err := m.messageAttachments("look at @" + image); err == nil {
+		t.Error("messageAttachments() should reject an image for a text-only model")
+	}
+}
+
+func TestSubmitWaitsForModelDetails(t *testing.T) {
+	image := filepath.Join(t.TempDir(), "cat.png")
+	if err := os.WriteFile(image, []byte("png"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	textOnly := api.Model{ID: "a/model", Architecture: api.ModelArchitecture{InputModalities: []string{"text"}}}
+
+	tests := []struct {
+		name      string
+		models    []api.Model
+		err       error
+		wantSent  bool
+		wantError bool
+	}{
+		{name: "text-only model", models: []api.Model{textOnly}, wantError: true},
+		{name: "lookup fails", err: errors.New("offline"), wantSent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestConversation(t)
+			client := api.NewMockClient()
+			client.ListModelsFunc = func(ctx context.Context, opts *api.ListModelsOptions) ([]api.Model, error) {
+				return tt.models, tt.err
+			}
+			m.client = client
+			m.textarea.SetValue("What is in @" + image)
+
+			// Nothing is sent until the model's details are known
+			model, cmd := m.handleSubmit()
+			m = model.(Model)
+			if cmd == nil || !m.awaitingDetails || len(m.messages) != 4 {
+				t.Fatalf("handleSubmit() sent %d messages, awaiting %v; want to wait for the lookup", len(m.messages)-4, m.awaitingDetails)
+			}
+
+			model, _ = m.Update(cmd())
+			m = model.(Model)
+			if sent := len(m.messages) == 5; sent != tt.wantSent {
+				t.Errorf("sent = %v after the lookup, want %v", sent, tt.wantSent)
+			}
+			if (m.Err() != nil) != tt.wantError {
+				t.Errorf("Err() = %v, wantError %v", m.Err(), tt.wantError)
+			}
+			if tt.wantSent && !strings.Contains(m.notice, "weren't checked") {
+				t.Errorf("notice = %q, want the unchecked attachments noted", m.notice)
+			}
+		})
+	}
+}
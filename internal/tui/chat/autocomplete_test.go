@@ -25,13 +25,13 @@ func TestAutocompleteState_Update(t *testing.T) {
 			name:        "slash only",
 			input:       "/",
 			wantVisible: true,
-			wantCount:   6, // /clear, /exit, /models, /new, /quit, /resume
+			wantCount:   16, // /attach, /branch, /branches, /clear, /compact, /context, /edit, /exit, /export, /models, /new, /quit, /resume, /retry, /system, /undo
 		},
 		{
 			name:        "partial command",
 			input:       "/e",
 			wantVisible: true,
-			wantCount:   1, // /exit
+			wantCount:   3, // /edit, /exit, /export
 		},
 		{
 			name:        "exact match",
@@ -59,10 +59,16 @@ func TestAutocompleteState_Update(t *testing.T) {
 		},
 		{
 			name:        "resume prefix",
-			input:       "/r",
+			input:       "/res",
 			wantVisible: true,
 			wantCount:   1, // /resume
 		},
+		{
+			name:        "shared prefix",
+			input:       "/re",
+			wantVisible: true,
+			wantCount:   2, // /resume, /retry
+		},
 		{
 			name:        "no match",
 			input:       "/xyz",
@@ -88,7 +94,7 @@ func TestAutocompleteState_Update(t *testing.T) {
 
 func TestAutocompleteState_Navigation(t *testing.T) {
 	a := NewAutocompleteState()
-	a.Update("/") // Show all 6 commands
+	a.Update("/") // Show all commands
 
 	if a.Index() != 0 {
 		t.Errorf("Initial Index() = %d, want 0", a.Index())
@@ -100,33 +106,33 @@ func TestAutocompleteState_Navigation(t *testing.T) {
 		t.Errorf("After Down() Index() = %d, want 1", a.Index())
 	}
 
-	a.Down()
-	a.Down()
-	a.Down()
-	a.Down()
-	if a.Index() != 5 {
-		t.Errorf("After 5x Down() Index() = %d, want 5", a.Index())
+	last := len(AvailableCommands()) - 1
+	for a.Index() < last {
+		before := a.Index()
+		a.Down()
+		if a.Index() != before+1 {
+			t.Fatalf("Down() from %d Index() = %d, want %d", before, a.Index(), before+1)
+		}
 	}
 
 	// Down at bottom should stay at bottom
 	a.Down()
-	if a.Index() != 5 {
-		t.Errorf("Down at bottom Index() = %d, want 5", a.Index())
+	if a.Index() != last {
+		t.Errorf("Down at bottom Index() = %d, want %d", a.Index(), last)
 	}
 
 	// Up navigation
 	a.Up()
-	if a.Index() != 4 {
-		t.Errorf("After Up() Index() = %d, want 4", a.Index())
+	if a.Index() != last-1 {
+		t.Errorf("After Up() Index() = %d, want %d", a.Index(), last-1)
 	}
 
 	// Up to top
-	a.Up()
-	a.Up()
-	a.Up()
-	a.Up()
+	for i := 0; i < last-1; i++ {
+		a.Up()
+	}
 	if a.Index() != 0 {
-		t.Errorf("After 5x Up() Index() = %d, want 0", a.Index())
+		t.Errorf("After %dx Up() Index() = %d, want 0", last, a.Index())
 	}
 
 	// Up at top should stay at top
@@ -138,7 +144,7 @@ func TestAutocompleteState_Navigation(t *testing.T) {
 
 func TestAutocompleteState_Select(t *testing.T) {
 	a := NewAutocompleteState()
-	a.Update("/e")
+	a.Update("/ex")
 
 	selected := a.Select()
 	if selected != "/exit" {
@@ -164,7 +170,7 @@ func TestAutocompleteState_Hide(t *testing.T) {
 func TestAutocompleteState_IndexClamp(t *testing.T) {
 	a := NewAutocompleteState()
 
-	// Start with 6 commands
+	// Start with all commands
 	a.Update("/")
 	a.Down()
 	a.Down()
@@ -173,7 +179,7 @@ func TestAutocompleteState_IndexClamp(t *testing.T) {
 	a.Down() // Index = 5
 
 	// Update to show only 1 command
-	a.Update("/e") // Only /exit
+	a.Update("/exi") // Only /exit
 
 	// Index should be clamped to 0
 	if a.Index() != 0 {
@@ -0,0 +1,58 @@
+package chat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// branch sets aside the last exchange as a branch so the conversation can
+// continue differently from before the last user message.
+func (m *Model) branch() error {
+	i := m.lastUserIndex()
+	if i < 0 {
+		return fmt.Errorf("nothing to branch from")
+	}
+	m.truncateMessages(i, true)
+	m.notice = fmt.Sprintf("Started a new branch; %s lists the others", CmdBranches)
+	return nil
+}
+
+// listBranches shows the session's branches as a numbered list.
+func (m *Model) listBranches() {
+	branches := m.session.Branches()
+	if len(branches) < 2 {
+		m.notice = fmt.Sprintf("No other branches; %s or %s starts one", CmdBranch, CmdEdit)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Branches (%s <n> to switch):", CmdBranches))
+	for i, branch := range branches {
+		marker := " "
+		if branch.Active {
+			marker = "*"
+		}
+		preview := branch.Preview
+		if branch.Length == 0 {
+			preview = "(new branch)"
+		}
+		sb.WriteString(fmt.Sprintf("\n%s %d) %d messages  %s", marker, i+1, branch.Length, preview))
+	}
+	m.notice = sb.String()
+}
+
+// switchBranch makes the n-th branch, counting from 1, the active one.
+func (m *Model) switchBranch(arg string) error {
+	branches := m.session.Branches()
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(branches) {
+		return fmt.Errorf("no branch %q; %s lists them", arg, CmdBranches)
+	}
+	if err := m.session.SwitchBranch(branches[n-1].Head); err != nil {
+		m.sessionErr = err
+	}
+	m.loadConversation()
+	m.notice = fmt.Sprintf("Switched to branch %d", n)
+	return nil
+}
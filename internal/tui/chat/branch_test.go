@@ -0,0 +1,46 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBranches(t *testing.T) {
+	m := newTestConversation(t)
+
+	m.listBranches()
+	if !strings.HasPrefix(m.notice, "No other branches") {
+		t.Errorf("notice = %q, want no other branches", m.notice)
+	}
+
+	if err := m.branch(); err != nil {
+		t.Fatalf("branch() error = %v", err)
+	}
+	assertConversation(t, &m, "first", "one")
+
+	m.listBranches()
+	if !strings.Contains(m.notice, "* 1) 2 messages") || !strings.Contains(m.notice, "  2) 4 messages  second") {
+		t.Errorf("notice = %q, want both branches with the active one marked", m.notice)
+	}
+
+	if err := m.switchBranch("2"); err != nil {
+		t.Fatalf("switchBranch() error = %v", err)
+	}
+	assertConversation(t, &m, "first", "one", "second", "two")
+	if m.Usage().TotalTokens != 30 || m.AnsweredBy() != "b/model" {
+		t.Errorf("usage = %d, answered by %q, want totals of the switched-to branch", m.Usage().TotalTokens, m.AnsweredBy())
+	}
+
+	for _, arg := range []string{"0", "3", "two"} {
+		if err := m.switchBranch(arg); err == nil {
+			t.Errorf("switchBranch(%q) error = nil, want error", arg)
+		}
+	}
+
+	// A branch started before the first message is listed as active
+	m.truncateMessages(0, true)
+	m.listBranches()
+	if !strings.Contains(m.notice, "* 2) 0 messages  (new branch)") {
+		t.Errorf("notice = %q, want the empty branch listed as active", m.notice)
+	}
+}
@@ -11,12 +11,22 @@ type Command struct {
 // AvailableCommands returns all available chat commands.
 func AvailableCommands() []Command {
 	return []Command{
+		{Name: CmdAttach, Description: "Attach a file to the next message"},
+		{Name: CmdBranch, Description: "Start a new branch before your last message"},
+		{Name: CmdBranches, Description: "List branches, or switch to branch n"},
 		{Name: CmdClear, Description: "Clear conversation history"},
+		{Name: CmdCompact, Description: "Summarize older messages, optionally with another model"},
+		{Name: CmdContext, Description: "Show context use, or set the strategy for a full context"},
+		{Name: CmdEdit, Description: "Edit and resend your last message"},
 		{Name: CmdExit, Description: "Exit the application"},
+		{Name: CmdExport, Description: "Save the conversation as .md, .html, .json or .txt"},
 		{Name: CmdModels, Description: "Change the AI model"},
 		{Name: CmdNew, Description: "Start a new conversation"},
 		{Name: CmdQuit, Description: "Exit the application"},
 		{Name: CmdResume, Description: "Resume a previous session"},
+		{Name: CmdRetry, Description: "Regenerate the last response, optionally with another model"},
+		{Name: CmdSystem, Description: "Set the system prompt (empty to remove)"},
+		{Name: CmdUndo, Description: "Remove the last exchange"},
 	}
 }
 
@@ -2,10 +2,20 @@ package chat
 
 // Command constants for chat commands.
 const (
-	CmdResume = "/resume"
-	CmdModels = "/models"
-	CmdQuit   = "/quit"
-	CmdExit   = "/exit"
-	CmdNew    = "/new"
-	CmdClear  = "/clear"
+	CmdResume   = "/resume"
+	CmdModels   = "/models"
+	CmdQuit     = "/quit"
+	CmdExit     = "/exit"
+	CmdNew      = "/new"
+	CmdClear    = "/clear"
+	CmdAttach   = "/attach"
+	CmdSystem   = "/system"
+	CmdRetry    = "/retry"
+	CmdEdit     = "/edit"
+	CmdUndo     = "/undo"
+	CmdBranch   = "/branch"
+	CmdBranches = "/branches"
+	CmdExport   = "/export"
+	CmdCompact  = "/compact"
+	CmdContext  = "/context"
 )
@@ -0,0 +1,315 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/config"
+	"github.com/vstratful/openrouter-cli/internal/tokens"
+	"github.com/vstratful/openrouter-cli/internal/tui"
+)
+
+// Context strategies decide what happens as a conversation outgrows the
+// model's context window. They are chosen per session with /context.
+const (
+	// ContextWarn only warns in the footer; the default
+	ContextWarn = "warn"
+	// ContextDropOldest leaves out the oldest messages that don't fit
+	ContextDropOldest = "drop-oldest"
+	// ContextMiddleOut lets OpenRouter drop messages from the middle
+	ContextMiddleOut = "middle-out"
+	// ContextCompact summarizes older messages with the compact model
+	ContextCompact = "compact"
+)
+
+// ContextStrategies lists the context strategies, the default first.
+var ContextStrategies = []string{ContextWarn, ContextDropOldest, ContextMiddleOut, ContextCompact}
+
+const (
+	// contextWarnRatio is the share of the context window at which the
+	// footer warns and the compact strategy compacts.
+	contextWarnRatio = 0.8
+
+	// contextFitRatio is the share of the context window drop-oldest fills,
+	// leaving room for estimates that run low.
+	contextFitRatio = 0.9
+
+	// compactKeep is the number of recent messages compaction keeps as
+	// they are.
+	compactKeep = 4
+
+	// compactPrompt instructs the compact model.
+	compactPrompt = "Summarize the conversation below so that it can continue without the original messages. " +
+		"Keep the user's goals, the decisions made, facts and figures established, code and names that may be " +
+		"referred to again, and open questions. Write concise notes, not a dialogue."
+
+	// summaryPrefix starts the system message holding a compacted summary.
+	summaryPrefix = "Summary of the earlier conversation:\n\n"
+)
+
+// compactDoneMsg carries the summary that replaces the older messages of
+// the conversation, or why it couldn't be made.
+type compactDoneMsg struct {
+	summary string
+	model   string
+	usage   *api.Usage
+	err     error
+}
+
+// ContextStrategy returns the session's context strategy.
+func (m *Model) ContextStrategy() string {
+	if m.session.ContextStrategy == "" {
+		return ContextWarn
+	}
+	return m.session.ContextStrategy
+}
+
+// SetContextStrategy changes the session's context strategy.
+func (m *Model) SetContextStrategy(strategy string) error {
+	if !slices.Contains(ContextStrategies, strategy) {
+		return fmt.Errorf("unknown context strategy %q (use %s)", strategy, strings.Join(ContextStrategies, ", "))
+	}
+	if strategy == ContextWarn {
+		strategy = ""
+	}
+	m.session.ContextStrategy = strategy
+	// Sessions are written once they have messages on any branch
+	if len(m.session.Nodes) > 0 {
+		m.sessionErr = m.session.Save()
+	}
+	return nil
+}
+
+// tokenizer returns the estimation profile of the current model's
+// tokenizer.
+func (m *Model) tokenizer() tokens.Tokenizer {
+	if m.modelDetails == nil {
+		return tokens.Default
+	}
+	return tokens.ForModel(m.modelDetails)
+}
+
+// ContextLength returns the current model's context window in tokens, or 0
+// while it isn't known.
+func (m *Model) ContextLength() int {
+	if m.modelDetails == nil || m.modelDetails.ContextLength == nil {
+		return 0
+	}
+	return *m.modelDetails.ContextLength
+}
+
+// ContextTokens returns the estimated prompt tokens of the next request,
+// including the system prompt.
+func (m *Model) ContextTokens() int {
+	n := m.tokenizer().Messages(api.WithSystemPrompt(m.systemPrompt, nil))
+	if len(m.messageTokens) != len(m.messages) {
+		m.countTokens()
+	}
+	for _, count := range m.messageTokens {
+		n += count
+	}
+	return n
+}
+
+// countTokens re-estimates the tokens of every message, such as when the
+// model's tokenizer becomes known.
+func (m *Model) countTokens() {
+	tokenizer := m.tokenizer()
+	m.messageTokens = make([]int, len(m.messages))
+	for i, msg := range m.messages {
+		m.messageTokens[i] = tokenizer.Message(msg)
+	}
+}
+
+// contextRatio returns the share of the context window the conversation
+// takes up, or 0 while the window isn't known.
+func (m *Model) contextRatio() float64 {
+	limit := m.ContextLength()
+	if limit == 0 {
+		return 0
+	}
+	return float64(m.ContextTokens()) / float64(limit)
+}
+
+// contextInfo returns the estimated context use for the footer, as a
+// warning once the conversation nears the context window.
+func (m *Model) contextInfo() string {
+	limit := m.ContextLength()
+	if limit == 0 || len(m.messages) == 0 {
+		return ""
+	}
+	ratio := m.contextRatio()
+	switch {
+	case ratio >= 1:
+		return tui.SessionWarningStyle.Render("⚠ Context full, /compact")
+	case ratio >= contextWarnRatio:
+		return tui.SessionWarningStyle.Render(fmt.Sprintf("⚠ Context %d%% full, /compact", int(ratio*100)))
+	}
+	return tui.DimHelpStyle.Render(fmt.Sprintf("ctx ~%s/%s", tui.FormatTokenCount(m.ContextTokens()), tui.FormatTokenCount(limit)))
+}
+
+// describeContext shows the estimated context use and the strategy.
+func (m *Model) describeContext() {
+	used := "~" + tui.FormatTokenCount(m.ContextTokens()) + " tokens"
+	if limit := m.ContextLength(); limit > 0 {
+		used = fmt.Sprintf("%s of %s (%d%%)", used, tui.FormatTokenCount(limit), int(m.contextRatio()*100))
+	}
+	m.notice = fmt.Sprintf("Context: %s, strategy %s\nStrategies: %s",
+		used, m.ContextStrategy(), strings.Join(ContextStrategies, ", "))
+}
+
+// fitContext returns the messages to send. Under the drop-oldest strategy
+// these are the most recent messages whose estimate fits the context
+// window, less room for the reply, starting with a user message; the last
+// message is always sent and a compacted summary at the start is kept. It
+// also returns how many messages were left out.
+func (m *Model) fitContext(messages []api.Message) ([]api.Message, int) {
+	limit := m.ContextLength()
+	if m.ContextStrategy() != ContextDropOldest || limit == 0 || len(messages) == 0 {
+		return messages, 0
+	}
+	tokenizer := m.tokenizer()
+	budget := int(float64(limit)*contextFitRatio) - tokenizer.Messages(api.WithSystemPrompt(m.systemPrompt, nil))
+	if m.params.MaxTokens != nil {
+		budget -= *m.params.MaxTokens
+	}
+
+	var summary []api.Message
+	rest := messages
+	if rest[0].Role == "system" {
+		summary, rest = rest[:1], rest[1:]
+		budget -= tokenizer.Message(summary[0])
+	}
+	start := len(rest)
+	for start > 0 {
+		n := tokenizer.Message(rest[start-1])
+		if n > budget {
+			break
+		}
+		budget -= n
+		start--
+	}
+	start = min(start, len(rest)-1)
+	for start < len(rest)-1 && rest[start].Role != "user" {
+		start++
+	}
+	if start <= 0 {
+		return messages, 0
+	}
+	return append(slices.Clone(summary), rest[start:]...), start
+}
+
+// compact summarizes all but the last few messages with model, or with the
+// compact model when empty, and returns a command making the request. The
+// summary replaces the messages when it arrives.
+func (m *Model) compact(model string) (tea.Cmd, error) {
+	older := len(m.messages) - compactKeep
+	if older < 2 {
+		return nil, fmt.Errorf("nothing to compact")
+	}
+	if model == "" {
+		model = m.compactModel
+	}
+	req := &api.ChatRequest{
+		Model: model,
+		Messages: []api.Message{
+			{Role: "system", Content: compactPrompt},
+			{Role: "user", Content: transcript(m.messages[:older])},
+		},
+		Provider: m.provider,
+		Usage:    &api.UsageOptions{Include: true},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.compactCancel = cancel
+	m.state = StateCompacting
+	m.err = nil
+
+	client := m.client
+	return func() tea.Msg {
+		resp, err := client.Chat(ctx, req)
+		if err != nil {
+			return compactDoneMsg{err: fmt.Errorf("failed to compact the conversation: %w", err)}
+		}
+		if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+			return compactDoneMsg{err: fmt.Errorf("failed to compact the conversation: %s returned no summary", model)}
+		}
+		if resp.Model != "" {
+			model = resp.Model
+		}
+		return compactDoneMsg{summary: strings.TrimSpace(resp.Choices[0].Message.Content), model: model, usage: resp.Usage}
+	}, nil
+}
+
+// autoCompact starts a compaction under the compact strategy once the
+// conversation nears the context window.
+func (m *Model) autoCompact() tea.Cmd {
+	if m.ContextStrategy() != ContextCompact || m.contextRatio() < contextWarnRatio {
+		return nil
+	}
+	cmd, err := m.compact("")
+	if err != nil {
+		return nil
+	}
+	return tea.Batch(cmd, m.spinner.Tick)
+}
+
+// cancelCompaction abandons a compaction in progress.
+func (m *Model) cancelCompaction() {
+	if m.compactCancel != nil {
+		m.compactCancel()
+		m.compactCancel = nil
+	}
+	m.state = StateIdle
+}
+
+// applyCompaction replaces the older messages with their summary on a new
+// branch of the session, so the full conversation stays under /branches.
+func (m *Model) applyCompaction(msg compactDoneMsg) {
+	m.compactCancel = nil
+	m.state = StateIdle
+	if msg.err != nil {
+		m.err = msg.err
+		return
+	}
+	replaced := max(len(m.session.Messages)-compactKeep, 0)
+	m.session.Compact(config.SessionMessage{
+		Role:    "system",
+		Content: summaryPrefix + msg.summary,
+		Model:   msg.model,
+		Usage:   msg.usage,
+	}, compactKeep)
+
+	// The session's totals carry on, plus the cost of the summary
+	usage := m.usage
+	m.loadConversation()
+	m.usage = usage
+	m.usage.Add(msg.usage)
+	if err := m.session.Save(); err != nil {
+		m.sessionErr = err
+	}
+	m.notice = fmt.Sprintf("Compacted %d messages into a summary with %s; /branches lists the full conversation", replaced, msg.model)
+}
+
+// transcript writes out messages as plain text for the compact model.
+func transcript(messages []api.Message) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		role := "User"
+		switch msg.Role {
+		case "assistant":
+			role = "Assistant"
+		case "system":
+			role = "System"
+		}
+		sb.WriteString(role + ": " + msg.Content)
+		if labels := attachmentLabels(msg); labels != "" {
+			sb.WriteString("\n" + labels)
+		}
+		sb.WriteString("\n\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
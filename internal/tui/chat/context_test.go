@@ -0,0 +1,183 @@
+package chat
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/config"
+	"github.com/vstratful/openrouter-cli/internal/tokens"
+)
+
+// withContextLength sets the model's context window to n tokens.
+func withContextLength(m *Model, n int) {
+	m.SetModelDetails(&api.Model{ID: m.modelName, ContextLength: &n})
+}
+
+func TestFitContext(t *testing.T) {
+	long := strings.Repeat("a", 400) // 100 tokens
+	messages := []api.Message{
+		{Role: "user", Content: long},
+		{Role: "assistant", Content: long},
+		{Role: "user", Content: long},
+		{Role: "assistant", Content: long},
+		{Role: "user", Content: "q"},
+	}
+	perMessage := tokens.Message(messages[0])
+
+	tests := []struct {
+		name     string
+		strategy string
+		length   int
+		messages []api.Message
+		want     int // Messages sent
+	}{
+		{name: "warn sends everything", strategy: ContextWarn, length: 100, messages: messages, want: 5},
+		{name: "unknown length", strategy: ContextDropOldest, messages: messages, want: 5},
+		{name: "everything fits", strategy: ContextDropOldest, length: 100_000, messages: messages, want: 5},
+		{name: "starts with a user message", strategy: ContextDropOldest, length: 3 * perMessage * 10 / 9, messages: messages, want: 3},
+		{name: "last message always sent", strategy: ContextDropOldest, length: 10, messages: messages, want: 1},
+		{
+			name:     "summary kept",
+			strategy: ContextDropOldest,
+			length:   4 * perMessage * 10 / 9,
+			messages: append([]api.Message{{Role: "system", Content: long}}, messages...),
+			want:     4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(Config{ModelName: "test-model"})
+			m.session.ContextStrategy = tt.strategy
+			if tt.length > 0 {
+				withContextLength(&m, tt.length)
+			}
+			got, dropped := m.fitContext(tt.messages)
+			if len(got) != tt.want || dropped != len(tt.messages)-tt.want {
+				t.Fatalf("fitContext() sent %d, dropped %d, want %d sent", len(got), dropped, tt.want)
+			}
+			if got[len(got)-1].Content != "q" {
+				t.Error("fitContext() should always send the last message")
+			}
+			if tt.messages[0].Role == "system" && got[0].Role != "system" {
+				t.Error("fitContext() should keep the summary")
+			}
+		})
+	}
+}
+
+func TestContextStrategy(t *testing.T) {
+	m := newTestConversation(t)
+	if got := m.ContextStrategy(); got != ContextWarn {
+		t.Errorf("ContextStrategy() = %q, want %q", got, ContextWarn)
+	}
+	if err := m.SetContextStrategy("truncate"); err == nil {
+		t.Error("SetContextStrategy() should reject an unknown strategy")
+	}
+
+	if err := m.SetContextStrategy(ContextMiddleOut); err != nil {
+		t.Fatal(err)
+	}
+	if req := m.buildRequest(m.messages); !reflect.DeepEqual(req.Transforms, []string{api.TransformMiddleOut}) {
+		t.Errorf("Transforms = %v, want middle-out", req.Transforms)
+	}
+	loaded, err := config.LoadSession(m.Session().ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ContextStrategy != ContextMiddleOut {
+		t.Errorf("saved ContextStrategy = %q, want %q", loaded.ContextStrategy, ContextMiddleOut)
+	}
+
+	if err := m.SetContextStrategy(ContextWarn); err != nil {
+		t.Fatal(err)
+	}
+	if req := m.buildRequest(m.messages); req.Transforms != nil {
+		t.Errorf("Transforms = %v, want none", req.Transforms)
+	}
+
+	// Saved while the active branch is empty
+	m.truncateMessages(0, true)
+	if err := m.SetContextStrategy(ContextCompact); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err = config.LoadSession(m.Session().ID); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ContextStrategy != ContextCompact {
+		t.Errorf("saved ContextStrategy with an empty branch = %q, want %q", loaded.ContextStrategy, ContextCompact)
+	}
+}
+
+func TestContextInfo(t *testing.T) {
+	m := newTestConversation(t)
+	if got := m.contextInfo(); got != "" {
+		t.Errorf("contextInfo() = %q, want nothing while the context length is unknown", got)
+	}
+
+	withContextLength(&m, 100_000)
+	if got := m.contextInfo(); !strings.Contains(got, "ctx ~") {
+		t.Errorf("contextInfo() = %q, want the estimate", got)
+	}
+
+	withContextLength(&m, m.ContextTokens()*10/9)
+	if got := m.contextInfo(); !strings.Contains(got, "⚠ Context 9") {
+		t.Errorf("contextInfo() = %q, want a warning", got)
+	}
+
+	// The estimate follows new messages
+	before := m.ContextTokens()
+	m.messages = append(m.messages, api.Message{Role: "user", Content: strings.Repeat("a", 400)})
+	m.appendRenderedMessage(m.messages[len(m.messages)-1])
+	if got := m.ContextTokens(); got <= before {
+		t.Errorf("ContextTokens() = %d after a new message, want more than %d", got, before)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	m := newTestConversation(t)
+	if _, err := m.compact(""); err == nil {
+		t.Error("compact() should fail with nothing but the kept messages")
+	}
+
+	if err := m.session.AppendMessage("user", "third"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.session.AppendMessage("assistant", "three"); err != nil {
+		t.Fatal(err)
+	}
+	m.loadConversation()
+	usage := m.Usage()
+
+	client := api.NewMockClient()
+	client.ChatFunc = func(ctx context.Context, req *api.ChatRequest) (*api.ChatResponse, error) {
+		return &api.ChatResponse{
+			Choices: []api.Choice{{Message: api.ChoiceMessage{Content: "They counted."}}},
+			Usage:   &api.Usage{TotalTokens: 5},
+		}, nil
+	}
+	m.client = client
+
+	cmd, err := m.compact("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.state != StateCompacting {
+		t.Errorf("state = %v, want compacting", m.state)
+	}
+	m.applyCompaction(cmd().(compactDoneMsg))
+
+	req := client.ChatCalls[0].Req
+	if req.Model != config.DefaultCompactModel || !strings.Contains(req.Messages[1].Content, "User: first\n\nAssistant: one") {
+		t.Errorf("request = %+v, want the older messages sent to the compact model", req)
+	}
+	assertConversation(t, &m, summaryPrefix+"They counted.", "second", "two", "third", "three")
+	if m.state != StateIdle || m.Usage().TotalTokens != usage.TotalTokens+5 {
+		t.Errorf("state = %v, usage = %d, want idle with the summary's usage added", m.state, m.Usage().TotalTokens)
+	}
+	if n := len(m.Session().Branches()); n != 2 {
+		t.Errorf("got %d branches, want the full conversation kept", n)
+	}
+}
@@ -0,0 +1,103 @@
+package chat
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/attachment"
+)
+
+// lastUserIndex returns the index of the last user message, or -1.
+func (m *Model) lastUserIndex() int {
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Role == "user" {
+			return i
+		}
+	}
+	return -1
+}
+
+// truncateMessages drops the messages from index n on, keeping the
+// conversation, the rendered history and the saved session in step. With
+// keepBranch the dropped messages stay in the session as a branch.
+func (m *Model) truncateMessages(n int, keepBranch bool) {
+	m.messages = m.messages[:n]
+	if keepBranch {
+		m.sessionErr = m.session.TruncateMessages(n)
+	} else {
+		m.sessionErr = m.session.RemoveMessages(n)
+	}
+	m.usage = m.session.TotalUsage()
+	m.answeredBy = m.session.LastModel()
+	m.servedBy = m.session.LastProvider()
+	m.rebuildRenderedHistory()
+}
+
+// undo removes the last user message and the responses that followed it.
+func (m *Model) undo() error {
+	i := m.lastUserIndex()
+	if i < 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+	m.truncateMessages(i, false)
+	return nil
+}
+
+// regenerate sets aside the responses to the last user message as a branch
+// and requests a new one, from model when it is not empty.
+func (m *Model) regenerate(model string) (tea.Cmd, error) {
+	i := m.lastUserIndex()
+	if i < 0 {
+		return nil, fmt.Errorf("nothing to retry")
+	}
+	var details tea.Cmd
+	if model != "" && model != m.modelName {
+		m.SetModelName(model)
+		details = m.loadModelDetails()
+	}
+	m.truncateMessages(i+1, true)
+
+	m.state = StateStreaming
+	m.currentContent = ""
+	m.currentReasoning = ""
+	m.err = nil
+	return tea.Batch(m.StartStream(), m.spinner.Tick, details), nil
+}
+
+// edit sets aside the last exchange as a branch and loads its user message
+// back into the input, with its attachments pending again, so it can be
+// changed and resent.
+func (m *Model) edit() error {
+	i := m.lastUserIndex()
+	if i < 0 {
+		return fmt.Errorf("nothing to edit")
+	}
+	msg := m.messages[i]
+	m.truncateMessages(i, true)
+
+	m.attachments = editAttachments(msg)
+	m.textarea.SetValue(msg.Content)
+	m.history.Reset()
+	return nil
+}
+
+// editAttachments recovers the attachments of a sent message that resending
+// its text would not attach again. Files referenced inline as @path come
+// last and are loaded afresh on submit, so they are left out.
+func editAttachments(msg api.Message) []*attachment.Attachment {
+	parts := msg.ContentParts
+	if len(parts) > 0 && parts[0].Type == "text" && parts[0].Text == msg.Content {
+		parts = parts[1:]
+	}
+	parts = parts[:max(len(parts)-len(inlineReferences(msg.Content)), 0)]
+
+	var attachments []*attachment.Attachment
+	for _, part := range parts {
+		// Parts whose data is no longer available can't be restored
+		if a, err := attachment.FromContentPart(part); err == nil {
+			attachments = append(attachments, a)
+		}
+	}
+	return attachments
+}
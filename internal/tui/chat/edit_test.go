@@ -0,0 +1,112 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/attachment"
+	"github.com/vstratful/openrouter-cli/internal/config"
+)
+
+// newTestConversation returns a model holding two exchanges, with its
+// session saved to a temporary directory.
+func newTestConversation(t *testing.T) Model {
+	t.Helper()
+	originalGetSessionDir := config.GetSessionDir
+	dir := t.TempDir()
+	config.GetSessionDir = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { config.GetSessionDir = originalGetSessionDir })
+
+	session := config.NewSession()
+	session.SetMessages([]config.SessionMessage{
+		{Role: "user", Content: "first"},
+		{Role: "assistant", Content: "one", Model: "a/model", Usage: &api.Usage{TotalTokens: 10}},
+		{Role: "user", Content: "second"},
+		{Role: "assistant", Content: "two", Model: "b/model", Usage: &api.Usage{TotalTokens: 20}},
+	})
+	if err := session.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return New(Config{Client: api.NewMockClient(), ModelName: "a/model", ExistingSession: session})
+}
+
+// assertConversation checks that the model and its saved session hold the
+// given message contents.
+func assertConversation(t *testing.T, m *Model, want ...string) {
+	t.Helper()
+	loaded, err := config.LoadSession(m.Session().ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.messages) != len(want) || len(loaded.Messages) != len(want) {
+		t.Fatalf("got %d messages, %d saved, want %d", len(m.messages), len(loaded.Messages), len(want))
+	}
+	for i, content := range want {
+		if m.messages[i].Content != content || loaded.Messages[i].Content != content {
+			t.Errorf("message %d = %q (saved %q), want %q", i, m.messages[i].Content, loaded.Messages[i].Content, content)
+		}
+	}
+}
+
+func TestUndo(t *testing.T) {
+	m := newTestConversation(t)
+
+	if err := m.undo(); err != nil {
+		t.Fatalf("undo() error = %v", err)
+	}
+	assertConversation(t, &m, "first", "one")
+	if n := len(m.Session().Branches()); n != 1 {
+		t.Errorf("got %d branches, want the undone exchange removed", n)
+	}
+	if m.Usage().TotalTokens != 10 || m.AnsweredBy() != "a/model" {
+		t.Errorf("usage = %d, answered by %q, want totals of the remaining exchange", m.Usage().TotalTokens, m.AnsweredBy())
+	}
+
+	m.undo()
+	if err := m.undo(); err == nil {
+		t.Error("undo() on an empty conversation should fail")
+	}
+}
+
+func TestEdit(t *testing.T) {
+	m := newTestConversation(t)
+
+	if err := m.edit(); err != nil {
+		t.Fatalf("edit() error = %v", err)
+	}
+	assertConversation(t, &m, "first", "one")
+	if got := m.textarea.Value(); got != "second" {
+		t.Errorf("input = %q, want the edited message", got)
+	}
+	if n := len(m.Session().Branches()); n != 2 {
+		t.Errorf("got %d branches, want the edited exchange kept as a branch", n)
+	}
+}
+
+func TestRegenerate(t *testing.T) {
+	m := newTestConversation(t)
+
+	cmd, err := m.regenerate("c/model")
+	if err != nil || cmd == nil {
+		t.Fatalf("regenerate() = %v, %v, want a stream command", cmd, err)
+	}
+	m.activeStream.Cancel()
+	assertConversation(t, &m, "first", "one", "second")
+	if m.state != StateStreaming || m.ModelName() != "c/model" {
+		t.Errorf("state = %v, model = %q, want streaming from c/model", m.state, m.ModelName())
+	}
+}
+
+func TestEditAttachments(t *testing.T) {
+	image, _ := attachment.New("cat.png", []byte("png"))
+	notes, _ := attachment.New("notes.txt", []byte("hi"))
+	msg := attachment.Message("Compare these", []*attachment.Attachment{image, notes})
+
+	got := editAttachments(msg)
+	if len(got) != 2 || got[0].Kind != attachment.KindImage || got[1].Name != "notes.txt" {
+		t.Errorf("editAttachments() = %+v, want the image and notes", got)
+	}
+	if got := editAttachments(api.Message{Role: "user", Content: "plain"}); len(got) != 0 {
+		t.Errorf("editAttachments() = %+v, want none for plain text", got)
+	}
+}
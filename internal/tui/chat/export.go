@@ -0,0 +1,33 @@
+package chat
+
+import (
+	"fmt"
+
+	"github.com/vstratful/openrouter-cli/internal/attachment"
+	"github.com/vstratful/openrouter-cli/internal/export"
+)
+
+// exportConversation writes the conversation to path, in the format its
+// extension names, or to a Markdown file named after the session in the
+// current directory.
+func (m *Model) exportConversation(path string) error {
+	if len(m.session.Messages) == 0 {
+		return fmt.Errorf("nothing to export")
+	}
+	if path == "" {
+		id := m.session.ID
+		if len(id) > 8 {
+			id = id[:8]
+		}
+		path = fmt.Sprintf("chat-%s.%s", id, export.FormatMarkdown)
+	}
+	path, err := attachment.ExpandHome(path)
+	if err != nil {
+		return err
+	}
+	if err := export.WriteFile(path, m.session); err != nil {
+		return err
+	}
+	m.notice = "Exported the conversation to " + path
+	return nil
+}
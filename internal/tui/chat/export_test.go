@@ -0,0 +1,34 @@
+package chat
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+func TestExportConversation(t *testing.T) {
+	m := newTestConversation(t)
+	path := filepath.Join(t.TempDir(), "chat.html")
+
+	if err := m.exportConversation(path); err != nil {
+		t.Fatalf("exportConversation() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "<!DOCTYPE html>") || !strings.Contains(string(data), "second") {
+		t.Errorf("export is not an HTML transcript of the conversation:\n%s", data)
+	}
+	if !strings.Contains(m.notice, path) {
+		t.Errorf("notice = %q, want the export path", m.notice)
+	}
+
+	empty := New(Config{Client: api.NewMockClient(), ModelName: "a/model"})
+	if err := empty.exportConversation(path); err == nil {
+		t.Error("exportConversation() of an empty conversation should fail")
+	}
+}
@@ -2,7 +2,7 @@ package chat
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -11,6 +11,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/attachment"
 	"github.com/vstratful/openrouter-cli/internal/config"
 	"github.com/vstratful/openrouter-cli/internal/tui"
 )
@@ -19,10 +20,12 @@ const maxTextareaHeight = 5
 
 // Message types for tea.Msg
 type (
-	StreamChunkMsg string
-	StreamDoneMsg  string
-	StreamErrMsg   struct{ Err error }
-	EscTimeoutMsg  struct{}
+	StreamChunkMsg     string
+	StreamReasoningMsg string
+	StreamRetryMsg     api.RetryEvent
+	StreamDoneMsg      string
+	StreamErrMsg       struct{ Err error }
+	EscTimeoutMsg      struct{}
 )
 
 // Model is the Bubble Tea model for the chat TUI.
@@ -39,20 +42,62 @@ type Model struct {
 	state          ChatState
 	escState       escState
 	currentContent string
-	err            error
-	sessionErr     error // Session save error (shown as warning in footer)
-	ready          bool
-	width          int
-	height         int
+	// currentReasoning accumulates streamed thinking for the in-flight response
+	currentReasoning string
+	// retry is the pending retry of the in-flight request, if any
+	retry      *api.RetryEvent
+	retryAt    time.Time
+	err        error
+	sessionErr error  // Session save error (shown as warning in footer)
+	notice     string // Output of the last command, shown until the next message
+	ready      bool
+	width      int
+	height     int
 
 	// Messages
 	messages []api.Message
+	// messageTokens holds the estimated tokens of each message
+	messageTokens []int
 
 	// Session
 	session   *config.Session
 	modelName string
+	fallbacks []string
+	params    api.SamplingParams
+	reasoning *api.ReasoningOptions
+	provider  *api.ProviderPreferences
 	isResumed bool
 
+	// systemPrompt is sent as a system message before the conversation
+	systemPrompt string
+
+	// compactModel summarizes older messages for /compact, and
+	// compactCancel abandons a compaction in progress
+	compactModel  string
+	compactCancel context.CancelFunc
+
+	// modelDetails is the current model's metadata, used to check
+	// attachments against its input modalities and to track the context
+	// window
+	modelDetails *api.Model
+
+	// detailsFor is the model whose lookup last finished, with or without
+	// details, and awaitingDetails holds a message with attachments until
+	// the lookup for the current model does
+	detailsFor      string
+	awaitingDetails bool
+
+	// attachments are files attached to the next message
+	attachments []*attachment.Attachment
+
+	// Running token usage and cost for the session
+	usage api.Usage
+
+	// answeredBy and servedBy are the model and upstream provider that
+	// produced the last response
+	answeredBy string
+	servedBy   string
+
 	// History navigation
 	history *HistoryNavigator
 
@@ -62,6 +107,9 @@ type Model struct {
 	// Input summary mode (for very long text)
 	showingSummary bool
 
+	// showReasoning expands reasoning sections of completed messages
+	showReasoning bool
+
 	// Markdown renderer
 	mdRenderer *tui.MarkdownRenderer
 
@@ -78,9 +126,17 @@ type Model struct {
 }
 
 // Config holds configuration for creating a new chat model.
+// SystemPrompt, when set, replaces the existing session's system prompt.
+// CompactModel defaults to config.DefaultCompactModel.
 type Config struct {
 	Client          api.Client
 	ModelName       string
+	Fallbacks       []string
+	Params          api.SamplingParams
+	Reasoning       *api.ReasoningOptions
+	Provider        *api.ProviderPreferences
+	SystemPrompt    string
+	CompactModel    string
 	ExistingSession *config.Session
 }
 
@@ -90,9 +146,9 @@ func New(cfg Config) Model {
 	ta.Placeholder = "Type your message..."
 	ta.Focus()
 	ta.Prompt = ""
-	ta.CharLimit = 0                              // No limit
-	ta.SetWidth(config.DefaultTerminalWidth)      // Default width, will be updated on WindowSizeMsg
-	ta.SetHeight(1)  // Start at 1 line, grows dynamically
+	ta.CharLimit = 0                         // No limit
+	ta.SetWidth(config.DefaultTerminalWidth) // Default width, will be updated on WindowSizeMsg
+	ta.SetHeight(1)                          // Start at 1 line, grows dynamically
 	ta.ShowLineNumbers = false
 	ta.KeyMap.InsertNewline.SetEnabled(false)
 	// Disable built-in arrow key handling for history navigation
@@ -111,6 +167,11 @@ func New(cfg Config) Model {
 		spinner:      sp,
 		client:       cfg.Client,
 		modelName:    cfg.ModelName,
+		fallbacks:    cfg.Fallbacks,
+		params:       cfg.Params,
+		reasoning:    cfg.Reasoning,
+		provider:     cfg.Provider,
+		compactModel: cfg.CompactModel,
 		messages:     []api.Message{},
 		history:      NewHistoryNavigator(),
 		autocomplete: NewAutocompleteState(),
@@ -121,26 +182,42 @@ func New(cfg Config) Model {
 	if cfg.ExistingSession != nil {
 		m.session = cfg.ExistingSession
 		m.isResumed = true
-		// Restore messages from session
-		for _, msg := range cfg.ExistingSession.Messages {
-			m.messages = append(m.messages, api.Message{
-				Role:    msg.Role,
-				Content: msg.Content,
-			})
-		}
+		// Restore messages from session; missing attachments are reported
+		// as a session warning
+		m.messages, m.sessionErr = cfg.ExistingSession.APIMessages()
 		// Set history from session
 		m.history.SetHistory(cfg.ExistingSession.History)
+		m.usage = cfg.ExistingSession.TotalUsage()
+		m.answeredBy = cfg.ExistingSession.LastModel()
+		m.servedBy = cfg.ExistingSession.LastProvider()
 	} else {
 		m.session = config.NewSession()
-		m.session.Model = cfg.ModelName
 	}
+	if m.compactModel == "" {
+		m.compactModel = config.DefaultCompactModel
+	}
+	m.lockSession()
+	m.session.SetModelChain(m.ModelChain())
+	if cfg.SystemPrompt != "" {
+		m.session.SystemPrompt = cfg.SystemPrompt
+	}
+	m.systemPrompt = m.session.SystemPrompt
 
 	return m
 }
 
-// Init initializes the chat model.
+// lockSession locks the session for as long as the chat has it open. If
+// another process has it open, the session is not saved and the footer
+// says so.
+func (m *Model) lockSession() {
+	if err := m.session.Lock(); err != nil {
+		m.sessionErr = err
+	}
+}
+
+// Init initializes the chat model and looks up the model's context window.
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(textarea.Blink, m.spinner.Tick)
+	return tea.Batch(textarea.Blink, m.spinner.Tick, m.loadModelDetails())
 }
 
 // Session returns the current session.
@@ -154,9 +231,61 @@ func (m *Model) ModelName() string {
 }
 
 // SetModelName sets the model name and updates the session.
+// Any fallback models are dropped.
 func (m *Model) SetModelName(name string) {
 	m.modelName = name
-	m.session.Model = name
+	m.fallbacks = nil
+	m.modelDetails = nil
+	m.session.SetModelChain(m.ModelChain())
+}
+
+// ModelChain returns the primary model followed by its fallbacks.
+func (m *Model) ModelChain() []string {
+	return append([]string{m.modelName}, m.fallbacks...)
+}
+
+// Params returns the sampling parameters sent with each request.
+func (m *Model) Params() api.SamplingParams {
+	return m.params
+}
+
+// buildRequest creates a streaming request for the current model and settings.
+func (m *Model) buildRequest(messages []api.Message) *api.ChatRequest {
+	req := &api.ChatRequest{
+		Messages:       api.WithSystemPrompt(m.systemPrompt, messages),
+		Stream:         true,
+		SamplingParams: m.params,
+		Reasoning:      m.reasoning,
+		Provider:       m.provider,
+		Usage:          &api.UsageOptions{Include: true},
+	}
+	if m.ContextStrategy() == ContextMiddleOut {
+		req.Transforms = []string{api.TransformMiddleOut}
+	}
+	req.SetModels(m.ModelChain())
+	return req
+}
+
+// ParameterNames returns the optional API parameters sent with each request.
+func (m *Model) ParameterNames() []string {
+	return m.buildRequest(nil).ParameterNames()
+}
+
+// SystemPrompt returns the system prompt sent before the conversation.
+func (m *Model) SystemPrompt() string {
+	return m.systemPrompt
+}
+
+// SetSystemPrompt changes the system prompt for the following requests and
+// records it in the session. An empty prompt removes it.
+func (m *Model) SetSystemPrompt(prompt string) {
+	m.systemPrompt = prompt
+	m.session.SystemPrompt = prompt
+	// Sessions are written once they have messages on any branch
+	if len(m.session.Nodes) > 0 {
+		m.sessionErr = m.session.Save()
+	}
+	m.rebuildRenderedHistory()
 }
 
 // IsResumed returns whether this is a resumed session.
@@ -174,24 +303,56 @@ func (m *Model) SetMessages(messages []api.Message) {
 	m.messages = messages
 }
 
-// SetSession sets a new session.
-func (m *Model) SetSession(session *config.Session) {
+// Close releases the session's lock once the chat has quit.
+func (m *Model) Close() {
+	m.session.Unlock()
+}
+
+// SetSession sets a new session, unlocking the previous one. If the session
+// changes the model, it returns a command looking up the model's details.
+func (m *Model) SetSession(session *config.Session) tea.Cmd {
+	m.session.Unlock()
 	m.session = session
 	m.isResumed = true
-	m.messages = []api.Message{}
-	for _, msg := range session.Messages {
-		m.messages = append(m.messages, api.Message{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
-	}
 	m.history.SetHistory(session.History)
-	if session.Model != "" {
-		m.modelName = session.Model
+	m.systemPrompt = session.SystemPrompt
+	var cmd tea.Cmd
+	if chain := session.ModelChain(); len(chain) > 0 {
+		if chain[0] != m.modelName {
+			m.modelDetails = nil
+			cmd = m.loadModelDetails()
+		}
+		m.modelName = chain[0]
+		m.fallbacks = chain[1:]
 	}
-	// Invalidate cache - will be rebuilt on next updateViewportContent
-	m.renderedHistory = ""
-	m.renderedWidth = 0
+	m.loadConversation()
+	m.lockSession()
+	return cmd
+}
+
+// loadConversation rebuilds the conversation and its totals from the
+// session's active branch.
+func (m *Model) loadConversation() {
+	m.messages, m.sessionErr = m.session.APIMessages()
+	m.usage = m.session.TotalUsage()
+	m.answeredBy = m.session.LastModel()
+	m.servedBy = m.session.LastProvider()
+	m.rebuildRenderedHistory()
+}
+
+// Usage returns the token usage and cost accumulated in this session.
+func (m *Model) Usage() api.Usage {
+	return m.usage
+}
+
+// AnsweredBy returns the model that answered the last response.
+func (m *Model) AnsweredBy() string {
+	return m.answeredBy
+}
+
+// ServedBy returns the upstream provider that served the last response.
+func (m *Model) ServedBy() string {
+	return m.servedBy
 }
 
 // Err returns the last error.
@@ -215,17 +376,16 @@ func (m *Model) StartStream() tea.Cmd {
 	// Capture what we need in local variables to avoid pointer issues
 	stream := m.activeStream
 	client := m.client
-	modelName := m.modelName
-	messages := m.messages
+	messages, dropped := m.fitContext(m.messages)
+	if dropped > 0 {
+		m.notice = fmt.Sprintf("Left out the %d oldest messages to fit the context window", dropped)
+	}
+	req := m.buildRequest(messages)
 
 	return func() tea.Msg {
 		go func() {
-			ctx := context.Background()
-			reader, err := client.ChatStream(ctx, &api.ChatRequest{
-				Model:    modelName,
-				Messages: messages,
-				Stream:   true,
-			})
+			ctx := api.WithRetryNotifier(stream.Context(), stream.SendRetry)
+			reader, err := client.ChatStream(ctx, req)
 			if err != nil {
 				stream.SendError(err)
 				stream.Close()
@@ -242,6 +402,21 @@ func (m *Model) StartStream() tea.Cmd {
 				if chunk == nil || chunk.Done {
 					break
 				}
+				if chunk.Usage != nil {
+					stream.SetUsage(chunk.Usage)
+				}
+				if chunk.Model != "" {
+					stream.SetModel(chunk.Model)
+				}
+				if chunk.Provider != "" {
+					stream.SetProvider(chunk.Provider)
+				}
+				if len(chunk.Images) > 0 {
+					stream.AddImages(chunk.Images)
+				}
+				if chunk.Reasoning != "" {
+					stream.SendReasoning(chunk.Reasoning)
+				}
 				if chunk.Content != "" {
 					stream.SendChunk(chunk.Content)
 				}
@@ -271,14 +446,18 @@ func waitForChunk(stream *StreamState) tea.Msg {
 			}
 			return StreamDoneMsg("")
 		}
-		return StreamChunkMsg(chunk)
+		if chunk.Retry != nil {
+			return StreamRetryMsg(*chunk.Retry)
+		}
+		if chunk.Reasoning != "" {
+			return StreamReasoningMsg(chunk.Reasoning)
+		}
+		return StreamChunkMsg(chunk.Content)
 	case err := <-stream.ErrChan():
 		if err != nil {
 			return StreamErrMsg{Err: err}
 		}
 		return waitForChunk(stream)
-	case <-time.After(config.StreamChunkTimeout):
-		return StreamErrMsg{Err: errors.New("stream timeout: no data received for 30 seconds")}
 	}
 }
 
@@ -0,0 +1,79 @@
+package chat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vstratful/openrouter-cli/internal/config"
+)
+
+func TestSystemPrompt(t *testing.T) {
+	session := config.NewSession()
+	session.SystemPrompt = "Be brief."
+
+	t.Run("restored from session", func(t *testing.T) {
+		m := New(Config{ModelName: "test-model", ExistingSession: session})
+		req := m.buildRequest(nil)
+		if len(req.Messages) != 1 || req.Messages[0].Role != "system" || req.Messages[0].Content != "Be brief." {
+			t.Errorf("Messages = %+v, want the session's system prompt", req.Messages)
+		}
+	})
+
+	t.Run("config overrides session", func(t *testing.T) {
+		m := New(Config{ModelName: "test-model", SystemPrompt: "Be verbose.", ExistingSession: session})
+		if got := m.SystemPrompt(); got != "Be verbose." {
+			t.Errorf("SystemPrompt() = %q, want %q", got, "Be verbose.")
+		}
+		if session.SystemPrompt != "Be verbose." {
+			t.Errorf("session SystemPrompt = %q, want it updated", session.SystemPrompt)
+		}
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		m := New(Config{ModelName: "test-model", SystemPrompt: "Be brief."})
+		m.SetSystemPrompt("")
+		if req := m.buildRequest(nil); len(req.Messages) != 0 {
+			t.Errorf("Messages = %+v, want none", req.Messages)
+		}
+		if m.Session().SystemPrompt != "" {
+			t.Errorf("session SystemPrompt = %q, want empty", m.Session().SystemPrompt)
+		}
+	})
+
+	t.Run("saved with an empty branch", func(t *testing.T) {
+		m := newTestConversation(t)
+		m.truncateMessages(0, true)
+		m.SetSystemPrompt("Be brief.")
+		loaded, err := config.LoadSession(m.Session().ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.SystemPrompt != "Be brief." {
+			t.Errorf("saved SystemPrompt = %q, want the new prompt", loaded.SystemPrompt)
+		}
+	})
+}
+
+func TestCloseReleasesLock(t *testing.T) {
+	m := newTestConversation(t)
+	dir, err := config.GetSessionDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lockPath := filepath.Join(dir, m.Session().ID+".lock")
+	if _, err := os.Stat(lockPath); err != nil {
+		t.Fatalf("open session has no lock file: %v", err)
+	}
+
+	m.textarea.SetValue(CmdQuit)
+	model, cmd := m.handleSubmit()
+	if cmd == nil {
+		t.Fatal("/quit returned no command")
+	}
+	m = model.(Model)
+	m.Close()
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("lock file left after quitting: %v", err)
+	}
+}
@@ -12,6 +12,8 @@ const (
 	StateStreaming
 	// StateEscPending indicates waiting for a second ESC press.
 	StateEscPending
+	// StateCompacting indicates older messages are being summarized.
+	StateCompacting
 )
 
 // EscAction represents the action to take on double ESC press.
@@ -39,6 +41,8 @@ func (s ChatState) String() string {
 		return "streaming"
 	case StateEscPending:
 		return "esc_pending"
+	case StateCompacting:
+		return "compacting"
 	default:
 		return "unknown"
 	}
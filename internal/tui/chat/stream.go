@@ -1,33 +1,57 @@
 package chat
 
 import (
+	"context"
 	"sync"
 
 	"github.com/vstratful/openrouter-cli/internal/api"
 	"github.com/vstratful/openrouter-cli/internal/config"
 )
 
+// StreamDelta is a piece of streamed output: answer content or reasoning,
+// or a notice that the request is waiting to be retried.
+type StreamDelta struct {
+	Content   string
+	Reasoning string
+	Retry     *api.RetryEvent
+}
+
 // StreamState manages the state of an active stream.
 // This replaces the global activeStream variable for better encapsulation.
 type StreamState struct {
 	mu        sync.Mutex
-	chunks    chan string
+	chunks    chan StreamDelta
 	errChan   chan error
 	done      bool
 	cancelled bool // track explicit user cancellation
 	reader    *api.StreamReader
+	usage     *api.Usage
+	model     string
+	provider  string
+	images    []api.ImageContent
+	ctx       context.Context
+	cancel    context.CancelFunc
 }
 
 // NewStreamState creates a new StreamState.
 func NewStreamState() *StreamState {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &StreamState{
-		chunks:  make(chan string, config.StreamChannelBuffer),
+		chunks:  make(chan StreamDelta, config.StreamChannelBuffer),
 		errChan: make(chan error, 1),
+		ctx:     ctx,
+		cancel:  cancel,
 	}
 }
 
+// Context returns the context for the stream's request, which is
+// cancelled by Cancel.
+func (s *StreamState) Context() context.Context {
+	return s.ctx
+}
+
 // Chunks returns the channel for receiving stream chunks.
-func (s *StreamState) Chunks() <-chan string {
+func (s *StreamState) Chunks() <-chan StreamDelta {
 	return s.chunks
 }
 
@@ -43,9 +67,75 @@ func (s *StreamState) SetReader(reader *api.StreamReader) {
 	s.reader = reader
 }
 
-// SendChunk sends a chunk to the chunks channel.
+// SetUsage records the usage reported at the end of the stream.
+func (s *StreamState) SetUsage(usage *api.Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage = usage
+}
+
+// Usage returns the usage reported by the stream, or nil if none was received.
+func (s *StreamState) Usage() *api.Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage
+}
+
+// SetModel records the model answering the stream.
+func (s *StreamState) SetModel(model string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.model = model
+}
+
+// Model returns the model answering the stream, if reported.
+func (s *StreamState) Model() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.model
+}
+
+// SetProvider records the upstream provider serving the stream.
+func (s *StreamState) SetProvider(provider string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.provider = provider
+}
+
+// Provider returns the upstream provider serving the stream, if reported.
+func (s *StreamState) Provider() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.provider
+}
+
+// AddImages records images generated in the stream.
+func (s *StreamState) AddImages(images []api.ImageContent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.images = append(s.images, images...)
+}
+
+// Images returns the images generated in the stream.
+func (s *StreamState) Images() []api.ImageContent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.images
+}
+
+// SendChunk sends a chunk of answer content to the chunks channel.
 func (s *StreamState) SendChunk(chunk string) {
-	s.chunks <- chunk
+	s.chunks <- StreamDelta{Content: chunk}
+}
+
+// SendRetry reports that the request is waiting to be retried.
+func (s *StreamState) SendRetry(event api.RetryEvent) {
+	s.chunks <- StreamDelta{Retry: &event}
+}
+
+// SendReasoning sends a chunk of reasoning text to the chunks channel.
+func (s *StreamState) SendReasoning(text string) {
+	s.chunks <- StreamDelta{Reasoning: text}
 }
 
 // SendError sends an error to the error channel.
@@ -68,6 +158,7 @@ func (s *StreamState) Close() {
 		if s.reader != nil {
 			s.reader.Close()
 		}
+		s.cancel()
 	}
 }
 
@@ -78,15 +169,13 @@ func (s *StreamState) IsDone() bool {
 	return s.done
 }
 
-// Cancel cancels the stream by closing the reader.
+// Cancel cancels the stream's request context, which aborts a pending
+// request or retry wait and ends the reader.
 func (s *StreamState) Cancel() {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	s.cancelled = true
-	if s.reader != nil && !s.done {
-		s.reader.Close()
-	}
+	s.mu.Unlock()
+	s.cancel()
 }
 
 // IsCancelled returns whether the stream was explicitly cancelled by the user.
@@ -1,6 +1,7 @@
 package chat
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/attachment"
 	"github.com/vstratful/openrouter-cli/internal/config"
 )
 
@@ -47,11 +49,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.activeStream.Cancel()
 				// Save partial response to messages so it persists
 				if m.currentContent != "" {
-					assistantMsg := api.Message{Role: "assistant", Content: m.currentContent}
+					assistantMsg := api.Message{Role: "assistant", Content: m.currentContent, Reasoning: m.currentReasoning}
 					m.messages = append(m.messages, assistantMsg)
 					m.appendRenderedMessage(assistantMsg)
 					// Save to session for resume
-					if err := m.session.AppendMessage("assistant", m.currentContent); err != nil {
+					if err := m.session.AppendSessionMessage(config.SessionMessage{
+						Role:      "assistant",
+						Content:   m.currentContent,
+						Reasoning: m.currentReasoning,
+					}); err != nil {
 						m.sessionErr = err
 					} else {
 						m.sessionErr = nil
@@ -59,10 +65,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				m.state = StateIdle
 				m.currentContent = ""
+				m.currentReasoning = ""
+				m.retry = nil
+				m.updateViewportContent()
+				return m, nil
+			}
+			if m.state == StateCompacting {
+				m.cancelCompaction()
+				m.notice = "Compaction cancelled"
 				m.updateViewportContent()
 				return m, nil
 			}
 			return m.handleEsc()
+		case tea.KeyCtrlT:
+			// Toggle expanded reasoning sections
+			m.showReasoning = !m.showReasoning
+			m.rebuildRenderedHistory()
+			m.updateViewportContent()
+			return m, nil
 		case tea.KeyCtrlU:
 			// Unix standard: clear line
 			m.textarea.Reset()
@@ -109,7 +129,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 		case tea.KeyEnter:
-			if m.state == StateStreaming {
+			if m.state == StateStreaming || m.state == StateCompacting {
 				return m, nil
 			}
 			return m.handleSubmit()
@@ -147,9 +167,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		textareaHeight := m.textarea.Height()
 
 		headerHeight := 1
-		inputBoxHeight := textareaHeight + 2 // textarea + border
-		footerHeight := 1
-		verticalMargins := headerHeight + inputBoxHeight + footerHeight + 1
+		verticalMargins := m.verticalMargins(textareaHeight)
 
 		if !m.ready {
 			m.viewport = viewport.New(msg.Width, msg.Height-verticalMargins)
@@ -167,31 +185,96 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.state != StateStreaming {
 			return m, nil
 		}
+		m.retry = nil
 		m.currentContent += string(msg)
 		m.updateViewportContent()
 		return m, m.WaitForChunk()
 
+	case StreamReasoningMsg:
+		if m.state != StateStreaming {
+			return m, nil
+		}
+		m.retry = nil
+		m.currentReasoning += string(msg)
+		m.updateViewportContent()
+		return m, m.WaitForChunk()
+
+	case StreamRetryMsg:
+		if m.state != StateStreaming {
+			return m, nil
+		}
+		event := api.RetryEvent(msg)
+		m.retry = &event
+		m.retryAt = time.Now().Add(event.Delay)
+		return m, m.WaitForChunk()
+
 	case StreamDoneMsg:
 		// Ignore if we're not streaming (was cancelled)
 		if m.state != StateStreaming {
 			return m, nil
 		}
-		if m.currentContent != "" {
-			msg := api.Message{Role: "assistant", Content: m.currentContent}
+		var usage *api.Usage
+		var answeredBy, provider string
+		var images []api.ImageContent
+		if m.activeStream != nil {
+			usage = m.activeStream.Usage()
+			answeredBy = m.activeStream.Model()
+			provider = m.activeStream.Provider()
+			images = m.activeStream.Images()
+		}
+		if answeredBy != "" {
+			m.answeredBy = answeredBy
+		}
+		if provider != "" {
+			m.servedBy = provider
+		}
+		m.usage.Add(usage)
+		if m.currentContent != "" || len(images) > 0 {
+			msg := api.Message{Role: "assistant", Content: m.currentContent, Reasoning: m.currentReasoning, Images: images}
 			m.messages = append(m.messages, msg)
 			m.appendRenderedMessage(msg) // Add to rendered cache
-			// Save assistant message to session for resume
-			if err := m.session.AppendMessage("assistant", m.currentContent); err != nil {
-				m.sessionErr = err
-			} else {
-				m.sessionErr = nil // Clear on success
+			// Save assistant message to session for resume; generated
+			// images move into the blob store
+			stored, err := config.NewSessionMessage(msg)
+			if err == nil {
+				stored.Model = answeredBy
+				stored.Provider = provider
+				stored.Usage = usage
+				err = m.session.AppendSessionMessage(stored)
 			}
+			m.sessionErr = err // Cleared on success
 		}
 		m.state = StateIdle
 		m.currentContent = ""
+		m.currentReasoning = ""
+		m.retry = nil
+		m.updateViewportContent()
+		return m, m.autoCompact()
+
+	case compactDoneMsg:
+		// Ignore a compaction that was cancelled
+		if m.state != StateCompacting {
+			return m, nil
+		}
+		m.applyCompaction(msg)
 		m.updateViewportContent()
 		return m, nil
 
+	case modelDetailsMsg:
+		// Ignore info for a model that is no longer selected
+		if msg.name != m.modelName {
+			return m, nil
+		}
+		m.detailsFor = msg.name
+		if msg.model != nil {
+			m.SetModelDetails(msg.model)
+		}
+		if m.awaitingDetails {
+			m.awaitingDetails = false
+			return m.handleSubmit()
+		}
+		return m, nil
+
 	case StreamErrMsg:
 		// Ignore errors if we're not streaming (was cancelled)
 		if m.state != StateStreaming {
@@ -200,6 +283,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.err = msg.Err
 		m.state = StateIdle
 		m.currentContent = ""
+		m.currentReasoning = ""
+		m.retry = nil
 		m.updateViewportContent()
 		return m, nil
 
@@ -210,7 +295,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case spinner.TickMsg:
-		if m.state == StateStreaming {
+		if m.state == StateStreaming || m.state == StateCompacting {
 			m.spinner, spCmd = m.spinner.Update(msg)
 			return m, spCmd
 		}
@@ -228,7 +313,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // handleEsc handles ESC key press with double-press detection.
 func (m Model) handleEsc() (tea.Model, tea.Cmd) {
-	isEmpty := strings.TrimSpace(m.textarea.Value()) == ""
+	isEmpty := strings.TrimSpace(m.textarea.Value()) == "" && len(m.attachments) == 0
 	now := time.Now()
 
 	if m.state == StateEscPending && now.Sub(m.escState.pressedAt) < config.EscDoublePressTimeout {
@@ -236,8 +321,9 @@ func (m Model) handleEsc() (tea.Model, tea.Cmd) {
 		if m.escState.action == EscActionExit {
 			return m, tea.Quit
 		}
-		// Clear input
+		// Clear input and pending attachments
 		m.textarea.Reset()
+		m.attachments = nil
 		m.updateTextareaState()
 		m.state = StateIdle
 		m.history.Reset()
@@ -263,6 +349,8 @@ func (m Model) handleSubmit() (tea.Model, tea.Cmd) {
 	if userInput == "" {
 		return m, nil
 	}
+	m.notice = ""
+	m.awaitingDetails = false
 
 	// Handle /resume command - signal to parent
 	if userInput == CmdResume {
@@ -285,19 +373,155 @@ func (m Model) handleSubmit() (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	}
 
+	// Handle /attach <path>
+	if path, ok := commandArg(userInput, CmdAttach); ok {
+		m.textarea.Reset()
+		if path == "" {
+			m.err = fmt.Errorf("usage: %s <path>", CmdAttach)
+			m.updateTextareaState()
+			return m, nil
+		}
+		cmd := m.attach(path)
+		m.updateTextareaState()
+		return m, cmd
+	}
+
+	// Handle /system [prompt]
+	if prompt, ok := commandArg(userInput, CmdSystem); ok {
+		m.textarea.Reset()
+		m.updateTextareaState()
+		m.SetSystemPrompt(prompt)
+		m.updateViewportContent()
+		return m, nil
+	}
+
+	// Handle /retry [model]
+	if model, ok := commandArg(userInput, CmdRetry); ok {
+		m.textarea.Reset()
+		m.updateTextareaState()
+		cmd, err := m.regenerate(model)
+		if err != nil {
+			m.err = err
+		}
+		m.updateViewportContent()
+		return m, cmd
+	}
+
+	// Handle /edit and /undo
+	if userInput == CmdEdit || userInput == CmdUndo {
+		m.textarea.Reset()
+		var err error
+		if userInput == CmdEdit {
+			err = m.edit()
+		} else {
+			err = m.undo()
+		}
+		m.err = err
+		m.updateTextareaState()
+		m.updateViewportContent()
+		return m, nil
+	}
+
+	// Handle /branch
+	if userInput == CmdBranch {
+		m.textarea.Reset()
+		m.updateTextareaState()
+		m.err = m.branch()
+		m.updateViewportContent()
+		return m, nil
+	}
+
+	// Handle /branches [n]
+	if arg, ok := commandArg(userInput, CmdBranches); ok {
+		m.textarea.Reset()
+		m.updateTextareaState()
+		m.err = nil
+		if arg == "" {
+			m.listBranches()
+		} else {
+			m.err = m.switchBranch(arg)
+		}
+		m.updateViewportContent()
+		return m, nil
+	}
+
+	// Handle /compact [model]
+	if model, ok := commandArg(userInput, CmdCompact); ok {
+		m.textarea.Reset()
+		m.updateTextareaState()
+		cmd, err := m.compact(model)
+		m.err = err
+		m.updateViewportContent()
+		if cmd == nil {
+			return m, nil
+		}
+		return m, tea.Batch(cmd, m.spinner.Tick)
+	}
+
+	// Handle /context [strategy]
+	if strategy, ok := commandArg(userInput, CmdContext); ok {
+		m.textarea.Reset()
+		m.updateTextareaState()
+		m.err = nil
+		if strategy == "" {
+			m.describeContext()
+		} else if m.err = m.SetContextStrategy(strategy); m.err == nil {
+			m.notice = "Context strategy set to " + strategy
+		}
+		m.updateViewportContent()
+		return m, nil
+	}
+
+	// Handle /export [path]
+	if path, ok := commandArg(userInput, CmdExport); ok {
+		m.textarea.Reset()
+		m.updateTextareaState()
+		m.err = m.exportConversation(path)
+		m.updateViewportContent()
+		return m, nil
+	}
+
 	// Handle /new and /clear commands
 	if userInput == CmdNew || userInput == CmdClear {
 		m.textarea.Reset()
 		m.updateTextareaState()
 		m.messages = []api.Message{}
-		m.renderedHistory = ""
 		m.currentContent = ""
+		m.currentReasoning = ""
+		strategy := m.session.ContextStrategy
+		m.session.Unlock()
 		m.session = config.NewSession()
-		m.session.Model = m.modelName
+		m.session.SetModelChain(m.ModelChain())
+		m.session.SystemPrompt = m.systemPrompt
+		m.session.ContextStrategy = strategy
+		m.sessionErr = nil
+		m.lockSession()
+		m.rebuildRenderedHistory()
+		m.attachments = nil
+		m.usage = api.Usage{}
+		m.answeredBy = ""
+		m.servedBy = ""
 		m.updateViewportContent()
 		return m, nil
 	}
 
+	// Collect pending and @path attachments; on error the input is kept
+	// so it can be corrected
+	attachments, err := m.messageAttachments(userInput)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	if len(attachments) > 0 && m.modelDetails == nil && m.client != nil {
+		if m.detailsFor != m.modelName {
+			// Send once the attachments can be checked against the model
+			m.awaitingDetails = true
+			m.notice = "Checking attachments against " + m.modelName + "..."
+			return m, m.loadModelDetails()
+		}
+		m.notice = "Couldn't look up " + m.modelName + "; its input types weren't checked"
+	}
+
 	// Save to history
 	m.history.Add(userInput)
 	if err := m.session.AppendHistory(userInput); err != nil {
@@ -305,20 +529,23 @@ func (m Model) handleSubmit() (tea.Model, tea.Cmd) {
 	}
 	m.history.Reset()
 
-	// Save user message to session for resume
-	if err := m.session.AppendMessage("user", userInput); err != nil {
-		m.sessionErr = err
-	} else {
-		m.sessionErr = nil // Clear on success
+	// Save user message to session for resume; attachments move into the
+	// blob store
+	msg := attachment.Message(userInput, attachments)
+	stored, err := config.NewSessionMessage(msg)
+	if err == nil {
+		err = m.session.AppendSessionMessage(stored)
 	}
+	m.sessionErr = err // Cleared on success
 
-	msg := api.Message{Role: "user", Content: userInput}
 	m.messages = append(m.messages, msg)
 	m.appendRenderedMessage(msg) // Add to rendered cache
+	m.attachments = nil
 	m.textarea.Reset()
 	m.updateTextareaState()
 	m.state = StateStreaming
 	m.currentContent = ""
+	m.currentReasoning = ""
 	m.err = nil
 
 	m.updateViewportContent()
@@ -422,10 +649,14 @@ func (m *Model) updateTextareaState() {
 
 	// Update viewport to account for new input height
 	if m.ready && m.height > 0 {
-		headerHeight := 1
-		inputBoxHeight := newHeight + 2 // textarea + border
-		footerHeight := 1
-		verticalMargins := headerHeight + inputBoxHeight + footerHeight + 1
-		m.viewport.Height = m.height - verticalMargins
+		m.viewport.Height = m.height - m.verticalMargins(newHeight)
 	}
 }
+
+// verticalMargins returns the rows taken by everything but the viewport.
+func (m *Model) verticalMargins(textareaHeight int) int {
+	headerHeight := 1
+	inputBoxHeight := textareaHeight + 2 // textarea + border
+	footerHeight := 1
+	return headerHeight + m.attachmentsHeight() + inputBoxHeight + footerHeight + 1
+}
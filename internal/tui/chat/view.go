@@ -1,11 +1,14 @@
 package chat
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/vstratful/openrouter-cli/internal/api"
+	"github.com/vstratful/openrouter-cli/internal/config"
 	"github.com/vstratful/openrouter-cli/internal/tui"
 )
 
@@ -23,23 +26,45 @@ func (m Model) View() string {
 
 	// Footer - show model name and status
 	var footer string
-	modelInfo := tui.DimHelpStyle.Render(m.modelName)
+	modelInfo := tui.DimHelpStyle.Render(m.modelInfo())
+	if m.servedBy != "" {
+		modelInfo += tui.DimHelpStyle.Render(" via " + m.servedBy)
+	}
 	sep := tui.DimHelpStyle.Render(" • ")
+	if usage := m.usageInfo(); usage != "" {
+		modelInfo += sep + tui.DimHelpStyle.Render(usage)
+	}
+	if context := m.contextInfo(); context != "" {
+		modelInfo += sep + context
+	}
 
 	// Session warning (if session save failed)
 	var sessionWarning string
-	if m.sessionErr != nil {
+	switch {
+	case errors.Is(m.sessionErr, config.ErrSessionLocked):
+		sessionWarning = sep + tui.SessionWarningStyle.Render("⚠ Session open elsewhere, not saving")
+	case errors.Is(m.sessionErr, config.ErrSessionChanged):
+		sessionWarning = sep + tui.SessionWarningStyle.Render("⚠ Session changed elsewhere, not saving")
+	case m.sessionErr != nil:
 		sessionWarning = sep + tui.SessionWarningStyle.Render("⚠ Session save failed")
 	}
 
 	switch m.state {
 	case StateStreaming:
 		escHint := sep + tui.KeyHintStyle.Render("Esc") + tui.DimHelpStyle.Render(": cancel")
-		if m.currentContent == "" {
+		if m.retry != nil {
+			status := tui.FormatRetryIn(*m.retry, time.Until(m.retryAt).Seconds())
+			footer = modelInfo + sep + m.spinner.View() + " " + tui.RetryStyle.Render(status) + escHint
+		} else if m.currentContent == "" && m.currentReasoning != "" {
+			footer = modelInfo + sep + m.spinner.View() + " Reasoning..." + escHint
+		} else if m.currentContent == "" {
 			footer = modelInfo + sep + m.spinner.View() + " Thinking..." + escHint
 		} else {
 			footer = modelInfo + sep + m.spinner.View() + " Streaming..." + escHint
 		}
+	case StateCompacting:
+		escHint := sep + tui.KeyHintStyle.Render("Esc") + tui.DimHelpStyle.Render(": cancel")
+		footer = modelInfo + sep + m.spinner.View() + " Compacting..." + escHint
 	case StateEscPending:
 		// Warning state
 		escAction := "clear input"
@@ -61,6 +86,9 @@ func (m Model) View() string {
 				tui.KeyHintStyle.Render("↑↓") + tui.DimHelpStyle.Render(": history"),
 				tui.KeyHintStyle.Render("/") + tui.DimHelpStyle.Render(": commands"),
 			}
+			if m.hasReasoning() {
+				hints = append(hints, tui.KeyHintStyle.Render("Ctrl+T")+tui.DimHelpStyle.Render(": reasoning"))
+			}
 			footer = modelInfo + sep + strings.Join(hints, sep)
 		}
 	}
@@ -92,6 +120,9 @@ func (m Model) View() string {
 	} else {
 		inputBox = currentInputStyle.Width(m.width - 4).Render(m.textarea.View())
 	}
+	if len(m.attachments) > 0 {
+		inputBox = m.renderAttachments() + "\n" + inputBox
+	}
 
 	if autocompleteView != "" {
 		return fmt.Sprintf(
@@ -113,6 +144,35 @@ func (m Model) View() string {
 	)
 }
 
+// modelInfo returns the model name for the footer, noting the fallback chain
+// and which model answered when it was not the primary.
+func (m *Model) modelInfo() string {
+	info := m.modelName
+	switch n := len(m.fallbacks); {
+	case n == 1:
+		info += " (+1 fallback)"
+	case n > 1:
+		info += fmt.Sprintf(" (+%d fallbacks)", n)
+	}
+	// Responses may report a dated variant of the requested slug
+	if m.answeredBy != "" && !strings.HasPrefix(m.answeredBy, m.modelName) {
+		info += " → " + m.answeredBy
+	}
+	return info
+}
+
+// usageInfo returns the running session token totals and cost for the footer.
+func (m *Model) usageInfo() string {
+	if m.usage.TotalTokens == 0 && m.usage.Cost == 0 {
+		return ""
+	}
+	info := fmt.Sprintf("↑%s ↓%s", tui.FormatTokenCount(m.usage.PromptTokens), tui.FormatTokenCount(m.usage.CompletionTokens))
+	if m.usage.Cost > 0 {
+		info += " " + tui.FormatCost(m.usage.Cost)
+	}
+	return info
+}
+
 // renderAutocomplete renders the autocomplete dropdown.
 func (m *Model) renderAutocomplete() string {
 	var items []string
@@ -166,31 +226,92 @@ func (m *Model) contentWidth() int {
 // renderSingleMessage renders a single message and returns the rendered string.
 func (m *Model) renderSingleMessage(msg api.Message) string {
 	var sb strings.Builder
-	if msg.Role == "user" {
+	switch msg.Role {
+	case "user":
 		sb.WriteString(tui.UserStyle.Render("You: "))
 		sb.WriteString(m.wrapText(msg.Content, m.contentWidth()-5))
-	} else {
+	case "system":
+		// Summaries of compacted messages
+		sb.WriteString(tui.HelpStyle.Render("System: "))
+		sb.WriteString(m.renderMarkdown(msg.Content, m.contentWidth()-8))
+	default:
 		sb.WriteString(tui.AssistantStyle.Render("Assistant: "))
+		if msg.Reasoning != "" {
+			sb.WriteString("\n" + m.renderReasoning(msg.Reasoning, m.showReasoning) + "\n")
+		}
 		sb.WriteString(m.renderMarkdown(msg.Content, m.contentWidth()-11))
 	}
+	if labels := attachmentLabels(msg); labels != "" {
+		sb.WriteString("\n" + tui.HelpStyle.Render(labels))
+	}
 	sb.WriteString("\n\n")
 	return sb.String()
 }
 
+// attachmentLabels summarizes the images and files attached to a message
+// and the images generated in it.
+func attachmentLabels(msg api.Message) string {
+	var labels []string
+	for _, part := range msg.ContentParts {
+		switch {
+		case part.Type == "image_url":
+			labels = append(labels, "[image]")
+		case part.Type == "file" && part.File != nil:
+			labels = append(labels, "[file: "+part.File.Filename+"]")
+		}
+	}
+	switch n := len(msg.Images); {
+	case n == 1:
+		labels = append(labels, "[generated image]")
+	case n > 1:
+		labels = append(labels, fmt.Sprintf("[%d generated images]", n))
+	}
+	return strings.Join(labels, " ")
+}
+
+// renderReasoning renders a dimmed reasoning section. Collapsed sections show
+// only a one-line summary.
+func (m *Model) renderReasoning(reasoning string, expanded bool) string {
+	if !expanded {
+		words := len(strings.Fields(reasoning))
+		return tui.ReasoningStyle.Render(fmt.Sprintf("▸ Thinking (%d words)", words))
+	}
+	header := tui.ReasoningStyle.Render("▾ Thinking")
+	body := tui.ReasoningStyle.Render(m.wrapText(strings.TrimSpace(reasoning), m.contentWidth()-2))
+	return header + "\n" + body
+}
+
+// hasReasoning returns true if any completed message has reasoning to toggle.
+func (m *Model) hasReasoning() bool {
+	for _, msg := range m.messages {
+		if msg.Reasoning != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // rebuildRenderedHistory re-renders all completed messages from scratch.
 // Called on resize or session load.
 func (m *Model) rebuildRenderedHistory() {
 	var sb strings.Builder
+	if m.systemPrompt != "" {
+		sb.WriteString(tui.HelpStyle.Render("System: "))
+		sb.WriteString(tui.ReasoningStyle.Render(m.wrapText(m.systemPrompt, m.contentWidth()-8)))
+		sb.WriteString("\n\n")
+	}
 	for _, msg := range m.messages {
 		sb.WriteString(m.renderSingleMessage(msg))
 	}
 	m.renderedHistory = sb.String()
+	m.countTokens()
 	m.renderedWidth = m.width
 }
 
 // appendRenderedMessage renders and appends a single message to the cache.
 func (m *Model) appendRenderedMessage(msg api.Message) {
 	m.renderedHistory += m.renderSingleMessage(msg)
+	m.messageTokens = append(m.messageTokens, m.tokenizer().Message(msg))
 }
 
 // updateViewportContent updates the viewport with current messages.
@@ -205,12 +326,20 @@ func (m *Model) updateViewportContent() {
 
 	if m.state == StateStreaming {
 		sb.WriteString(tui.AssistantStyle.Render("Assistant: "))
+		if m.currentReasoning != "" {
+			// Show live reasoning until the answer starts, then follow the toggle
+			expanded := m.showReasoning || m.currentContent == ""
+			sb.WriteString("\n" + m.renderReasoning(m.currentReasoning, expanded) + "\n")
+		}
 		if m.currentContent != "" {
 			sb.WriteString(m.renderMarkdown(m.currentContent, m.contentWidth()-11))
 		}
 		sb.WriteString("▋")
 	}
 
+	if m.notice != "" {
+		sb.WriteString(tui.HelpStyle.Render(m.notice) + "\n")
+	}
 	if m.err != nil {
 		sb.WriteString(tui.ErrorStyle.Render("Error: "+m.err.Error()) + "\n")
 	}
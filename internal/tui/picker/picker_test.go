@@ -1,9 +1,11 @@
 package picker
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/vstratful/openrouter-cli/internal/api"
 	"github.com/vstratful/openrouter-cli/internal/config"
 )
@@ -383,3 +385,138 @@ type testError struct {
 func (e *testError) Error() string {
 	return e.msg
 }
+
+// useTempSessionDir keeps sessions in a temporary directory for the test.
+func useTempSessionDir(t *testing.T) {
+	t.Helper()
+	originalGetSessionDir := config.GetSessionDir
+	dir := t.TempDir()
+	config.GetSessionDir = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { config.GetSessionDir = originalGetSessionDir })
+}
+
+func TestSessionPickerDeleteAndRename(t *testing.T) {
+	useTempSessionDir(t)
+
+	var summaries []config.SessionSummary
+	for _, content := range []string{"first", "second"} {
+		s := config.NewSession()
+		if err := s.AppendMessage("user", content); err != nil {
+			t.Fatal(err)
+		}
+		summaries = append(summaries, s.Summary())
+	}
+	p := NewSessionPicker(summaries, 80, 24)
+	press := func(keys ...string) {
+		for _, k := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+			if k == "enter" {
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			}
+			p, _ = p.Update(msg)
+		}
+	}
+
+	// Any key but y cancels a delete
+	press("x", "n")
+	if len(p.List.Items()) != 2 {
+		t.Fatalf("list has %d items after a cancelled delete, want 2", len(p.List.Items()))
+	}
+
+	press("r")
+	if !p.Busy() {
+		t.Fatal("picker should be busy while renaming")
+	}
+	press("N", "o", "t", "e", "s", "enter")
+	renamed, err := config.LoadSession(summaries[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Title != "Notes" {
+		t.Errorf("Title = %q, want Notes", renamed.Title)
+	}
+	if got := GetSessionSummary(p.SelectedItem()).Title; got != "Notes" {
+		t.Errorf("list item title = %q, want Notes", got)
+	}
+
+	press("x", "y")
+	if len(p.List.Items()) != 1 {
+		t.Errorf("list has %d items after delete, want 1", len(p.List.Items()))
+	}
+	if _, err := config.LoadSession(summaries[0].ID); err == nil {
+		t.Error("deleted session can still be loaded")
+	}
+}
+
+func TestSessionPickerOpenSession(t *testing.T) {
+	useTempSessionDir(t)
+
+	s := config.NewSession()
+	if err := s.AppendMessage("user", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	p := NewSessionPicker([]config.SessionSummary{s.Summary()}, 80, 24)
+	p.SetOpen(s.ID)
+
+	for key, want := range map[string]string{
+		"x": "Error: can't delete the session open in the chat",
+		"r": "Error: can't rename the session open in the chat",
+	} {
+		p, _ = p.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		if p.Busy() {
+			t.Errorf("%s on the open session started a delete or rename", key)
+		}
+		if help := p.Help(""); help != want {
+			t.Errorf("Help() after %s on the open session = %q, want %q", key, help, want)
+		}
+		p, _ = p.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	}
+	if len(p.List.Items()) != 1 {
+		t.Errorf("list has %d items, want the open session kept", len(p.List.Items()))
+	}
+	if _, err := config.LoadSession(s.ID); err != nil {
+		t.Errorf("open session can't be loaded: %v", err)
+	}
+}
+
+func TestSessionPickerFiltersContent(t *testing.T) {
+	useTempSessionDir(t)
+
+	var summaries []config.SessionSummary
+	for _, messages := range [][]string{
+		{"Deploy steps", "Run the Migration script first."},
+		{"Lunch ideas", "Try the noodle place."},
+	} {
+		s := config.NewSession()
+		for _, content := range messages {
+			if err := s.AppendMessage("user", content); err != nil {
+				t.Fatal(err)
+			}
+		}
+		summaries = append(summaries, s.Summary())
+	}
+
+	p := NewSessionPicker(summaries, 80, 24)
+	targets := make([]string, len(p.List.Items()))
+	for i, item := range p.List.Items() {
+		targets[i] = item.FilterValue()
+	}
+	tests := []struct {
+		term string
+		want []int
+	}{
+		{term: "migration", want: []int{0}},
+		{term: "deploy SCRIPT", want: []int{0}},
+		{term: "the", want: []int{0, 1}},
+		{term: "migration noodle", want: nil},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, rank := range p.List.Filter(tt.term, targets) {
+			got = append(got, rank.Index)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("filter %q matched %v, want %v", tt.term, got, tt.want)
+		}
+	}
+}
@@ -2,14 +2,28 @@ package picker
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/vstratful/openrouter-cli/internal/config"
 )
 
-// SessionItem wraps a SessionSummary for display in a picker.
+// SessionItem wraps a SessionSummary for display in a picker. Content is
+// the text of the session's messages, so the picker can filter on it.
 type SessionItem struct {
 	Summary config.SessionSummary
+	Content string
+
+	filterValue string // FilterValue, computed once by newSessionItem
+}
+
+// newSessionItem returns an item for a session with the given content.
+func newSessionItem(summary config.SessionSummary, content string) SessionItem {
+	item := SessionItem{Summary: summary, Content: content}
+	item.filterValue = item.FilterValue()
+	return item
 }
 
 func (i SessionItem) Title() string {
@@ -17,29 +31,211 @@ func (i SessionItem) Title() string {
 }
 
 func (i SessionItem) Description() string {
+	name := fmt.Sprintf("\"%s\"", i.Summary.Preview)
+	if i.Summary.Title != "" {
+		name = i.Summary.Title
+	}
+	if len(i.Summary.Tags) > 0 {
+		name += " #" + strings.Join(i.Summary.Tags, " #")
+	}
 	if i.Summary.Model != "" {
-		return fmt.Sprintf("[%s] \"%s\" (%d messages)", i.Summary.Model, i.Summary.Preview, i.Summary.MessageCount)
+		return fmt.Sprintf("[%s] %s (%d messages)", i.Summary.Model, name, i.Summary.MessageCount)
 	}
-	return fmt.Sprintf("\"%s\" (%d messages)", i.Summary.Preview, i.Summary.MessageCount)
+	return fmt.Sprintf("%s (%d messages)", name, i.Summary.MessageCount)
 }
 
 func (i SessionItem) FilterValue() string {
-	return i.Summary.Preview
+	if i.filterValue != "" {
+		return i.filterValue
+	}
+	fields := append([]string{i.Summary.Title, i.Summary.Preview}, i.Summary.Tags...)
+	fields = append(fields, i.Content)
+	return strings.Join(strings.Fields(strings.Join(fields, " ")), " ")
 }
 
-// NewSessionPicker creates a new picker for sessions.
-func NewSessionPicker(summaries []config.SessionSummary, width, height int) Model {
+// filterSessions is the session picker's list filter. A session matches
+// when every word of the filter occurs in its title, first message, tags
+// or content, ignoring case. Matches keep their order, most recent first.
+func filterSessions(term string, targets []string) []list.Rank {
+	words := strings.Fields(strings.ToLower(term))
+	var ranks []list.Rank
+	for i, target := range targets {
+		target = strings.ToLower(target)
+		matched := true
+		for _, word := range words {
+			if !strings.Contains(target, word) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			ranks = append(ranks, list.Rank{Index: i})
+		}
+	}
+	return ranks
+}
+
+// SessionPicker is a picker for sessions that can also delete and rename
+// them: x deletes the selected session after confirmation and r edits its
+// title.
+type SessionPicker struct {
+	Model
+	open       string // ID of the session open in the chat; see SetOpen
+	confirming bool
+	renaming   bool
+	input      textinput.Model
+	err        error
+}
+
+// NewSessionPicker creates a new picker for sessions. Filtering matches
+// message content from the search index as well as titles and previews.
+func NewSessionPicker(summaries []config.SessionSummary, width, height int) SessionPicker {
+	// Without the index, filter on titles and previews only
+	texts, _ := config.SessionSearchTexts()
 	items := make([]list.Item, len(summaries))
 	for i, s := range summaries {
-		items[i] = SessionItem{Summary: s}
+		items[i] = newSessionItem(s, texts[s.ID])
 	}
 
-	return New(Config{
+	input := textinput.New()
+	input.Prompt = "Title: "
+	input.CharLimit = 100
+
+	model := New(Config{
 		Title:  "Resume a previous session",
 		Items:  items,
 		Width:  width,
 		Height: height,
 	})
+	model.List.Filter = filterSessions
+	return SessionPicker{Model: model, input: input}
+}
+
+// SetOpen marks the session open in the chat showing the picker. It can't
+// be deleted or renamed from the picker, since the chat would write it
+// again with its own copy.
+func (p *SessionPicker) SetOpen(id string) {
+	p.open = id
+}
+
+// Busy reports whether the picker is confirming a delete or editing a
+// title. Callers should pass every key to Update while it is.
+func (p SessionPicker) Busy() bool {
+	return p.confirming || p.renaming
+}
+
+// Update handles messages for the picker, including the delete and rename
+// keys.
+func (p SessionPicker) Update(msg tea.Msg) (SessionPicker, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		p.Model, cmd = p.Model.Update(msg)
+		return p, cmd
+	}
+
+	switch {
+	case p.confirming:
+		p.confirming = false
+		if key.String() == "y" {
+			p.deleteSelected()
+		}
+		return p, nil
+
+	case p.renaming:
+		switch key.String() {
+		case "enter":
+			p.renaming = false
+			p.renameSelected(strings.TrimSpace(p.input.Value()))
+			return p, nil
+		case "esc":
+			p.renaming = false
+			return p, nil
+		}
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		return p, cmd
+	}
+
+	summary := GetSessionSummary(p.SelectedItem())
+	if summary != nil && !p.IsFiltering() {
+		switch key.String() {
+		case "x":
+			p.err = nil
+			if summary.ID == p.open {
+				p.err = fmt.Errorf("can't delete the session open in the chat")
+				return p, nil
+			}
+			p.confirming = true
+			return p, nil
+		case "r":
+			p.err = nil
+			if summary.ID == p.open {
+				p.err = fmt.Errorf("can't rename the session open in the chat")
+				return p, nil
+			}
+			p.renaming = true
+			p.input.SetValue(summary.Title)
+			p.input.CursorEnd()
+			return p, p.input.Focus()
+		}
+	}
+
+	var cmd tea.Cmd
+	p.Model, cmd = p.Model.Update(msg)
+	return p, cmd
+}
+
+// deleteSelected deletes the selected session and removes it from the list.
+func (p *SessionPicker) deleteSelected() {
+	summary := GetSessionSummary(p.SelectedItem())
+	if summary == nil {
+		return
+	}
+	if err := config.DeleteSession(summary.ID); err != nil {
+		p.err = err
+		return
+	}
+	p.List.RemoveItem(p.List.GlobalIndex())
+}
+
+// renameSelected sets the selected session's title.
+func (p *SessionPicker) renameSelected(title string) {
+	item, ok := p.SelectedItem().(SessionItem)
+	if !ok {
+		return
+	}
+	session, err := config.LoadSession(item.Summary.ID)
+	if err == nil {
+		session.Title = title
+		err = session.Save()
+	}
+	if err != nil {
+		p.err = fmt.Errorf("failed to rename session: %w", err)
+		return
+	}
+	p.List.SetItem(p.List.GlobalIndex(), newSessionItem(session.Summary(), item.Content))
+}
+
+// Help returns the key help for the picker's current state, starting with
+// the caller's own keys.
+func (p SessionPicker) Help(keys string) string {
+	switch {
+	case p.confirming:
+		name := "this session"
+		if summary := GetSessionSummary(p.SelectedItem()); summary != nil {
+			name = fmt.Sprintf("%q", summary.Preview)
+			if summary.Title != "" {
+				name = fmt.Sprintf("%q", summary.Title)
+			}
+		}
+		return fmt.Sprintf("Delete %s? y: delete | any other key: cancel", name)
+	case p.renaming:
+		return p.input.View() + "  Enter: save | Esc: cancel"
+	case p.err != nil:
+		return "Error: " + p.err.Error()
+	}
+	return keys + " | x: delete | r: rename"
 }
 
 // GetSessionSummary extracts the SessionSummary from a selected item.
@@ -0,0 +1,27 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/vstratful/openrouter-cli/internal/api"
+)
+
+// FormatRetry describes a pending retry, e.g. "rate limited, retrying in 12s".
+func FormatRetry(event api.RetryEvent) string {
+	return FormatRetryIn(event, event.Delay.Seconds())
+}
+
+// FormatRetryIn describes a pending retry with the given number of seconds
+// remaining, for countdowns.
+func FormatRetryIn(event api.RetryEvent, seconds float64) string {
+	secs := int(math.Ceil(seconds))
+	if secs < 1 {
+		secs = 1
+	}
+	reason := "request failed"
+	if event.RateLimited {
+		reason = "rate limited"
+	}
+	return fmt.Sprintf("%s, retrying in %ds (attempt %d)", reason, secs, event.Attempt)
+}
@@ -54,6 +54,17 @@ var (
 
 	SessionWarningStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("#FFA500")) // Orange - warning but not error
+
+	RetryStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFA500")) // Orange - waiting, not failed
+
+	ReasoningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("245")). // Dimmed - secondary to the answer
+			Italic(true)
+
+	SearchMatchStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("3")). // Yellow - search terms in snippets
+				Bold(true)
 )
 
 // Picker styles
@@ -0,0 +1,23 @@
+package tui
+
+import "fmt"
+
+// FormatTokenCount formats a token count compactly (e.g., 950, 1.2k, 3.4M).
+func FormatTokenCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// FormatCost formats a cost in credits (USD), keeping precision for small amounts.
+func FormatCost(cost float64) string {
+	if cost > 0 && cost < 0.01 {
+		return fmt.Sprintf("$%.4f", cost)
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}